@@ -2,6 +2,7 @@ package cards
 
 import (
 	"bytes"
+	crand "crypto/rand"
 	"fmt"
 	"math/rand"
 	"sort"
@@ -92,6 +93,16 @@ func (c *Card) Values() []int {
 	return []int{RankValues[c.rank]}
 }
 
+// Rank gets the rank of the card.
+func (c *Card) Rank() Rank {
+	return c.rank
+}
+
+// Suit gets the suit of the card.
+func (c *Card) Suit() Suit {
+	return c.suit
+}
+
 // Get a plain string notation for the card, e.g. A♤ for the Ace of Spades.
 func (c *Card) Notation() string {
 	if c.faceUp {
@@ -131,35 +142,182 @@ func (c *Card) Render() string {
 //
 type Deck struct {
 	Cards []*Card
+	// The number of 52-card decks making up the deck, e.g. 6 for a casino shoe.
+	// Zero is treated as a single deck.
+	Decks int
+	// The proportion of the deck that is dealt before the cut card is reached
+	// and the deck needs shuffling, e.g. 0.75. Zero uses DefaultPenetration.
+	Penetration float64
+	// The source of randomness for unique shuffles. A time-seeded source is
+	// used if none is given.
+	Random Randomiser
+	// The seed of the last shuffle, if it had one. Shuffling a full deck with
+	// the seed puts it back in the same order.
+	Seed int64
+}
+
+// DefaultPenetration places the cut card three quarters of the way through the
+// deck.
+const DefaultPenetration = 0.75
+
+// NewShoe constructs a deck made up of several 52-card decks, with all of the
+// cards in order.
+func NewShoe(decks int) *Deck {
+	shoe := &Deck{Decks: decks}
+	shoe.Init()
+	return shoe
 }
 
-// Initialise the deck with all 52 cards in order.
+// Initialise the deck with all 52 cards in order for each of its decks.
 func (d *Deck) Init() {
 	d.Cards = []*Card{}
-	for _, s := range Suits {
-		for _, r := range Ranks {
-			d.Cards = append(d.Cards, &Card{r, s, true})
+	for i := 0; i < d.DeckCount(); i++ {
+		for _, s := range Suits {
+			for _, r := range Ranks {
+				d.Cards = append(d.Cards, &Card{r, s, true})
+			}
 		}
 	}
 }
 
+// DeckCount gets the number of 52-card decks making up the deck.
+func (d *Deck) DeckCount() int {
+	if d.Decks < 1 {
+		return 1
+	}
+	return d.Decks
+}
+
+// Size gets the number of cards in the deck when it is full.
+func (d *Deck) Size() int {
+	return d.DeckCount() * 52
+}
+
+// Dealt gets the number of cards that have been dealt from the deck since it
+// was last full.
+func (d *Deck) Dealt() int {
+	dealt := d.Size() - len(d.Cards)
+	if dealt < 0 {
+		return 0
+	}
+	return dealt
+}
+
+// NeedsShuffle sees if the cut card has been reached, i.e. enough of the deck
+// has been dealt that it should be shuffled before the next round.
+func (d *Deck) NeedsShuffle() bool {
+	penetration := d.Penetration
+	if penetration <= 0 {
+		penetration = DefaultPenetration
+	}
+	if penetration > 1 {
+		penetration = 1
+	}
+	if len(d.Cards) == 0 {
+		return true
+	}
+	return float64(d.Dealt()) >= penetration*float64(d.Size())
+}
+
 const UniqueShuffle = iota
 
 // Shuffle the deck to an order based on a seed value. UniqueShuffle can be
-// passed to get random shuffling.
+// passed to get random shuffling from the deck's Randomiser.
 func (d *Deck) Shuffle(seed int64) {
-	if seed == UniqueShuffle {
-		seed = time.Now().UnixNano()
+	if seed != UniqueShuffle {
+		d.ShuffleSeed(seed)
+		return
 	}
-	rand.Seed(seed)
-	for i := 0; i < 52; i++ {
-		r := i + rand.Intn(52-i)
+	d.Seed = UniqueShuffle
+	d.shuffle(d.randomiser())
+}
+
+// ShuffleSeed shuffles the deck to the order given by a seed value, and keeps
+// the seed. A full deck shuffled with the same seed always ends up in the same
+// order, so a shoe can be dealt again from its seed.
+func (d *Deck) ShuffleSeed(seed int64) {
+	d.Seed = seed
+	d.shuffle(NewSeededRandomiser(seed))
+}
+
+// shuffle the cards in the deck with a source of randomness.
+func (d *Deck) shuffle(random Randomiser) {
+	size := len(d.Cards)
+	for i := 0; i < size; i++ {
+		r := i + random.Intn(size-i)
 		d.Cards[r], d.Cards[i] = d.Cards[i], d.Cards[r]
 	}
 }
 
-// Pop the top card off the deck.
+// Reshuffle refills the deck and shuffles it. If the deck's Randomiser can give
+// seeds, the deck is shuffled with ShuffleSeed from a new seed drawn from it,
+// so that the shuffle can be repeated, e.g. to replay a game. Any other
+// Randomiser shuffles the deck itself, and the deck is left without a seed.
+func (d *Deck) Reshuffle() {
+	d.Init()
+	seeded, ok := d.randomiser().(SeededRandomiser)
+	if !ok {
+		d.Shuffle(UniqueShuffle)
+		return
+	}
+	seed := seeded.Int63()
+	if seed == UniqueShuffle {
+		seed++
+	}
+	d.ShuffleSeed(seed)
+}
+
+// randomiser gets the deck's Randomiser, giving it a time-seeded one if it
+// doesn't have one yet.
+func (d *Deck) randomiser() Randomiser {
+	if d.Random == nil {
+		d.Random = NewSeededRandomiser(time.Now().UnixNano())
+	}
+	return d.Random
+}
+
+// Randomiser is a source of randomness for shuffling. Each deck can have its
+// own, so that decks don't interfere with each other and can be shuffled
+// reproducibly. *rand.Rand satisfies this.
+type Randomiser interface {
+	// Intn gets a random int in the range [0, n).
+	Intn(n int) int
+}
+
+// SeededRandomiser is a Randomiser that can also give seeds for whole
+// shuffles. *rand.Rand satisfies this.
+type SeededRandomiser interface {
+	Randomiser
+	// Int63 gets a random non-negative int64.
+	Int63() int64
+}
+
+// NewSeededRandomiser constructs a math/rand randomiser from a seed value.
+// Randomisers with the same seed give the same sequence of shuffles.
+func NewSeededRandomiser(seed int64) Randomiser {
+	return rand.New(rand.NewSource(seed))
+}
+
+// CryptoRandomiser is a randomiser using the cryptographically secure
+// crypto/rand, for when shuffles must not be predictable.
+type CryptoRandomiser struct {
+}
+
+// Intn gets a random int in the range [0, n) from crypto/rand.
+func (cr CryptoRandomiser) Intn(n int) int {
+	r, err := crand.Int(crand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		panic(err)
+	}
+	return int(r.Int64())
+}
+
+// Pop the top card off the deck. If the deck has run out part way through a
+// round, it is refilled and shuffled rather than leaving nothing to deal.
 func (d *Deck) Pop() *Card {
+	if len(d.Cards) == 0 {
+		d.Reshuffle()
+	}
 	card := *d.Cards[len(d.Cards)-1]
 	d.Cards = d.Cards[:len(d.Cards)-1]
 	return &card
@@ -227,12 +385,20 @@ func (h *Hand) IsSoft() bool {
 	if h.IsBust() {
 		return false
 	}
+	hasAce := false
+	hard := 0
 	for _, card := range h.Cards {
+		if !card.faceUp {
+			continue
+		}
 		if card.rank == Ace {
-			return true
+			hasAce = true
 		}
+		hard += card.Values()[0]
 	}
-	return false
+	// The ace has to be able to count as 11 without busting, otherwise e.g.
+	// A,6,K is just a hard 17.
+	return hasAce && hard+10 <= 21
 }
 
 // HasHard17 sees if a hand has a hard 17 or greater. Dealers hit until hard 17
@@ -251,6 +417,12 @@ func (h *Hand) HasHard17() bool {
 	return false
 }
 
+// Score gets the best score for the hand, i.e. its highest score that isn't
+// bust if there is one.
+func (h *Hand) Score() int {
+	return util.MaxInt(h.Scores())
+}
+
 // WinFactor assesses whether one hand beats another, giving the multiplier for
 // calculating the winnings. E.g. 2.5 for blackjack, 2 for winning, 1 for push
 // and 0 for losing.
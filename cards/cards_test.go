@@ -35,6 +35,13 @@ func TestCard_Values(t *testing.T) {
 	}
 }
 
+// Should be able to get the rank and suit of a card.
+func TestCard_RankSuit(t *testing.T) {
+	card := NewCard(Queen, Hearts)
+	assert.Equal(t, Queen, card.Rank())
+	assert.Equal(t, Hearts, card.Suit())
+}
+
 // Cards should be able to give a readable notation as a string.
 func TestCard_Notation(t *testing.T) {
 	expected := map[*Card]string{
@@ -96,6 +103,73 @@ func TestDeck_Init(t *testing.T) {
 	assert.Equal(t, "A♤", deck.Cards[39].Notation())
 }
 
+// Initialising a shoe should add all the cards for each of its decks in order.
+func TestDeck_Init_Shoe(t *testing.T) {
+	deck := Deck{Decks: 6}
+	deck.Init()
+	assert.Len(t, deck.Cards, 312)
+	assert.Equal(t, "A♧", deck.Cards[0].Notation())
+	assert.Equal(t, "A♧", deck.Cards[52].Notation())
+	assert.Equal(t, "K♤", deck.Cards[311].Notation())
+}
+
+// Should be able to construct a shoe from several decks.
+func TestNewShoe(t *testing.T) {
+	shoe := NewShoe(8)
+	assert.Equal(t, 8, shoe.DeckCount())
+	assert.Equal(t, 416, shoe.Size())
+	assert.Len(t, shoe.Cards, 416)
+}
+
+// A deck with no deck count should be treated as a single deck.
+func TestDeck_DeckCount(t *testing.T) {
+	assert.Equal(t, 1, (&Deck{}).DeckCount())
+	assert.Equal(t, 52, (&Deck{}).Size())
+	assert.Equal(t, 2, (&Deck{Decks: 2}).DeckCount())
+}
+
+// Should be able to count the cards dealt from a deck.
+func TestDeck_Dealt(t *testing.T) {
+	deck := NewShoe(2)
+	assert.Equal(t, 0, deck.Dealt())
+	deck.Pop()
+	deck.Pop()
+	assert.Equal(t, 2, deck.Dealt())
+}
+
+// A deck should need shuffling once the cut card is reached.
+func TestDeck_NeedsShuffle(t *testing.T) {
+	deck := Deck{}
+	deck.Init()
+	assert.False(t, deck.NeedsShuffle())
+
+	// The cut card is 75% of the way through by default, i.e. after 39 cards.
+	for i := 0; i < 38; i++ {
+		deck.Pop()
+	}
+	assert.False(t, deck.NeedsShuffle())
+	deck.Pop()
+	assert.True(t, deck.NeedsShuffle())
+}
+
+// Should be able to place the cut card at a specific penetration.
+func TestDeck_NeedsShuffle_Penetration(t *testing.T) {
+	shoe := NewShoe(6)
+	shoe.Penetration = 0.5
+	for i := 0; i < 155; i++ {
+		shoe.Pop()
+	}
+	assert.False(t, shoe.NeedsShuffle())
+	shoe.Pop()
+	assert.True(t, shoe.NeedsShuffle())
+}
+
+// An empty deck always needs shuffling.
+func TestDeck_NeedsShuffle_Empty(t *testing.T) {
+	deck := Deck{Penetration: 1}
+	assert.True(t, deck.NeedsShuffle())
+}
+
 // Should be able to get an output rendering of a deck.
 func TestDeck_Render(t *testing.T) {
 	deck := Deck{}
@@ -113,6 +187,118 @@ func TestDeck_Shuffle(t *testing.T) {
 	assert.Equal(t, "8♦", deck.Cards[2].Notation())
 }
 
+// Shuffling a shoe should shuffle all of its cards without losing any.
+func TestDeck_Shuffle_Shoe(t *testing.T) {
+	shoe := NewShoe(6)
+	shoe.Shuffle(42)
+	assert.Len(t, shoe.Cards, 312)
+
+	counts := map[string]int{}
+	for _, card := range shoe.Cards {
+		counts[card.Notation()]++
+	}
+	assert.Len(t, counts, 52)
+	for _, count := range counts {
+		assert.Equal(t, 6, count)
+	}
+
+	// The last cards of the shoe should not stay in order.
+	ordered := NewShoe(6)
+	assert.NotEqual(t, ordered.Cards[300:], shoe.Cards[300:])
+}
+
+// Shuffling should use the deck's own randomiser when one is given.
+func TestDeck_Shuffle_Randomiser(t *testing.T) {
+	deck := Deck{Random: &scriptedRandomiser{}}
+	deck.Init()
+	deck.Shuffle(UniqueShuffle)
+
+	// Always picking the current card leaves the deck in order.
+	ordered := Deck{}
+	ordered.Init()
+	assert.Equal(t, ordered.Cards, deck.Cards)
+}
+
+// Decks with their own seeded randomisers should shuffle reproducibly,
+// regardless of other decks being shuffled in between.
+func TestDeck_Shuffle_SeededRandomiser(t *testing.T) {
+	first := Deck{Random: NewSeededRandomiser(42)}
+	first.Init()
+	second := Deck{Random: NewSeededRandomiser(42)}
+	second.Init()
+
+	first.Shuffle(UniqueShuffle)
+	other := Deck{}
+	other.Init()
+	other.Shuffle(UniqueShuffle)
+	second.Shuffle(UniqueShuffle)
+	assert.Equal(t, first.Cards, second.Cards)
+
+	first.Shuffle(UniqueShuffle)
+	second.Shuffle(UniqueShuffle)
+	assert.Equal(t, first.Cards, second.Cards)
+}
+
+// Reshuffling should keep a seed that puts a full deck in the same order.
+func TestDeck_Reshuffle(t *testing.T) {
+	deck := Deck{Decks: 2, Random: NewSeededRandomiser(42)}
+	deck.Reshuffle()
+	assert.Len(t, deck.Cards, 104)
+	assert.NotEqual(t, int64(UniqueShuffle), deck.Seed)
+
+	again := Deck{Decks: 2}
+	again.Init()
+	again.ShuffleSeed(deck.Seed)
+	assert.Equal(t, deck.Cards, again.Cards)
+}
+
+// Should be able to shuffle with crypto/rand.
+func TestDeck_Shuffle_CryptoRandomiser(t *testing.T) {
+	deck := Deck{Random: CryptoRandomiser{}}
+	deck.Init()
+	deck.Shuffle(UniqueShuffle)
+	assert.Len(t, deck.Cards, 52)
+	for i := 0; i < 100; i++ {
+		r := CryptoRandomiser{}.Intn(3)
+		assert.True(t, r >= 0 && r < 3)
+	}
+}
+
+// Reshuffling with a randomiser that can't give seeds, like crypto/rand, should
+// shuffle with it card by card rather than through a seed drawn from it.
+func TestDeck_Reshuffle_CryptoRandomiser(t *testing.T) {
+	random := &countingRandomiser{random: CryptoRandomiser{}}
+	deck := Deck{Decks: 2, Random: random}
+	deck.Reshuffle()
+	assert.Len(t, deck.Cards, 104)
+	assert.Equal(t, int64(UniqueShuffle), deck.Seed)
+	assert.Equal(t, 104, random.calls)
+	assert.Equal(t, 104, random.largest)
+}
+
+// A randomiser that counts how it is used.
+type countingRandomiser struct {
+	random  Randomiser
+	calls   int
+	largest int
+}
+
+func (cr *countingRandomiser) Intn(n int) int {
+	cr.calls++
+	if n > cr.largest {
+		cr.largest = n
+	}
+	return cr.random.Intn(n)
+}
+
+// A randomiser that always gives the lowest possible value.
+type scriptedRandomiser struct {
+}
+
+func (sr *scriptedRandomiser) Intn(n int) int {
+	return 0
+}
+
 // Should be able to pop the top card off the deck.
 func TestDeck_Pop(t *testing.T) {
 	deck := Deck{}
@@ -122,6 +308,13 @@ func TestDeck_Pop(t *testing.T) {
 	assert.Equal(t, "Q♤", deck.Pop().Notation())
 }
 
+// Popping from an empty deck should refill it rather than failing.
+func TestDeck_Pop_Empty(t *testing.T) {
+	deck := Deck{}
+	assert.NotNil(t, deck.Pop())
+	assert.Len(t, deck.Cards, 51)
+}
+
 // Forcing the next card to be a card currently in the deck should bring it to
 // the top.
 func TestDeck_ForceNext(t *testing.T) {
@@ -232,6 +425,32 @@ func TestHand_IsSoft(t *testing.T) {
 	hand.Hit(NewCard(Queen, Hearts))
 	hand.Hit(NewCard(Ten, Spades))
 	assert.False(t, hand.IsSoft(), "Hand of 5,Q,X should be soft.")
+
+	hand = Hand{}
+	hand.Hit(NewCard(Ace, Clubs))
+	hand.Hit(NewCard(Six, Hearts))
+	hand.Hit(NewCard(King, Spades))
+	assert.False(t, hand.IsSoft(), "Hand of A,6,K should not be soft.")
+
+	hand = Hand{}
+	hand.Hit(NewCard(Ace, Clubs))
+	hand.Hit(NewCard(Ace, Hearts))
+	hand.Hit(NewCard(Nine, Spades))
+	assert.True(t, hand.IsSoft(), "Hand of A,A,9 should be soft.")
+}
+
+// Should be able to get the best score for a hand.
+func TestHand_Score(t *testing.T) {
+	hand := Hand{}
+	hand.Hit(NewCard(Ace, Clubs))
+	hand.Hit(NewCard(Six, Hearts))
+	assert.Equal(t, 17, hand.Score())
+
+	hand.Hit(NewCard(King, Spades))
+	assert.Equal(t, 17, hand.Score())
+
+	hand.Hit(NewCard(Nine, Spades))
+	assert.Equal(t, 26, hand.Score())
 }
 
 // A hand should know if it has hard 17 or higher.
@@ -273,6 +492,13 @@ func TestHand_HasHard17(t *testing.T) {
 	hand.Hit(NewCard(Ace, Diamonds))
 	hand.Hit(NewCard(King, Hearts))
 	assert.True(t, hand.HasHard17(), "Hand of A,K should have hard 17.")
+
+	// 17 with an ace counted as 1 has hard 17.
+	hand = Hand{}
+	hand.Hit(NewCard(Ace, Diamonds))
+	hand.Hit(NewCard(Six, Hearts))
+	hand.Hit(NewCard(King, Hearts))
+	assert.True(t, hand.HasHard17(), "Hand of A,6,K should have hard 17.")
 }
 
 // Should be able to tell if a hand is allowed to be split.
@@ -0,0 +1,173 @@
+package counting
+
+import (
+	"strings"
+
+	"github.com/hughgrigg/blackjack/cards"
+)
+
+//
+// Systems
+//
+
+// System is a card counting system, which tags each card value with an amount
+// to add to the running count when a card of that value is seen.
+type System struct {
+	Name string
+	// The tag for each card value, indexed 1 (ace) to 10.
+	Tags [11]float64
+}
+
+// The counting systems on offer.
+var (
+	HiLo = System{
+		Name: "Hi-Lo",
+		Tags: [11]float64{1: -1, 2: 1, 3: 1, 4: 1, 5: 1, 6: 1, 10: -1},
+	}
+	KO = System{
+		Name: "KO",
+		Tags: [11]float64{1: -1, 2: 1, 3: 1, 4: 1, 5: 1, 6: 1, 7: 1, 10: -1},
+	}
+	OmegaII = System{
+		Name: "Omega II",
+		Tags: [11]float64{2: 1, 3: 1, 4: 2, 5: 2, 6: 2, 7: 1, 9: -1, 10: -2},
+	}
+	Zen = System{
+		Name: "Zen",
+		Tags: [11]float64{1: -1, 2: 1, 3: 1, 4: 2, 5: 2, 6: 2, 7: 1, 10: -2},
+	}
+	WongHalves = System{
+		Name: "Wong Halves",
+		Tags: [11]float64{
+			1: -1, 2: 0.5, 3: 1, 4: 1, 5: 1.5, 6: 1, 7: 0.5, 9: -0.5, 10: -1,
+		},
+	}
+)
+
+// Systems lists every counting system on offer.
+var Systems = []System{HiLo, KO, OmegaII, Zen, WongHalves}
+
+// Find gets a counting system by its name, ignoring case, spaces and dashes so
+// that e.g. "hilo" finds Hi-Lo.
+func Find(name string) (System, bool) {
+	for _, system := range Systems {
+		if simplify(system.Name) == simplify(name) {
+			return system, true
+		}
+	}
+	return System{}, false
+}
+
+// simplify puts a system name in lower case without spaces or dashes.
+func simplify(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(name))
+}
+
+// Tag gets the amount a card adds to the running count.
+func (s System) Tag(card *cards.Card) float64 {
+	return s.Tags[card.Values()[0]]
+}
+
+// deckTotal gets the sum of the tags of every card in a 52-card deck.
+func (s System) deckTotal() float64 {
+	sum := 0.0
+	for _, rank := range cards.Ranks {
+		sum += 4 * s.Tags[cards.RankValues[rank]]
+	}
+	return sum
+}
+
+// Balanced sees if the tags of a full deck sum to zero, so that the running
+// count ends a shoe where it started. Unbalanced systems like KO are played
+// from the running count alone, without converting it to a true count.
+func (s System) Balanced() bool {
+	return s.deckTotal() == 0
+}
+
+// InitialCount gets the running count at the start of a shoe. Balanced systems
+// start at zero. Unbalanced systems start low enough to finish the shoe on +4,
+// e.g. 4 - 4 × decks for KO.
+func (s System) InitialCount(decks int) float64 {
+	if s.Balanced() {
+		return 0
+	}
+	return 4 - s.deckTotal()*float64(decks)
+}
+
+//
+// Counter
+//
+
+// Counter keeps the count for a shoe as its cards are seen. It can watch a
+// board to see cards as they are dealt and turned over.
+type Counter struct {
+	System System
+	// The number of 52-card decks in the shoe.
+	Decks   int
+	running float64
+	// The cards already counted, so that a card seen twice only counts once.
+	counted map[*cards.Card]bool
+}
+
+// NewCounter makes a counter for a shoe of a number of decks, ready for the
+// start of the shoe.
+func NewCounter(system System, decks int) *Counter {
+	if decks < 1 {
+		decks = 1
+	}
+	counter := &Counter{System: system, Decks: decks}
+	counter.Shuffled()
+	return counter
+}
+
+// See counts a card if it is face up and hasn't been counted already. A face
+// down card, e.g. the dealer's hole card, is only counted once it has been
+// turned over and seen again.
+func (c *Counter) See(card *cards.Card) {
+	if card == nil || !card.IsFaceUp() || c.counted[card] {
+		return
+	}
+	c.counted[card] = true
+	c.running += c.System.Tag(card)
+}
+
+// Shuffled starts the count again for a freshly shuffled shoe.
+func (c *Counter) Shuffled() {
+	c.running = c.System.InitialCount(c.Decks)
+	c.counted = map[*cards.Card]bool{}
+}
+
+// Seen gets the number of cards counted since the shoe was shuffled.
+func (c *Counter) Seen() int {
+	return len(c.counted)
+}
+
+// RunningCount gets the sum of the tags of the cards seen so far.
+func (c *Counter) RunningCount() float64 {
+	return c.running
+}
+
+// DecksRemaining gets the number of decks yet to be seen.
+func (c *Counter) DecksRemaining() float64 {
+	return float64(c.Decks*52-c.Seen()) / 52
+}
+
+// TrueCount gets the running count per deck remaining, which is what bets and
+// plays are based on with a balanced system. The remaining decks are taken to
+// be at least half a deck, so the count doesn't run away at the end of a shoe.
+// Unbalanced systems have no true count, so their running count is given.
+func (c *Counter) TrueCount() float64 {
+	if !c.System.Balanced() {
+		return c.running
+	}
+	remaining := c.DecksRemaining()
+	if remaining < 0.5 {
+		remaining = 0.5
+	}
+	return c.running / remaining
+}
@@ -0,0 +1,142 @@
+package counting
+
+import (
+	"testing"
+
+	"github.com/hughgrigg/blackjack/cards"
+	"github.com/hughgrigg/blackjack/game"
+	"github.com/stretchr/testify/assert"
+)
+
+//
+// Systems
+//
+
+// Every system but KO should be balanced over a full deck.
+func TestSystem_Balanced(t *testing.T) {
+	for _, system := range Systems {
+		assert.Equal(t, system.Name != "KO", system.Balanced(), system.Name)
+	}
+}
+
+// KO should start below zero so that it finishes the shoe on +4.
+func TestSystem_InitialCount(t *testing.T) {
+	assert.Equal(t, 0.0, HiLo.InitialCount(6))
+	assert.Equal(t, 0.0, KO.InitialCount(1))
+	assert.Equal(t, -20.0, KO.InitialCount(6))
+}
+
+// Each system should tag cards with its own values.
+func TestSystem_Tag(t *testing.T) {
+	for _, tags := range []struct {
+		system System
+		rank   cards.Rank
+		tag    float64
+	}{
+		{HiLo, cards.Two, 1},
+		{HiLo, cards.Seven, 0},
+		{HiLo, cards.King, -1},
+		{HiLo, cards.Ace, -1},
+		{KO, cards.Seven, 1},
+		{OmegaII, cards.Five, 2},
+		{OmegaII, cards.Nine, -1},
+		{OmegaII, cards.Ace, 0},
+		{Zen, cards.Ten, -2},
+		{Zen, cards.Ace, -1},
+		{WongHalves, cards.Five, 1.5},
+		{WongHalves, cards.Nine, -0.5},
+	} {
+		assert.Equal(
+			t,
+			tags.tag,
+			tags.system.Tag(cards.NewCard(tags.rank, cards.Spades)),
+			"%s %s",
+			tags.system.Name,
+			string(tags.rank),
+		)
+	}
+}
+
+// Should be able to find systems by a loosely written name.
+func TestFind(t *testing.T) {
+	system, ok := Find("hilo")
+	assert.True(t, ok)
+	assert.Equal(t, "Hi-Lo", system.Name)
+
+	system, ok = Find("Wong-Halves")
+	assert.True(t, ok)
+	assert.Equal(t, "Wong Halves", system.Name)
+
+	_, ok = Find("Red Seven")
+	assert.False(t, ok)
+}
+
+//
+// Counter
+//
+
+// The running and true counts should follow the cards seen.
+func TestCounter_See(t *testing.T) {
+	counter := NewCounter(HiLo, 2)
+	for _, rank := range []cards.Rank{cards.Two, cards.Five, cards.Six, cards.Eight} {
+		counter.See(cards.NewCard(rank, cards.Clubs))
+	}
+	assert.Equal(t, 3.0, counter.RunningCount())
+	assert.Equal(t, 4, counter.Seen())
+	assert.InDelta(t, 1.923, counter.DecksRemaining(), 0.001)
+	assert.InDelta(t, 1.56, counter.TrueCount(), 0.01)
+}
+
+// Face down cards should only be counted once they are turned over, and a card
+// should never be counted twice.
+func TestCounter_See_FaceDown(t *testing.T) {
+	counter := NewCounter(HiLo, 1)
+	card := cards.NewCard(cards.King, cards.Hearts).FaceDown()
+
+	counter.See(card)
+	assert.Equal(t, 0.0, counter.RunningCount())
+
+	counter.See(card.FaceUp())
+	counter.See(card)
+	assert.Equal(t, -1.0, counter.RunningCount())
+	assert.Equal(t, 1, counter.Seen())
+}
+
+// A shuffle should start the count again.
+func TestCounter_Shuffled(t *testing.T) {
+	counter := NewCounter(KO, 6)
+	counter.See(cards.NewCard(cards.Three, cards.Clubs))
+	assert.Equal(t, -19.0, counter.RunningCount())
+
+	counter.Shuffled()
+	assert.Equal(t, -20.0, counter.RunningCount())
+	assert.Equal(t, 0, counter.Seen())
+}
+
+// Unbalanced systems should give their running count as the true count.
+func TestCounter_TrueCount_Unbalanced(t *testing.T) {
+	counter := NewCounter(KO, 2)
+	counter.See(cards.NewCard(cards.Seven, cards.Clubs))
+	assert.Equal(t, counter.RunningCount(), counter.TrueCount())
+}
+
+// A counter watching a board should count the hole card only once the dealer
+// turns it over.
+func TestCounter_Watch(t *testing.T) {
+	counter := NewCounter(HiLo, 1)
+	board := &game.Board{Watchers: []game.Watcher{counter}}
+	engine := game.NewEngine(board, game.DefaultRules())
+	board.Deck.Cards[51] = cards.NewCard(cards.Five, cards.Clubs)  // dealer 1
+	board.Deck.Cards[50] = cards.NewCard(cards.Ten, cards.Clubs)   // player 1
+	board.Deck.Cards[49] = cards.NewCard(cards.Ten, cards.Clubs)   // dealer 2
+	board.Deck.Cards[48] = cards.NewCard(cards.Nine, cards.Clubs)  // player 2
+	board.Deck.Cards[47] = cards.NewCard(cards.Eight, cards.Clubs) // dealer 3
+
+	engine.Execute(0, game.Command{Type: game.CommandDeal})
+	assert.Equal(t, 0.0, counter.RunningCount())
+	assert.Equal(t, 3, counter.Seen())
+
+	engine.Execute(0, game.Command{Type: game.CommandStand})
+	assert.Equal(t, -1.0, counter.RunningCount())
+	assert.Equal(t, 5, counter.Seen())
+}
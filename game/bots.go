@@ -0,0 +1,243 @@
+package game
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//
+// Bots
+//
+
+// MimicDealer is a simple bot that plays its hands the way the dealer has to,
+// hitting below 17 and standing otherwise. It never takes insurance or
+// surrenders.
+type MimicDealer struct {
+}
+
+// Choose hits or stands like the dealer, or declines whatever else is on offer.
+func (md MimicDealer) Choose(board *Board, actions ActionSet) string {
+	if _, ok := actions["h"]; ok && board.Player.ActiveBet().Hand.Score() < 17 {
+		return "h"
+	}
+	for _, key := range []string{"s", "n", "c"} {
+		if _, ok := actions[key]; ok {
+			return key
+		}
+	}
+	return ""
+}
+
+//
+// Process bots
+//
+
+// ProcessBot is a bot played by another program, e.g. a strategy written in
+// another language. Each time the bot has a choice to make, the program is sent
+// a line of JSON with what it can see, as a BotState, and replies with a line
+// holding the key of the action it takes, e.g. "h".
+type ProcessBot struct {
+	// How long the program has to reply before the board falls back to a
+	// default, and to exit once it's closed before it is killed.
+	Timeout time.Duration
+	// Why the program last failed to choose, if it did.
+	Err error
+
+	cmd      *exec.Cmd
+	in       io.WriteCloser
+	requests chan []byte
+	replies  chan string
+	// The error that ended the replies, once they have ended.
+	readErr error
+	// The number of replies still to come to requests that timed out, which
+	// are skipped so the program doesn't get out of step.
+	owed   int
+	mutex  sync.Mutex
+	closed bool
+}
+
+// How long a process bot has to reply, unless told otherwise.
+const BotTimeout = 5 * time.Second
+
+// ErrBotTimeout is why a process bot failed to choose if it took too long.
+var ErrBotTimeout = errors.New("the bot's program took too long to reply")
+
+// BotState is what a process bot is sent when it has a choice to make.
+type BotState struct {
+	Stage   string  `json:"stage"`
+	Seat    string  `json:"seat"`
+	Balance float64 `json:"balance"`
+	// The hand being played, and the amount bet on it.
+	Hand HandSnapshot `json:"hand"`
+	Bet  float64      `json:"bet"`
+	// The dealer's face up card, if they have one.
+	DealerUpCard string      `json:"dealer_up_card,omitempty"`
+	Actions      []BotAction `json:"actions"`
+}
+
+// BotAction is an action a process bot can take, with the key that takes it.
+type BotAction struct {
+	Key         string      `json:"key"`
+	Description string      `json:"description"`
+	Command     CommandType `json:"command"`
+}
+
+// StartProcessBot starts a program to play as a bot, talking to it over its
+// standard input and output.
+func StartProcessBot(cmd *exec.Cmd) (*ProcessBot, error) {
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	pb := &ProcessBot{
+		Timeout:  BotTimeout,
+		cmd:      cmd,
+		in:       in,
+		requests: make(chan []byte),
+		replies:  make(chan string),
+	}
+
+	// Writing and reading happen on their own, so that a program that hangs
+	// can't hold the board up.
+	go func() {
+		for request := range pb.requests {
+			in.Write(request)
+		}
+	}()
+	go func() {
+		lines := bufio.NewScanner(out)
+		for lines.Scan() {
+			pb.replies <- strings.TrimSpace(lines.Text())
+		}
+		pb.readErr = lines.Err()
+		if pb.readErr == nil {
+			pb.readErr = io.ErrUnexpectedEOF
+		}
+		close(pb.replies)
+	}()
+	return pb, nil
+}
+
+// Choose sends the program what the seat can see and gets the key it chooses.
+// If the program can't be reached, nothing is chosen and the board falls back
+// to a default.
+func (pb *ProcessBot) Choose(board *Board, actions ActionSet) string {
+	pb.mutex.Lock()
+	defer pb.mutex.Unlock()
+	if pb.closed {
+		return ""
+	}
+
+	player := board.Player
+	bet := player.ActiveBet()
+	state := BotState{
+		Stage:   StageName(board.Stage),
+		Seat:    player.Name,
+		Balance: floatOf(player.Balance),
+		Hand:    snapshotHand(bet.Hand),
+		Bet:     floatOf(bet.amount),
+		Actions: []BotAction{},
+	}
+	if up := board.Dealer.UpCard(); up != nil {
+		state.DealerUpCard = up.Notation()
+	}
+	keys := []string{}
+	for key := range actions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		state.Actions = append(state.Actions, BotAction{
+			key,
+			actions[key].Description,
+			actions[key].Command,
+		})
+	}
+
+	line, err := json.Marshal(state)
+	if err != nil {
+		return pb.fail(board, err)
+	}
+	deadline := time.After(pb.Timeout)
+	select {
+	case pb.requests <- append(line, '\n'):
+	case <-deadline:
+		return pb.fail(board, ErrBotTimeout)
+	}
+	for {
+		select {
+		case reply, ok := <-pb.replies:
+			if !ok {
+				return pb.fail(board, pb.readErr)
+			}
+			if pb.owed > 0 {
+				pb.owed--
+				continue
+			}
+			return reply
+		case <-deadline:
+			pb.owed++
+			return pb.fail(board, ErrBotTimeout)
+		}
+	}
+}
+
+// fail records why the program failed to choose, noting it in the game log if
+// it's something new, and chooses nothing so that the board falls back to a
+// default.
+func (pb *ProcessBot) fail(board *Board, err error) string {
+	if pb.Err == nil || pb.Err.Error() != err.Error() {
+		board.Log.Push(fmt.Sprintf(
+			"[%s's program failed: %s](fg-red)",
+			board.Player.Name,
+			err,
+		))
+	}
+	pb.Err = err
+	return ""
+}
+
+// Close ends the program by closing its input, and waits for it to exit. If it
+// doesn't finish its output in time, it is killed.
+func (pb *ProcessBot) Close() error {
+	pb.mutex.Lock()
+	defer pb.mutex.Unlock()
+	if pb.closed {
+		return nil
+	}
+	pb.closed = true
+	close(pb.requests)
+	pb.in.Close()
+
+	// The program's output has to be read to the end before waiting for it,
+	// as waiting closes the pipe. Replies no longer matter, so they're thrown
+	// away.
+	read := make(chan struct{})
+	go func() {
+		for range pb.replies {
+		}
+		close(read)
+	}()
+	select {
+	case <-read:
+	case <-time.After(pb.Timeout):
+		pb.cmd.Process.Kill()
+		<-read
+	}
+	return pb.cmd.Wait()
+}
@@ -0,0 +1,210 @@
+package game
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/hughgrigg/blackjack/cards"
+	"github.com/stretchr/testify/assert"
+)
+
+//
+// Bots
+//
+
+// The dealer mimicking bot should hit below 17 and stand otherwise.
+func TestMimicDealer_Choose(t *testing.T) {
+	board := &Board{}
+	board.Begin(0, DefaultRules())
+	actions := PlayerStage{}.Actions(board)
+	bot := MimicDealer{}
+
+	board.Player.ActiveBet().Hand.Hit(cards.NewCard(cards.Ten, cards.Clubs).FaceUp())
+	board.Player.ActiveBet().Hand.Hit(cards.NewCard(cards.Six, cards.Clubs).FaceUp())
+	assert.Equal(t, "h", bot.Choose(board, actions))
+
+	board.Player.ActiveBet().Hand.Hit(cards.NewCard(cards.Ace, cards.Clubs).FaceUp())
+	assert.Equal(t, "s", bot.Choose(board, actions))
+}
+
+// The dealer mimicking bot should decline insurance and early surrender.
+func TestMimicDealer_Choose_Declines(t *testing.T) {
+	board := &Board{}
+	board.Begin(0, DefaultRules())
+	bot := MimicDealer{}
+
+	assert.Equal(t, "n", bot.Choose(board, Insurance{}.Actions(board)))
+	assert.Equal(t, "c", bot.Choose(board, EarlySurrender{}.Actions(board)))
+}
+
+// Not a test in itself: plays as a process bot over stdin and stdout, hitting
+// below 17, when run by the process bot tests.
+func TestProcessBot_Program(t *testing.T) {
+	switch os.Getenv("BLACKJACK_TEST_BOT") {
+	case "":
+		return
+	case "silent":
+		time.Sleep(time.Hour)
+	case "chatty":
+		// Carry on writing after the input has closed.
+		io.Copy(io.Discard, os.Stdin)
+		for i := 0; i < 100000; i++ {
+			fmt.Println("s")
+		}
+		os.Exit(0)
+	case "slow":
+		// Reply with how many choices have been asked for, taking a while.
+		lines := bufio.NewScanner(os.Stdin)
+		for asked := 1; lines.Scan(); asked++ {
+			time.Sleep(200 * time.Millisecond)
+			fmt.Println(asked)
+		}
+		os.Exit(0)
+	}
+	lines := bufio.NewScanner(os.Stdin)
+	for lines.Scan() {
+		state := BotState{}
+		json.Unmarshal(lines.Bytes(), &state)
+		key := ""
+		for _, action := range state.Actions {
+			switch {
+			case action.Command == CommandHit && state.Hand.Score < 17,
+				action.Command == CommandStand && key == "",
+				action.Command == CommandDeclineInsurance:
+				key = action.Key
+			}
+		}
+		if os.Getenv("BLACKJACK_TEST_BOT") == "state" {
+			fmt.Fprintln(os.Stderr, lines.Text())
+		}
+		fmt.Println(key)
+	}
+	os.Exit(0)
+}
+
+// Start this test binary as a process bot.
+func startTestBot(t *testing.T, mode string, stderr io.Writer) *ProcessBot {
+	cmd := exec.Command(os.Args[0], "-test.run=TestProcessBot_Program")
+	cmd.Env = append(os.Environ(), "BLACKJACK_TEST_BOT="+mode)
+	cmd.Stderr = stderr
+	bot, err := StartProcessBot(cmd)
+	assert.NoError(t, err)
+	return bot
+}
+
+// A process bot should be sent what the seat can see and take the action the
+// program chooses.
+func TestProcessBot_Choose(t *testing.T) {
+	stderr := &bytes.Buffer{}
+	bot := startTestBot(t, "state", stderr)
+	board := &Board{}
+	board.Begin(0, DefaultRules())
+	board.Dealer.hand.Hit(cards.NewCard(cards.Ten, cards.Clubs))
+	board.Dealer.hand.Hit(cards.NewCard(cards.Six, cards.Clubs).FaceDown())
+	actions := PlayerStage{}.Actions(board)
+
+	board.Player.ActiveBet().Hand.Hit(cards.NewCard(cards.Ten, cards.Hearts))
+	board.Player.ActiveBet().Hand.Hit(cards.NewCard(cards.Six, cards.Clubs))
+	assert.Equal(t, "h", bot.Choose(board, actions))
+
+	board.Player.ActiveBet().Hand.Hit(cards.NewCard(cards.Ace, cards.Clubs))
+	assert.Equal(t, "s", bot.Choose(board, actions))
+	assert.NoError(t, bot.Close())
+	assert.NoError(t, bot.Err)
+
+	state := BotState{}
+	assert.NoError(t, json.Unmarshal(bytes.Split(stderr.Bytes(), []byte("\n"))[0], &state))
+	assert.Equal(t, BotState{
+		Stage:        "betting",
+		Seat:         "Player",
+		Balance:      95,
+		Hand:         HandSnapshot{Cards: []string{"X♥", "6♧"}, Score: 16},
+		Bet:          5,
+		DealerUpCard: "X♧",
+		Actions:      state.Actions,
+	}, state)
+	assert.Contains(t, state.Actions, BotAction{"h", "Hit", CommandHit})
+	assert.Equal(t, "", bot.Choose(board, actions))
+}
+
+// A process bot should play whole rounds on a board, and the board should fall
+// back to a default if the program goes away.
+func TestProcessBot_Board(t *testing.T) {
+	bot := startTestBot(t, "play", nil)
+	engine := NewEngine(&Board{
+		Deck:    &cards.Deck{Random: cards.NewSeededRandomiser(5)},
+		Players: []*Player{NewPlayer("Ann", 100, nil), NewPlayer("Bot", 100, bot)},
+	}, DefaultRules())
+	board := engine.Board
+	for round := 0; round < 20; round++ {
+		if round == 10 {
+			assert.NoError(t, bot.Close())
+		}
+		engine.Execute(0, Command{Type: CommandDeal})
+		for i := 0; i < 10 && StageName(board.Stage) != "conclusion"; i++ {
+			command := CommandStand
+			if StageName(board.Stage) == "insurance" {
+				command = CommandDeclineInsurance
+			}
+			assert.NoError(t, engine.Execute(0, Command{Type: command}))
+		}
+		assert.Equal(t, "conclusion", StageName(board.Stage))
+		for _, bet := range board.Players[1].Bets {
+			assert.True(t, bet.IsFinished())
+		}
+		engine.Execute(0, Command{Type: CommandNewRound})
+	}
+	assert.NoError(t, bot.Err)
+}
+
+// A process bot that doesn't reply in time should choose nothing, rather than
+// holding the board up, and be killed if it doesn't exit when closed.
+func TestProcessBot_Timeout(t *testing.T) {
+	bot := startTestBot(t, "silent", nil)
+	bot.Timeout = 50 * time.Millisecond
+	board := &Board{}
+	board.BeginHeadless(DefaultRules())
+
+	start := time.Now()
+	assert.Equal(t, "", bot.Choose(board, PlayerStage{}.Actions(board)))
+	assert.Equal(t, ErrBotTimeout, bot.Err)
+	assert.Contains(t, board.Log.Events()[len(board.Log.Events())-1], "took too long")
+	assert.Error(t, bot.Close(), "Killed.")
+	assert.True(t, time.Since(start) < time.Second)
+}
+
+// Closing should read the program's output to the end before waiting for it to
+// exit.
+func TestProcessBot_Close(t *testing.T) {
+	bot := startTestBot(t, "chatty", nil)
+	assert.NoError(t, bot.Close())
+	select {
+	case _, open := <-bot.replies:
+		assert.False(t, open)
+		assert.Equal(t, io.ErrUnexpectedEOF, bot.readErr)
+	default:
+		t.Error("the program's output is still being read")
+	}
+}
+
+// Replies that come too late should be skipped, so that the program doesn't
+// get out of step with the board.
+func TestProcessBot_Late(t *testing.T) {
+	bot := startTestBot(t, "slow", nil)
+	bot.Timeout = 50 * time.Millisecond
+	board := &Board{}
+	board.BeginHeadless(DefaultRules())
+	actions := PlayerStage{}.Actions(board)
+
+	assert.Equal(t, "", bot.Choose(board, actions))
+	bot.Timeout = 5 * time.Second
+	assert.Equal(t, "2", bot.Choose(board, actions))
+	assert.NoError(t, bot.Close())
+}
@@ -0,0 +1,234 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+//
+// Engine
+//
+
+// Engine drives a headless board with typed commands, for tests, bots and
+// servers. Each command is carried out straight away, with no action delay.
+type Engine struct {
+	Board *Board
+}
+
+// NewEngine begins a headless board under a set of house rules and wraps it in
+// an engine. As with Begin, the deck and seats can be given to the board
+// beforehand, and the rules must be valid.
+func NewEngine(board *Board, rules Rules) *Engine {
+	board.BeginHeadless(rules)
+	return &Engine{board}
+}
+
+// Submit carries out a command for a seat, returning a snapshot of the board
+// afterwards along with an error if the command was not allowed.
+func (e *Engine) Submit(seat int, command Command) (Snapshot, error) {
+	err := e.submit(seat, command)
+	return e.Board.Snapshot(), err
+}
+
+// Execute carries out a command for a seat like Submit, but without taking a
+// snapshot, e.g. for simulations that only need the outcome.
+func (e *Engine) Execute(seat int, command Command) error {
+	return e.submit(seat, command)
+}
+
+// Snapshot gets the current state of the engine's board.
+func (e *Engine) Snapshot() Snapshot {
+	return e.Board.Snapshot()
+}
+
+// Act takes the action a key stands for at the current stage for a seat, as if
+// the key were pressed at the keyboard, e.g. "h" to hit.
+func (e *Engine) Act(seat int, key string) error {
+	if _, err := e.turn(seat); err != nil {
+		return err
+	}
+	action, ok := e.Board.Stage.Actions(e.Board)[key]
+	if !ok {
+		return ErrNoSuchAction
+	}
+	return e.Board.Take(action)
+}
+
+// Actions gets the actions a seat can take at the current stage, keyed as they
+// are at the keyboard. A seat has none while it waits for its turn.
+func (e *Engine) Actions(seat int) ActionSet {
+	b := e.Board
+	if seat < 0 || seat >= len(b.Players) {
+		return ActionSet{}
+	}
+	if anySeatActs(b.Stage) {
+		// Look from the seat's point of view without giving it the turn.
+		current := b.Player
+		b.Player = b.Players[seat]
+		defer func() { b.Player = current }()
+	}
+	if b.Player != b.Players[seat] {
+		return ActionSet{}
+	}
+	return b.Stage.Actions(b)
+}
+
+// turn gives a seat the turn if any seat can act at the current stage, and
+// gets its player if it's the seat's turn.
+func (e *Engine) turn(seat int) (*Player, error) {
+	b := e.Board
+	if seat < 0 || seat >= len(b.Players) {
+		return nil, ErrNoSuchSeat
+	}
+	player := b.Players[seat]
+	if anySeatActs(b.Stage) {
+		b.Player = player
+	}
+	if b.Player != player {
+		return nil, ErrNotYourTurn
+	}
+	return player, nil
+}
+
+// anySeatActs sees if any seat can act at a stage rather than wait its turn,
+// i.e. to bet or start a new round.
+func anySeatActs(stage Stage) bool {
+	switch stage.(type) {
+	case Betting, *Betting, Conclusion, *Conclusion:
+		return true
+	}
+	return false
+}
+
+// submit carries out a command for a seat.
+func (e *Engine) submit(seat int, command Command) error {
+	b := e.Board
+	player, err := e.turn(seat)
+	if err != nil {
+		return err
+	}
+
+	if command.Type == CommandBet {
+		switch b.Stage.(type) {
+		case Betting, *Betting:
+			if err := player.PlaceBet(command.Amount); err != nil {
+				return err
+			}
+			b.betPlaced(player)
+			return nil
+		}
+		return &IllegalCommandError{command.Type, StageName(b.Stage)}
+	}
+	if command.Type == CommandInsure {
+		switch b.Stage.(type) {
+		case Insurance, *Insurance:
+			bet := player.Bets[0]
+			// A seat with blackjack is offered even money instead.
+			if bet.Hand.HasBlackJack() {
+				break
+			}
+			amount := new(big.Float).Quo(bet.amount, big.NewFloat(2))
+			if command.Amount != 0 {
+				amount = big.NewFloat(command.Amount)
+			}
+			return b.Take(insure(amount, "Insurance"))
+		}
+		return &IllegalCommandError{command.Type, StageName(b.Stage)}
+	}
+	if command.Type == CommandSideBet {
+		switch b.Stage.(type) {
+		case Betting, *Betting:
+			return b.PlaceSideBet(player, command.SideBet, command.Amount)
+		}
+		return &IllegalCommandError{command.Type, StageName(b.Stage)}
+	}
+
+	for _, action := range b.Stage.Actions(b) {
+		if action.Command == command.Type {
+			return b.Take(action)
+		}
+	}
+	return &IllegalCommandError{command.Type, StageName(b.Stage)}
+}
+
+// PlaceBet sets the player's first bet to an amount, moving the difference to
+// or from their balance.
+func (p *Player) PlaceBet(amount float64) error {
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	available := new(big.Float).Add(p.Balance, p.Bets[0].amount)
+	bet := big.NewFloat(amount)
+	if available.Cmp(bet) == -1 {
+		return ErrCannotAfford
+	}
+	p.Bets[0].amount = bet
+	p.Balance = available.Sub(available, bet)
+	return nil
+}
+
+//
+// Commands
+//
+
+// Command is a typed move a seat can make on the board.
+type Command struct {
+	Type CommandType `json:"type"`
+	// The amount to bet, for bet and side bet commands only, or to insure, up
+	// to half the bet, for insure commands. Insuring nothing takes the full
+	// half.
+	Amount float64 `json:"amount,omitempty"`
+	// Which side bet to place, for side bet commands only.
+	SideBet SideBetKind `json:"side_bet,omitempty"`
+}
+
+// CommandType is the kind of move a command makes.
+type CommandType string
+
+// The commands that can be made on the board.
+const (
+	CommandBet              CommandType = "bet"
+	CommandSideBet          CommandType = "side bet"
+	CommandRaise            CommandType = "raise"
+	CommandLower            CommandType = "lower"
+	CommandChangeSeat       CommandType = "change seat"
+	CommandDeal             CommandType = "deal"
+	CommandInsure           CommandType = "insure"
+	CommandDeclineInsurance CommandType = "decline insurance"
+	CommandEvenMoney        CommandType = "even money"
+	CommandPlayOn           CommandType = "play on"
+	CommandHit              CommandType = "hit"
+	CommandStand            CommandType = "stand"
+	CommandDouble           CommandType = "double"
+	CommandSplit            CommandType = "split"
+	CommandSurrender        CommandType = "surrender"
+	CommandNewRound         CommandType = "new round"
+)
+
+//
+// Errors
+//
+
+// Errors explaining why a command could not be carried out.
+var (
+	ErrNoSuchSeat    = errors.New("there is no such seat at the board")
+	ErrNotYourTurn   = errors.New("it is not this seat's turn")
+	ErrCannotAfford  = errors.New("the balance does not cover the bet")
+	ErrMinimumBet    = errors.New("the bet is already at the minimum")
+	ErrInvalidAmount = errors.New("bets must be for more than nothing")
+	ErrOverInsured   = errors.New("insurance can be for at most half the bet")
+	ErrNoSuchAction  = errors.New("there is no action for that key at this stage")
+)
+
+// IllegalCommandError is given for a command that is not allowed at the current
+// stage of the game, e.g. hitting during betting or doubling on three cards.
+type IllegalCommandError struct {
+	Command CommandType
+	Stage   string
+}
+
+// Error describes the illegal command.
+func (e *IllegalCommandError) Error() string {
+	return fmt.Sprintf("can't %s during the %s stage", e.Command, e.Stage)
+}
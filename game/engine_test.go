@@ -0,0 +1,174 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/hughgrigg/blackjack/cards"
+	"github.com/stretchr/testify/assert"
+)
+
+//
+// Engine
+//
+
+// Stack the engine's deck so that the next round deals the given cards to the
+// dealer and a single seat.
+func stackEngine(e *Engine, dealer1, player1, dealer2, player2 cards.Rank) {
+	e.Board.Deck.Cards[51] = cards.NewCard(dealer1, cards.Clubs)    // dealer 1
+	e.Board.Deck.Cards[50] = cards.NewCard(player1, cards.Spades)   // player 1
+	e.Board.Deck.Cards[49] = cards.NewCard(dealer2, cards.Clubs)    // dealer 2
+	e.Board.Deck.Cards[48] = cards.NewCard(player2, cards.Hearts)   // player 2
+	e.Board.Deck.Cards[47] = cards.NewCard(cards.Two, cards.Hearts) // next
+}
+
+// Should be able to play a whole round through the engine without delays.
+func TestEngine_Submit(t *testing.T) {
+	engine := NewEngine(&Board{}, DefaultRules())
+	stackEngine(engine, cards.Ten, cards.Nine, cards.Seven, cards.Seven)
+
+	snapshot, err := engine.Submit(0, Command{Type: CommandBet, Amount: 20})
+	assert.Nil(t, err)
+	assert.Equal(t, 20.0, snapshot.Seats[0].Bets[0].Amount)
+	assert.Equal(t, 80.0, snapshot.Seats[0].Balance)
+
+	snapshot, err = engine.Submit(0, Command{Type: CommandDeal})
+	assert.Nil(t, err)
+	assert.Equal(t, "player", snapshot.Stage)
+	assert.Equal(t, 0, snapshot.Turn)
+	assert.Equal(t, []string{"X♧", "🂠 ?"}, snapshot.Dealer.Cards)
+	assert.Equal(t, 16, snapshot.Seats[0].Bets[0].Hand.Score)
+	assert.Contains(t, snapshot.Commands, CommandDouble)
+
+	snapshot, err = engine.Submit(0, Command{Type: CommandHit})
+	assert.Nil(t, err)
+	assert.Equal(t, 18, snapshot.Seats[0].Bets[0].Hand.Score)
+	assert.NotContains(t, snapshot.Commands, CommandDouble)
+
+	snapshot, err = engine.Submit(0, Command{Type: CommandStand})
+	assert.Nil(t, err)
+	assert.Equal(t, "conclusion", snapshot.Stage)
+	assert.Equal(t, 17, snapshot.Dealer.Score)
+	assert.Equal(t, 120.0, snapshot.Seats[0].Balance)
+	assert.Equal(t, []CommandType{CommandNewRound}, snapshot.Commands)
+}
+
+// Insurance should be taken for any amount up to half the bet, or the full half
+// if no amount is given.
+func TestEngine_Submit_Insure(t *testing.T) {
+	engine := NewEngine(&Board{}, DefaultRules())
+	stackEngine(engine, cards.Ace, cards.Ten, cards.Nine, cards.Nine)
+	assert.NoError(t, engine.Execute(0, Command{Type: CommandBet, Amount: 10}))
+	assert.NoError(t, engine.Execute(0, Command{Type: CommandDeal}))
+
+	assert.Equal(t, ErrOverInsured, engine.Execute(0, Command{Type: CommandInsure, Amount: 6}))
+	assert.Equal(t, ErrInvalidAmount, engine.Execute(0, Command{Type: CommandInsure, Amount: -1}))
+	assert.Equal(t, "90", engine.Board.Player.Balance.String())
+	assert.Equal(t, "insurance", StageName(engine.Board.Stage))
+
+	assert.NoError(t, engine.Execute(0, Command{Type: CommandInsure, Amount: 2}))
+	assert.Equal(t, "88", engine.Board.Player.Balance.String())
+	assert.Equal(t, "player", StageName(engine.Board.Stage))
+
+	engine = NewEngine(&Board{}, DefaultRules())
+	stackEngine(engine, cards.Ace, cards.Ten, cards.Nine, cards.Nine)
+	assert.NoError(t, engine.Execute(0, Command{Type: CommandDeal}))
+	assert.NoError(t, engine.Execute(0, Command{Type: CommandInsure}))
+	assert.Equal(t, "92.5", engine.Board.Player.Balance.String())
+}
+
+// Commands that are not allowed at the current stage should be refused with an
+// error saying why.
+func TestEngine_Submit_Illegal(t *testing.T) {
+	engine := NewEngine(&Board{}, DefaultRules())
+	stackEngine(engine, cards.Ten, cards.Nine, cards.Seven, cards.Seven)
+
+	snapshot, err := engine.Submit(0, Command{Type: CommandHit})
+	assert.Equal(t, &IllegalCommandError{CommandHit, "betting"}, err)
+	assert.Equal(t, "can't hit during the betting stage", err.Error())
+	assert.Equal(t, "betting", snapshot.Stage)
+
+	engine.Submit(0, Command{Type: CommandDeal})
+	engine.Submit(0, Command{Type: CommandHit})
+
+	_, err = engine.Submit(0, Command{Type: CommandDouble})
+	assert.Equal(t, &IllegalCommandError{CommandDouble, "player"}, err)
+
+	_, err = engine.Submit(0, Command{Type: CommandBet, Amount: 10})
+	assert.Equal(t, &IllegalCommandError{CommandBet, "player"}, err)
+}
+
+// Bets the seat can't cover should be refused.
+func TestEngine_Submit_CannotAfford(t *testing.T) {
+	engine := NewEngine(&Board{}, DefaultRules())
+
+	_, err := engine.Submit(0, Command{Type: CommandBet, Amount: 101})
+	assert.Equal(t, ErrCannotAfford, err)
+
+	snapshot, err := engine.Submit(0, Command{Type: CommandBet, Amount: 100})
+	assert.Nil(t, err)
+	assert.Equal(t, 0.0, snapshot.Seats[0].Balance)
+
+	_, err = engine.Submit(0, Command{Type: CommandBet, Amount: 0})
+	assert.Equal(t, ErrInvalidAmount, err)
+	_, err = engine.Submit(0, Command{Type: CommandBet, Amount: -5})
+	assert.Equal(t, ErrInvalidAmount, err)
+}
+
+// Seats should only be able to act on their own turn.
+func TestEngine_Submit_Seats(t *testing.T) {
+	engine := NewEngine(
+		&Board{Players: []*Player{
+			NewPlayer("Ann", 100, nil),
+			NewPlayer("Bob", 100, nil),
+		}},
+		DefaultRules(),
+	)
+
+	deck := engine.Board.Deck
+	deck.Cards[51] = cards.NewCard(cards.Ten, cards.Clubs)    // dealer 1
+	deck.Cards[50] = cards.NewCard(cards.Nine, cards.Spades)  // ann 1
+	deck.Cards[49] = cards.NewCard(cards.Nine, cards.Hearts)  // bob 1
+	deck.Cards[48] = cards.NewCard(cards.Seven, cards.Clubs)  // dealer 2
+	deck.Cards[47] = cards.NewCard(cards.Seven, cards.Spades) // ann 2
+	deck.Cards[46] = cards.NewCard(cards.Eight, cards.Hearts) // bob 2
+
+	_, err := engine.Submit(2, Command{Type: CommandDeal})
+	assert.Equal(t, ErrNoSuchSeat, err)
+
+	// Any seat can deal.
+	snapshot, err := engine.Submit(1, Command{Type: CommandDeal})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, snapshot.Turn)
+
+	_, err = engine.Submit(1, Command{Type: CommandStand})
+	assert.Equal(t, ErrNotYourTurn, err)
+
+	snapshot, err = engine.Submit(0, Command{Type: CommandStand})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, snapshot.Turn)
+}
+
+// Should be able to take actions by their keys, as at the keyboard.
+func TestEngine_Act(t *testing.T) {
+	engine := NewEngine(
+		&Board{Players: []*Player{
+			NewPlayer("Ann", 100, nil),
+			NewPlayer("Bob", 100, nil),
+		}},
+		DefaultRules(),
+	)
+	stackEngine(engine, cards.Ten, cards.Nine, cards.Seven, cards.Seven)
+
+	assert.Contains(t, engine.Actions(1), "d")
+	assert.NoError(t, engine.Act(1, "r"))
+	assert.Equal(t, 10.0, engine.Snapshot().Seats[1].Bets[0].Amount)
+	assert.Equal(t, ErrNoSuchAction, engine.Act(1, "h"))
+	assert.NoError(t, engine.Act(1, "d"))
+
+	assert.Equal(t, "Hit", engine.Actions(0)["h"].Description)
+	assert.Empty(t, engine.Actions(1))
+	assert.Empty(t, engine.Actions(2))
+	assert.Equal(t, ErrNotYourTurn, engine.Act(1, "h"))
+	assert.NoError(t, engine.Act(0, "s"))
+	assert.Equal(t, 1, engine.Snapshot().Turn)
+}
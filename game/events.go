@@ -0,0 +1,382 @@
+package game
+
+import "github.com/hughgrigg/blackjack/cards"
+
+//
+// Events
+//
+
+// Event is a typed change to the state of the board, passed to the board's
+// subscribers as it happens. Folding a board's events in order over a snapshot
+// of it brings the snapshot up to date.
+type Event interface {
+	// Kind gets a short name for the type of event, e.g. "card dealt".
+	Kind() string
+	// Apply folds the event into a snapshot of the board.
+	Apply(state *Snapshot)
+}
+
+// Subscriber is told about every event on a board as it happens.
+type Subscriber interface {
+	Notify(event Event)
+}
+
+// SubscriberFunc lets a plain function subscribe to a board's events.
+type SubscriberFunc func(event Event)
+
+// Notify passes the event to the function.
+func (f SubscriberFunc) Notify(event Event) {
+	f(event)
+}
+
+// Fold brings a snapshot of the board up to date with the events that followed
+// it, without changing the snapshot given. The commands available, the number
+// of cards remaining and whether bets are finished are not carried by events,
+// so are left as they were.
+func Fold(state Snapshot, events ...Event) Snapshot {
+	folded := state
+	folded.Dealer = copyHand(state.Dealer)
+	folded.Seats = []SeatSnapshot{}
+	for _, seat := range state.Seats {
+		bets := []BetSnapshot{}
+		for _, bet := range seat.Bets {
+			bet.Hand = copyHand(bet.Hand)
+			bets = append(bets, bet)
+		}
+		seat.Bets = bets
+		if seat.SideBets != nil {
+			seat.SideBets = append([]SideBetSnapshot{}, seat.SideBets...)
+		}
+		folded.Seats = append(folded.Seats, seat)
+	}
+	for _, event := range events {
+		event.Apply(&folded)
+	}
+	return folded
+}
+
+// The seat number given in events for the dealer.
+const DealerSeat = -1
+
+// StageChanged is when the game moves on to a new stage. Moving on to betting
+// starts a new round, clearing the table.
+type StageChanged struct {
+	Stage string `json:"stage"`
+	// The seat whose turn it is, or -1 for none.
+	Turn int `json:"turn"`
+}
+
+// BetPlaced is when a seat changes its first bet during betting.
+type BetPlaced struct {
+	Seat    int     `json:"seat"`
+	Name    string  `json:"name"`
+	Amount  float64 `json:"amount"`
+	Balance float64 `json:"balance"`
+}
+
+// CardDealt is when a card is dealt to one of a seat's hands, or to the
+// dealer. Face down cards stay hidden.
+type CardDealt struct {
+	Seat int    `json:"seat"`
+	Hand int    `json:"hand"`
+	Card string `json:"card"`
+}
+
+// HandSplit is when a seat splits one of its hands in two, the second card
+// going to a new hand straight after it.
+type HandSplit struct {
+	Seat    int     `json:"seat"`
+	Hand    int     `json:"hand"`
+	Amount  float64 `json:"amount"`
+	Balance float64 `json:"balance"`
+}
+
+// DoubledDown is when a seat doubles its bet on one of its hands.
+type DoubledDown struct {
+	Seat int `json:"seat"`
+	Hand int `json:"hand"`
+	// The bet's amount after doubling.
+	Amount  float64 `json:"amount"`
+	Balance float64 `json:"balance"`
+}
+
+// DealerRevealed is when the dealer turns over their face down card.
+type DealerRevealed struct {
+	Card string `json:"card"`
+}
+
+// BetSettled is when a bet is won, lost, pushed or surrendered.
+type BetSettled struct {
+	Seat    int     `json:"seat"`
+	Hand    int     `json:"hand"`
+	Outcome string  `json:"outcome"`
+	Staked  float64 `json:"staked"`
+	Paid    float64 `json:"paid"`
+	Balance float64 `json:"balance"`
+}
+
+// SideBetPlaced is when a seat changes one of its side bets during betting.
+// An amount of zero takes the side bet off.
+type SideBetPlaced struct {
+	Seat    int         `json:"seat"`
+	SideBet SideBetKind `json:"side_bet"`
+	Amount  float64     `json:"amount"`
+	Balance float64     `json:"balance"`
+}
+
+// SideBetSettled is when a side bet is judged on the first cards dealt, and
+// paid out if it won.
+type SideBetSettled struct {
+	Seat    int         `json:"seat"`
+	SideBet SideBetKind `json:"side_bet"`
+	// What the side bet won with, e.g. "flush", or empty if it lost.
+	Result  string  `json:"result"`
+	Staked  float64 `json:"staked"`
+	Paid    float64 `json:"paid"`
+	Balance float64 `json:"balance"`
+}
+
+// InsuranceTaken is when a seat takes insurance against dealer blackjack.
+type InsuranceTaken struct {
+	Seat    int     `json:"seat"`
+	Amount  float64 `json:"amount"`
+	Balance float64 `json:"balance"`
+}
+
+// InsuranceSettled is when a seat's insurance is paid out or lost.
+type InsuranceSettled struct {
+	Seat    int     `json:"seat"`
+	Staked  float64 `json:"staked"`
+	Paid    float64 `json:"paid"`
+	Balance float64 `json:"balance"`
+}
+
+// Kind gets the type of event.
+func (e StageChanged) Kind() string { return "stage changed" }
+
+// Kind gets the type of event.
+func (e BetPlaced) Kind() string { return "bet placed" }
+
+// Kind gets the type of event.
+func (e CardDealt) Kind() string { return "card dealt" }
+
+// Kind gets the type of event.
+func (e HandSplit) Kind() string { return "hand split" }
+
+// Kind gets the type of event.
+func (e DoubledDown) Kind() string { return "doubled down" }
+
+// Kind gets the type of event.
+func (e DealerRevealed) Kind() string { return "dealer revealed" }
+
+// Kind gets the type of event.
+func (e BetSettled) Kind() string { return "bet settled" }
+
+// Kind gets the type of event.
+func (e SideBetPlaced) Kind() string { return "side bet placed" }
+
+// Kind gets the type of event.
+func (e SideBetSettled) Kind() string { return "side bet settled" }
+
+// Kind gets the type of event.
+func (e InsuranceTaken) Kind() string { return "insurance taken" }
+
+// Kind gets the type of event.
+func (e InsuranceSettled) Kind() string { return "insurance settled" }
+
+// Apply moves the snapshot on to the stage, clearing the table for a new
+// round.
+func (e StageChanged) Apply(state *Snapshot) {
+	state.Stage = e.Stage
+	state.Turn = e.Turn
+	if e.Stage != StageName(Betting{}) {
+		return
+	}
+	state.Dealer = HandSnapshot{Cards: []string{}}
+	for i := range state.Seats {
+		state.Seats[i].Insurance = 0
+		state.Seats[i].SideBets = nil
+		state.Seats[i].Bets = []BetSnapshot{{Hand: HandSnapshot{Cards: []string{}}}}
+	}
+}
+
+// Apply sets the seat's first bet, adding the seat if it's new.
+func (e BetPlaced) Apply(state *Snapshot) {
+	for len(state.Seats) <= e.Seat {
+		state.Seats = append(state.Seats, SeatSnapshot{
+			Bets: []BetSnapshot{{Hand: HandSnapshot{Cards: []string{}}}},
+		})
+	}
+	seat := &state.Seats[e.Seat]
+	seat.Name = e.Name
+	seat.Balance = e.Balance
+	seat.Bets[0].Amount = e.Amount
+}
+
+// Apply adds the card to the hand.
+func (e CardDealt) Apply(state *Snapshot) {
+	hand := state.hand(e.Seat, e.Hand)
+	if hand == nil {
+		return
+	}
+	hand.Cards = append(hand.Cards, e.Card)
+	hand.Score = scoreOf(hand.Cards)
+}
+
+// Apply moves the hand's second card to a new hand and bet after it.
+func (e HandSplit) Apply(state *Snapshot) {
+	hand := state.hand(e.Seat, e.Hand)
+	if hand == nil || len(hand.Cards) < 2 {
+		return
+	}
+	seat := &state.Seats[e.Seat]
+	split := BetSnapshot{
+		Hand:   HandSnapshot{Cards: []string{hand.Cards[1]}},
+		Amount: e.Amount,
+	}
+	split.Hand.Score = scoreOf(split.Hand.Cards)
+	hand.Cards = hand.Cards[:1]
+	hand.Score = scoreOf(hand.Cards)
+	bets := append([]BetSnapshot{}, seat.Bets[:e.Hand+1]...)
+	bets = append(bets, split)
+	seat.Bets = append(bets, seat.Bets[e.Hand+1:]...)
+	seat.Balance = e.Balance
+}
+
+// Apply sets the doubled bet and the seat's balance.
+func (e DoubledDown) Apply(state *Snapshot) {
+	if state.hand(e.Seat, e.Hand) == nil {
+		return
+	}
+	seat := &state.Seats[e.Seat]
+	seat.Bets[e.Hand].Amount = e.Amount
+	seat.Balance = e.Balance
+}
+
+// Apply shows the dealer's face down card.
+func (e DealerRevealed) Apply(state *Snapshot) {
+	hidden := cards.NewCard(cards.Ace, cards.Spades).FaceDown().Notation()
+	for i, card := range state.Dealer.Cards {
+		if card == hidden {
+			state.Dealer.Cards[i] = e.Card
+			break
+		}
+	}
+	state.Dealer.Score = scoreOf(state.Dealer.Cards)
+}
+
+// Apply clears the settled bet and sets the seat's balance.
+func (e BetSettled) Apply(state *Snapshot) {
+	if state.hand(e.Seat, e.Hand) == nil {
+		return
+	}
+	seat := &state.Seats[e.Seat]
+	seat.Bets[e.Hand].Amount = 0
+	seat.Balance = e.Balance
+}
+
+// Apply sets the side bet and the seat's balance, keeping side bets in the
+// usual order.
+func (e SideBetPlaced) Apply(state *Snapshot) {
+	if e.Seat < 0 || e.Seat >= len(state.Seats) {
+		return
+	}
+	seat := &state.Seats[e.Seat]
+	seat.Balance = e.Balance
+	placed := map[SideBetKind]SideBetSnapshot{}
+	for _, sideBet := range seat.SideBets {
+		placed[sideBet.Kind] = sideBet
+	}
+	placed[e.SideBet] = SideBetSnapshot{Kind: e.SideBet, Amount: e.Amount}
+	seat.SideBets = nil
+	for _, kind := range SideBetKinds {
+		if sideBet, ok := placed[kind]; ok && (kind != e.SideBet || e.Amount > 0) {
+			seat.SideBets = append(seat.SideBets, sideBet)
+		}
+	}
+}
+
+// Apply settles the side bet and sets the seat's balance.
+func (e SideBetSettled) Apply(state *Snapshot) {
+	if e.Seat < 0 || e.Seat >= len(state.Seats) {
+		return
+	}
+	seat := &state.Seats[e.Seat]
+	seat.Balance = e.Balance
+	for i := range seat.SideBets {
+		if seat.SideBets[i].Kind == e.SideBet {
+			seat.SideBets[i].Result = e.Result
+			seat.SideBets[i].Settled = true
+		}
+	}
+}
+
+// Apply sets the seat's insurance and balance.
+func (e InsuranceTaken) Apply(state *Snapshot) {
+	if e.Seat < 0 || e.Seat >= len(state.Seats) {
+		return
+	}
+	state.Seats[e.Seat].Insurance = e.Amount
+	state.Seats[e.Seat].Balance = e.Balance
+}
+
+// Apply clears the seat's insurance and sets its balance.
+func (e InsuranceSettled) Apply(state *Snapshot) {
+	if e.Seat < 0 || e.Seat >= len(state.Seats) {
+		return
+	}
+	state.Seats[e.Seat].Insurance = 0
+	state.Seats[e.Seat].Balance = e.Balance
+}
+
+// hand gets one of a seat's hands in the snapshot, or the dealer's hand.
+func (s *Snapshot) hand(seat int, hand int) *HandSnapshot {
+	if seat == DealerSeat {
+		return &s.Dealer
+	}
+	if seat < 0 || seat >= len(s.Seats) ||
+		hand < 0 || hand >= len(s.Seats[seat].Bets) {
+		return nil
+	}
+	return &s.Seats[seat].Bets[hand].Hand
+}
+
+// copyHand copies a hand snapshot, so that folding into it leaves the original
+// alone.
+func copyHand(hand HandSnapshot) HandSnapshot {
+	hand.Cards = append([]string{}, hand.Cards...)
+	return hand
+}
+
+// scoreOf gets the score of a hand from the notation of its cards, leaving out
+// face down cards as the board does.
+func scoreOf(notations []string) int {
+	return HandSnapshot{Cards: notations}.Hand().Score()
+}
+
+// emit passes an event to the board's subscribers.
+func (b *Board) emit(event Event) {
+	for _, subscriber := range b.Subscribers {
+		subscriber.Notify(event)
+	}
+}
+
+// seatOf gets the number of a seat at the board, or -1 for none.
+func (b *Board) seatOf(player *Player) int {
+	for i, seat := range b.Players {
+		if seat == player {
+			return i
+		}
+	}
+	return -1
+}
+
+// betPlaced tells the subscribers about a seat's first bet.
+func (b *Board) betPlaced(player *Player) {
+	b.emit(BetPlaced{
+		Seat:    b.seatOf(player),
+		Name:    player.Name,
+		Amount:  floatOf(player.Bets[0].amount),
+		Balance: floatOf(player.Balance),
+	})
+}
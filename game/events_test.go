@@ -0,0 +1,158 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/hughgrigg/blackjack/cards"
+	"github.com/stretchr/testify/assert"
+)
+
+//
+// Events
+//
+
+// Keeps every event a board emits.
+type eventLog struct {
+	events []Event
+}
+
+func (l *eventLog) Notify(event Event) {
+	l.events = append(l.events, event)
+}
+
+// Make a headless board with a subscriber, and stack the first cards dealt from
+// its deck, the last card given being dealt first.
+func subscribedEngine(stacked ...*cards.Card) (*Engine, *eventLog) {
+	log := &eventLog{}
+	board := &Board{
+		Deck:        &cards.Deck{Random: cards.NewSeededRandomiser(1)},
+		Players:     []*Player{NewPlayer("Ann", 100, nil), NewPlayer("Bob", 50, nil)},
+		Subscribers: []Subscriber{log},
+	}
+	engine := NewEngine(board, DefaultRules())
+	deck := board.Deck.Cards
+	for i, card := range stacked {
+		deck[len(deck)-len(stacked)+i] = card
+	}
+	return engine, log
+}
+
+// Folding the events should give the board's state, apart from what events
+// don't carry.
+func assertFolded(t *testing.T, board *Board, events []Event) {
+	want := board.Snapshot()
+	got := Fold(Snapshot{}, events...)
+	for _, snapshot := range []*Snapshot{&want, &got} {
+		snapshot.Commands = nil
+		snapshot.Remaining = 0
+		for _, seat := range snapshot.Seats {
+			for i := range seat.Bets {
+				seat.Bets[i].Finished = false
+			}
+		}
+		// Any seat can bet, so the turn moves around freely.
+		if snapshot.Stage == "betting" {
+			snapshot.Turn = 0
+		}
+	}
+	assert.Equal(t, want, got)
+}
+
+// Folding the events from a board should keep up with it over several rounds.
+func TestFold(t *testing.T) {
+	engine, log := subscribedEngine()
+	board := engine.Board
+	assertFolded(t, board, log.events)
+
+	for round := 0; round < 10; round++ {
+		engine.Execute(0, Command{Type: CommandBet, Amount: 10})
+		engine.Execute(1, Command{Type: CommandRaise})
+		engine.Execute(1, Command{Type: CommandLower})
+		engine.Execute(0, Command{Type: CommandDeal})
+		assertFolded(t, board, log.events)
+		for i := 0; i < 20 && StageName(board.Stage) != "conclusion"; i++ {
+			command := CommandStand
+			switch {
+			case StageName(board.Stage) == "insurance":
+				command = CommandDeclineInsurance
+			case board.Player.ActiveBet().Hand.Score() < 12:
+				command = CommandHit
+			}
+			engine.Execute(board.seatOf(board.Player), Command{Type: command})
+			assertFolded(t, board, log.events)
+		}
+		engine.Execute(0, Command{Type: CommandNewRound})
+		assertFolded(t, board, log.events)
+	}
+}
+
+// Splitting and doubling should be folded into the seat's bets.
+func TestFold_SplitAndDouble(t *testing.T) {
+	engine, log := subscribedEngine(
+		cards.NewCard(cards.King, cards.Clubs),     // dealer 3
+		cards.NewCard(cards.Nine, cards.Diamonds),  // Ann hand 2, 3rd
+		cards.NewCard(cards.Ten, cards.Diamonds),   // Ann hand 1, 3rd
+		cards.NewCard(cards.Two, cards.Clubs),      // Ann hand 2, 2nd
+		cards.NewCard(cards.Three, cards.Diamonds), // Ann hand 1, 2nd
+		cards.NewCard(cards.Nine, cards.Spades),    // Bob 2
+		cards.NewCard(cards.Eight, cards.Hearts),   // Ann 2
+		cards.NewCard(cards.Seven, cards.Clubs),    // dealer 2
+		cards.NewCard(cards.Ten, cards.Spades),     // Bob 1
+		cards.NewCard(cards.Eight, cards.Spades),   // Ann 1
+		cards.NewCard(cards.Six, cards.Clubs),      // dealer 1
+	)
+	board := engine.Board
+	engine.Execute(0, Command{Type: CommandDeal})
+	assert.NoError(t, engine.Execute(0, Command{Type: CommandSplit}))
+	assertFolded(t, board, log.events)
+	assert.NoError(t, engine.Execute(0, Command{Type: CommandDouble}))
+	assertFolded(t, board, log.events)
+	assert.NoError(t, engine.Execute(0, Command{Type: CommandHit}))
+	assert.NoError(t, engine.Execute(0, Command{Type: CommandStand}))
+	assert.NoError(t, engine.Execute(1, Command{Type: CommandStand}))
+	assertFolded(t, board, log.events)
+
+	folded := Fold(Snapshot{}, log.events...)
+	assert.Equal(t, []string{"8♤", "3♦", "X♦"}, folded.Seats[0].Bets[0].Hand.Cards)
+	assert.Equal(t, []string{"8♥", "2♧", "9♦"}, folded.Seats[0].Bets[1].Hand.Cards)
+	assert.Equal(t, []string{"6♧", "7♧", "K♧"}, folded.Dealer.Cards)
+	assert.Equal(t, "conclusion", folded.Stage)
+}
+
+// Insurance should be folded into the seat's balance as it's taken and paid.
+func TestFold_Insurance(t *testing.T) {
+	engine, log := subscribedEngine(
+		cards.NewCard(cards.Nine, cards.Spades),  // Bob 2
+		cards.NewCard(cards.Nine, cards.Hearts),  // Ann 2
+		cards.NewCard(cards.King, cards.Clubs),   // dealer 2
+		cards.NewCard(cards.Ten, cards.Spades),   // Bob 1
+		cards.NewCard(cards.Ten, cards.Diamonds), // Ann 1
+		cards.NewCard(cards.Ace, cards.Clubs),    // dealer 1
+	)
+	board := engine.Board
+	engine.Execute(0, Command{Type: CommandBet, Amount: 10})
+	engine.Execute(0, Command{Type: CommandDeal})
+	assert.NoError(t, engine.Execute(0, Command{Type: CommandInsure}))
+	assertFolded(t, board, log.events)
+	assert.NoError(t, engine.Execute(1, Command{Type: CommandDeclineInsurance}))
+	assertFolded(t, board, log.events)
+
+	kinds := []string{}
+	for _, event := range log.events {
+		kinds = append(kinds, event.Kind())
+	}
+	assert.Contains(t, kinds, "insurance taken")
+	assert.Contains(t, kinds, "insurance settled")
+	assert.Contains(t, kinds, "dealer revealed")
+	assert.Equal(t, 100.0, Fold(Snapshot{}, log.events...).Seats[0].Balance)
+}
+
+// Folding should leave the snapshot it starts from as it was.
+func TestFold_Copies(t *testing.T) {
+	engine, log := subscribedEngine()
+	start := engine.Snapshot()
+	engine.Execute(0, Command{Type: CommandDeal})
+	Fold(start, log.events...)
+	assert.Equal(t, []string{}, start.Dealer.Cards)
+	assert.Empty(t, start.Seats[0].Bets[0].Hand.Cards)
+}
@@ -15,38 +15,73 @@ import (
 
 // The main game controller object.
 type Board struct {
-	Deck        *cards.Deck
-	Dealer      *Dealer
-	Player      *Player
+	Deck   *cards.Deck
+	Dealer *Dealer
+	// The seat whose turn it is.
+	Player *Player
+	// All of the seats at the board, in the order they are dealt to.
+	Players     []*Player
 	Log         *Log
 	Stage       Stage
+	Rules       Rules
 	actionQueue chan Action
 	wg          *sync.WaitGroup
+	// Counts stage changes, so bots know when their turn has moved on.
+	stageChanges int
+	// Told about every card dealt or turned over, and every shuffle.
+	Watchers []Watcher
+	// Told how every bet is settled.
+	Bookkeepers []Bookkeeper
+	// Records each round played, if set.
+	History *History
+	// Told about every change to the state of the board, as typed events.
+	Subscribers []Subscriber
 }
 
-// An action that can be made on the board, returning boolean success.
-type Action func(b *Board) bool
+// Watcher follows the cards as they come out of the deck, e.g. to count them.
+// Cards are passed as they are dealt, whether face up or down, and again when
+// a face down card is turned over.
+type Watcher interface {
+	See(card *cards.Card)
+	Shuffled()
+}
+
+// Bookkeeper keeps track of how bets are settled, e.g. for a player's lifetime
+// statistics. It is given the amount staked on a bet and the amount paid back,
+// including the stake itself. Insurance and side bets are settled with outcomes
+// of their own.
+type Bookkeeper interface {
+	Settle(player *Player, outcome Outcome, staked *big.Float, paid *big.Float)
+}
+
+// An action that can be made on the board, returning an error if it could not
+// be made.
+type Action func(b *Board) error
 
-// An action the player can consider taking, with a description.
+// An action the player can consider taking, with a description and the command
+// that takes it.
 type PlayerAction struct {
 	Execute     Action
 	Description string
+	Command     CommandType
 }
 
 // ActionSet is a set of player actions for a game stage.
 type ActionSet map[string]PlayerAction
 
-// Begin initialises the board and starts its action queue.
-func (b *Board) Begin(actionDelay int) *Board {
-	b.Stage = Betting{}
-	b.Log = &Log{}
-
-	b.Deck = &cards.Deck{}
-	b.Deck.Init()
-	b.Deck.Shuffle(cards.UniqueShuffle)
-
-	b.initPlayer(-1, -1)
+// Begin initialises the board under a set of house rules and starts its action
+// queue. A deck or shoe can be given to the board beforehand, otherwise one is
+// made with the number of decks the rules call for. Likewise seats can be given
+// beforehand, otherwise there is a single player. The rules must be valid, or
+// Begin panics.
+func (b *Board) Begin(actionDelay int, rules Rules) *Board {
+	b.setUp(rules)
+	return b.Resume(actionDelay)
+}
 
+// Resume starts the action queue of a board that has already been set up, e.g.
+// one loaded from a saved session, without dealing a fresh shoe.
+func (b *Board) Resume(actionDelay int) *Board {
 	// Run board actions with a more human interval so the player can keep up.
 	b.wg = &sync.WaitGroup{}
 	b.actionQueue = make(chan Action, 999)
@@ -61,19 +96,132 @@ func (b *Board) Begin(actionDelay int) *Board {
 	return b
 }
 
-// Queue up an action for the board to carry out.
+// BeginHeadless initialises the board like Begin, but without an action queue,
+// so that every action is carried out straight away.
+func (b *Board) BeginHeadless(rules Rules) *Board {
+	b.setUp(rules)
+	return b
+}
+
+// setUp initialises the board's deck, seats and stage under a set of house
+// rules, which must be valid.
+func (b *Board) setUp(rules Rules) {
+	if err := rules.Validate(); err != nil {
+		panic(fmt.Sprintf("can't set up a board: %s", err))
+	}
+	b.Stage = Betting{}
+	b.Log = &Log{}
+	b.Rules = rules
+
+	if b.Deck == nil {
+		b.Deck = &cards.Deck{Decks: rules.Decks}
+	}
+	b.Deck.Reshuffle()
+	b.shuffled()
+
+	if len(b.Players) == 0 {
+		b.initPlayer(-1, -1)
+	} else {
+		// Seats given beforehand start off betting what they can.
+		b.resetHands(0)
+		for _, player := range b.Players {
+			player.Raise(5)
+		}
+		b.Player = b.Players[0]
+	}
+	b.emit(StageChanged{StageName(b.Stage), b.seatOf(b.Player)})
+	for _, player := range b.Players {
+		b.betPlaced(player)
+	}
+}
+
+// Queue up an action for the board to carry out, or carry it out straight away
+// if the board is headless.
 func (b *Board) action(a Action) *Board {
+	if b.actionQueue == nil {
+		a(b)
+		return b
+	}
 	b.wg.Add(1)
 	b.actionQueue <- a
 	return b
 }
 
 func (b *Board) Wait() *Board {
-	b.wg.Wait()
+	if b.wg != nil {
+		b.wg.Wait()
+	}
 	return b
 }
 
-// Initialise the dealer's and player's hands.
+// draw takes the next card from the deck and shows it to the watchers. The
+// watchers are told if the deck had run out and was shuffled to draw it.
+func (b *Board) draw(faceUp bool) *cards.Card {
+	refilled := len(b.Deck.Cards) == 0
+	card := b.Deck.Pop()
+	if refilled {
+		b.shuffled()
+		b.History.record(HandEvent{Type: EventShuffle, Seed: b.Deck.Seed})
+	}
+	if faceUp {
+		card.FaceUp()
+	} else {
+		card.FaceDown()
+	}
+	b.show(card)
+	return card
+}
+
+// show passes a card to the watchers.
+func (b *Board) show(card *cards.Card) {
+	for _, watcher := range b.Watchers {
+		watcher.See(card)
+	}
+}
+
+// settle tells the bookkeepers and subscribers how a bet was settled, and
+// records it in the hand history.
+func (b *Board) settle(player *Player, bet *Bet, outcome Outcome, staked *big.Float, paid *big.Float) {
+	b.History.record(HandEvent{
+		Type:       EventSettle,
+		Seat:       player.Name,
+		SeatNumber: b.seatOf(player) + 1,
+		Hand:       handNumber(player, bet),
+		Outcome:    outcome.String(),
+		Staked:     floatOf(staked),
+		Paid:       floatOf(paid),
+	})
+	b.emit(BetSettled{
+		Seat:    b.seatOf(player),
+		Hand:    handNumber(player, bet) - 1,
+		Outcome: outcome.String(),
+		Staked:  floatOf(staked),
+		Paid:    floatOf(paid),
+		Balance: floatOf(player.Balance),
+	})
+	b.keepBooks(player, outcome, staked, paid)
+}
+
+// keepBooks tells the bookkeepers how a bet was settled.
+func (b *Board) keepBooks(player *Player, outcome Outcome, staked *big.Float, paid *big.Float) {
+	for _, bookkeeper := range b.Bookkeepers {
+		bookkeeper.Settle(
+			player,
+			outcome,
+			new(big.Float).Copy(staked),
+			new(big.Float).Copy(paid),
+		)
+	}
+}
+
+// shuffled tells the watchers that the deck has been shuffled.
+func (b *Board) shuffled() {
+	for _, watcher := range b.Watchers {
+		watcher.Shuffled()
+	}
+}
+
+// Initialise the dealer's and players' hands.
 func (b *Board) resetHands(initialBet float64) {
 	if initialBet < 0 {
 		initialBet = 5
@@ -82,38 +230,42 @@ func (b *Board) resetHands(initialBet float64) {
 		b.Dealer = &Dealer{}
 	}
 	b.Dealer.hand = &cards.Hand{}
-	b.Player.Bets = append(
-		[]*Bet{},
-		&Bet{big.NewFloat(initialBet), &cards.Hand{}, false},
-	)
+	b.Dealer.hitSoft17 = b.Rules.HitSoft17
+	for _, player := range b.Players {
+		player.insurance = nil
+		player.Bets = append(
+			[]*Bet{},
+			&Bet{amount: big.NewFloat(initialBet), Hand: &cards.Hand{}},
+		)
+	}
 }
 
 // ChangeStage progresses the game on to a new stage.
 func (b *Board) ChangeStage(stage Stage) {
+	b.stageChanges++
 	b.Stage = &Observing{}
-	b.action(func(b *Board) bool {
+	b.action(func(b *Board) error {
 		b.Stage = stage
-		return true
+		b.emit(StageChanged{StageName(stage), b.seatOf(b.Player)})
+		return nil
 	}).Wait()
 	b.Stage.Begin(b)
 }
 
 // Dealer is the dealer in the blackjack game.
 type Dealer struct {
-	hand *cards.Hand
+	hand      *cards.Hand
+	hitSoft17 bool
 }
 
-// Play has the dealer carry out their turn, hitting until hard 17 or more.
+// Play has the dealer carry out their turn, hitting until 17 or more.
 func (d *Dealer) Play(b *Board) {
-	for _, card := range d.hand.Cards {
-		if !card.IsFaceUp() {
-			b.action(func(b *Board) bool {
-				card.FaceUp()
-				b.Log.Push(fmt.Sprintf("Dealer had %s", card.Render()))
-				return true
-			}).Wait()
-		}
+	d.revealHoleCard(b)
+	// Without a hole card, the dealer's second card comes now.
+	if len(d.hand.Cards) < 2 {
+		b.HitDealer().Wait()
 	}
+	b.SettleInsurance()
 	for d.MustHit() {
 		b.HitDealer().Wait()
 	}
@@ -121,66 +273,310 @@ func (d *Dealer) Play(b *Board) {
 }
 
 // MustHit sees if the dealer has to keep hitting. In blackjack, the dealer
-// must keeping hitting until: they have 17 or more (not including soft 17), or
-// they bust.
+// must keeping hitting until: they have 17 or more (not including soft 17 if
+// the house rules say the dealer hits soft 17), or they bust.
 func (d *Dealer) MustHit() bool {
 	if d.hand.HasHard17() {
 		return false
 	}
+	if !d.hitSoft17 && d.hand.Score() >= 17 {
+		return false
+	}
 	if d.hand.IsBust() {
 		return false
 	}
 	return true
 }
 
+// revealHoleCard turns the dealer's face down card over.
+func (d *Dealer) revealHoleCard(b *Board) {
+	for _, card := range d.hand.Cards {
+		if !card.IsFaceUp() {
+			b.action(func(b *Board) error {
+				card.FaceUp()
+				b.show(card)
+				b.emit(DealerRevealed{cardName(card)})
+				b.History.record(HandEvent{
+					Type: EventReveal,
+					Seat: dealerSeat,
+					Card: cardName(card),
+				})
+				b.Log.Push(fmt.Sprintf("Dealer had %s", card.Render()))
+				return nil
+			}).Wait()
+		}
+	}
+}
+
+// Peek sees if the dealer has blackjack, including their face down card.
+func (d *Dealer) Peek() bool {
+	hand := &cards.Hand{}
+	for _, card := range d.hand.Cards {
+		peeked := *card
+		hand.Hit(peeked.FaceUp())
+	}
+	return hand.HasBlackJack()
+}
+
+// ShowsAceOrTen sees if the dealer's face up card could make blackjack with
+// their hole card.
+func (d *Dealer) ShowsAceOrTen() bool {
+	if len(d.hand.Cards) == 0 || !d.hand.Cards[0].IsFaceUp() {
+		return false
+	}
+	values := d.hand.Cards[0].Values()
+	return util.IntsContain(10, values) || util.IntsContain(11, values)
+}
+
+// ShowsAce sees if the dealer's face up card is an ace.
+func (d *Dealer) ShowsAce() bool {
+	return len(d.hand.Cards) > 0 &&
+		d.hand.Cards[0].IsFaceUp() &&
+		d.hand.Cards[0].Rank() == cards.Ace
+}
+
+// UpCard gets the dealer's face up card, if they have been dealt one.
+func (d *Dealer) UpCard() *cards.Card {
+	if len(d.hand.Cards) == 0 || !d.hand.Cards[0].IsFaceUp() {
+		return nil
+	}
+	return d.hand.Cards[0]
+}
+
+// Unseen gets the cards the players can't see, i.e. those left in the deck
+// and the dealer's face down hole card.
+func (b *Board) Unseen() *cards.Deck {
+	unseen := &cards.Deck{Cards: append([]*cards.Card{}, b.Deck.Cards...)}
+	for _, card := range b.Dealer.hand.Cards {
+		if !card.IsFaceUp() {
+			unseen.Cards = append(unseen.Cards, card)
+		}
+	}
+	return unseen
+}
+
 // Render gets a rendering of the dealer's Hand as a string.
 func (d Dealer) Render() string {
 	return d.hand.Render()
 }
 
-// Deal initial cards for the dealer and the player.
+// Deal initial cards for the dealer and each seat in turn.
 func (b *Board) Deal() *Board {
 	b.Stage = &Observing{}
+	b.History.begin(b)
 
 	// Dealer's first card
-	b.action(func(b *Board) bool {
-		card := b.Deck.Pop().FaceUp()
+	b.action(func(b *Board) error {
+		card := b.draw(true)
 		b.Log.Push(fmt.Sprintf("Dealer dealt %s", card.Render()))
 		b.Dealer.hand.Hit(card)
-		return true
+		b.History.dealt(dealerSeat, 0, 0, card)
+		b.emit(CardDealt{DealerSeat, 0, card.Notation()})
+		return nil
 	}).Wait()
 
-	// Player first card
-	b.HitPlayer().Wait()
+	// Players' first cards
+	for _, player := range b.Players {
+		b.dealPlayerCard(player, player.ActiveBet())
+	}
 
-	// Dealer second card
-	b.action(func(b *Board) bool {
-		card := b.Deck.Pop().FaceDown()
-		b.Log.Push(fmt.Sprintf("Dealer dealt %s", card.Render()))
-		b.Dealer.hand.Hit(card)
-		return true
-	}).Wait().Wait()
+	// Dealer second card, unless the dealer takes no hole card.
+	if b.Rules.HoleCard != EuropeanNoHoleCard {
+		b.action(func(b *Board) error {
+			card := b.draw(false)
+			b.Log.Push(fmt.Sprintf("Dealer dealt %s", card.Render()))
+			b.Dealer.hand.Hit(card)
+			b.History.dealt(dealerSeat, 0, 0, card)
+			b.emit(CardDealt{DealerSeat, 0, card.Notation()})
+			return nil
+		}).Wait().Wait()
+	}
 
-	// Player second card
-	b.HitPlayer().Wait()
+	// Players' second cards
+	for _, player := range b.Players {
+		b.dealPlayerCard(player, player.ActiveBet())
+		if player.ActiveBet().Hand.HasBlackJack() {
+			b.Log.Push(fmt.Sprintf("[%s has blackjack!](fg-cyan)", player.Name))
+		}
+	}
+	b.holdSideBetCards()
+
+	// Offer insurance to each seat if the dealer is showing an ace.
+	if b.Dealer.ShowsAce() {
+		b.Log.Push("Dealer shows an ace, insurance is open")
+		b.firstSeat(anySeat)
+		b.ChangeStage(&Insurance{})
+		return b
+	}
+
+	b.BeginPlay()
+
+	return b
+}
+
+// NextInsurance moves insurance on to the next seat, or on to play once every
+// seat has decided.
+func (b *Board) NextInsurance() {
+	if b.nextSeat(anySeat) {
+		b.ChangeStage(&Insurance{})
+		return
+	}
+	b.BeginPlay()
+}
+
+// BeginPlay moves on to the player stage once the initial cards are dealt,
+// going straight to the dealer if no seat has anything to play, e.g. due to
+// immediately getting blackjack. Early surrender is offered first if the house
+// rules allow it and the dealer might have blackjack.
+func (b *Board) BeginPlay() {
+	if b.Rules.Surrender == SurrenderEarly &&
+		b.Dealer.ShowsAceOrTen() &&
+		b.firstSeat(unfinishedSeat) {
+		b.Log.Push("Early surrender is open")
+		b.ChangeStage(&EarlySurrender{})
+		return
+	}
+	b.PeekAndPlay()
+}
+
+// NextEarlySurrender moves early surrender on to the next seat, or on to play
+// once every seat has decided.
+func (b *Board) NextEarlySurrender() {
+	if b.nextSeat(unfinishedSeat) {
+		b.ChangeStage(&EarlySurrender{})
+		return
+	}
+	b.PeekAndPlay()
+}
 
-	// Begin player stage if we have not gone straight to conclusion due to
-	// player immediately getting blackjack.
-	if !b.Player.ActiveBet().Hand.HasBlackJack() {
+// PeekAndPlay has the dealer peek for blackjack if the house rules say so, and
+// then moves on to the player stage.
+func (b *Board) PeekAndPlay() {
+	if b.Rules.HoleCard == AmericanHoleCard && b.Dealer.ShowsAceOrTen() {
+		if b.Dealer.Peek() {
+			b.revealDealerBlackjack()
+			return
+		}
+		b.Log.Push("Dealer peeks, no blackjack")
+		b.SettleInsurance()
+	}
+	if b.firstSeat(unfinishedSeat) {
 		b.ChangeStage(&PlayerStage{})
+		return
 	}
+	b.ChangeStage(&DealerStage{})
+}
 
-	return b
+// firstSeat gives the turn to the first seat matching a condition, returning
+// false if there are none.
+func (b *Board) firstSeat(wants func(p *Player) bool) bool {
+	for _, player := range b.Players {
+		if wants(player) {
+			b.Player = player
+			return true
+		}
+	}
+	return false
+}
+
+// nextSeat moves the turn on to the next seat after the current one matching a
+// condition, returning false if there are none.
+func (b *Board) nextSeat(wants func(p *Player) bool) bool {
+	seen := false
+	for _, player := range b.Players {
+		if seen && wants(player) {
+			b.Player = player
+			return true
+		}
+		if player == b.Player {
+			seen = true
+		}
+	}
+	return false
+}
+
+// anySeat matches every seat.
+func anySeat(p *Player) bool {
+	return true
+}
+
+// humanSeat matches seats that are played from the keyboard.
+func humanSeat(p *Player) bool {
+	return !p.IsBot()
+}
+
+// unfinishedSeat matches seats that have hands left to play.
+func unfinishedSeat(p *Player) bool {
+	return !p.IsFinished()
+}
+
+// playBot has a seat that isn't played from the keyboard choose and take its
+// actions at a stage, until the stage moves on. A bot making an invalid choice
+// falls back to a default.
+func (b *Board) playBot(stage Stage, fallback string) {
+	player := b.Player
+	if player == nil || !player.IsBot() {
+		return
+	}
+	for {
+		changes := b.stageChanges
+		actions := stage.Actions(b)
+		key := player.Controller.Choose(b, actions)
+		if !b.botAct(actions, key) && !b.botAct(actions, fallback) {
+			return
+		}
+		if b.stageChanges != changes {
+			return
+		}
+	}
+}
+
+// botAct takes an action chosen by a bot, if it is available.
+func (b *Board) botAct(actions ActionSet, key string) bool {
+	action, ok := actions[key]
+	if !ok {
+		return false
+	}
+	b.Log.Push(fmt.Sprintf(
+		">> [%s: %s](fg-bold,fg-magenta)",
+		b.Player.Name,
+		action.Description,
+	))
+	return b.Take(action) == nil
+}
+
+// Take carries out an action for the seat whose turn it is, recording the
+// decision in the hand history if it succeeds.
+func (b *Board) Take(action PlayerAction) error {
+	if b.Player == nil {
+		return action.Execute(b)
+	}
+	// The decision goes ahead of the cards it deals, but is taken back out if
+	// the action fails, so that replays only make decisions that were made.
+	decision := b.History.record(HandEvent{
+		Type:       EventDecision,
+		Seat:       b.Player.Name,
+		SeatNumber: b.seatOf(b.Player) + 1,
+		Hand:       handNumber(b.Player, b.Player.ActiveBet()),
+		Command:    action.Command,
+	})
+	err := action.Execute(b)
+	if err != nil {
+		b.History.withdraw(decision)
+	}
+	return err
 }
 
 // HitDealer hits the dealer's Hand and checks if that ends their turn.
 func (b *Board) HitDealer() *Board {
-	b.action(func(b *Board) bool {
-		card := b.Deck.Pop().FaceUp()
+	b.action(func(b *Board) error {
+		card := b.draw(true)
 		b.Log.Push(fmt.Sprintf("Dealer dealt %s", card.Render()))
 		b.Dealer.hand.Hit(card)
+		b.History.dealt(dealerSeat, 0, 0, card)
+		b.emit(CardDealt{DealerSeat, 0, card.Notation()})
 
-		return true
+		return nil
 	}).Wait()
 
 	return b
@@ -214,28 +610,24 @@ func (b *Board) ConcludeDealerTurn() *Board {
 	return b
 }
 
-// HitPlayer hits the player's first Hand and advances the game stage if
+// HitPlayer hits the current seat's active Hand and advances the game stage if
 // appropriate.
 func (b *Board) HitPlayer() *Board {
-	b.action(func(b *Board) bool {
-		card := b.Deck.Pop().FaceUp()
-		b.Log.Push(fmt.Sprintf("Player dealt %s", card.Render()))
-		b.Player.ActiveBet().Hand.Hit(card)
-
-		return true
-	}).Wait()
+	bet := b.Player.ActiveBet()
+	b.dealPlayerCard(b.Player, bet)
 
 	// Has player bust?
-	if b.Player.ActiveBet().Hand.IsBust() {
+	if bet.Hand.IsBust() {
 		b.Log.Push(fmt.Sprintf(
-			"Player busts at %d",
-			util.MinInt(b.Player.ActiveBet().Hand.Scores()),
+			"%s busts at %d",
+			b.Player.Name,
+			util.MinInt(bet.Hand.Scores()),
 		))
 	}
 
 	// Has player got blackjack?
-	if b.Player.ActiveBet().Hand.HasBlackJack() {
-		b.Log.Push("[Player has blackjack!](fg-cyan)")
+	if bet.Hand.HasBlackJack() {
+		b.Log.Push(fmt.Sprintf("[%s has blackjack!](fg-cyan)", b.Player.Name))
 	}
 
 	b.AssessPlayerStage()
@@ -243,54 +635,139 @@ func (b *Board) HitPlayer() *Board {
 	return b
 }
 
-// DoubleDown doubles the player's first bet, hits the player's first Hand and
-// immediately advances the game stage.
+// DoubleDown doubles the player's active bet, hits its Hand and immediately
+// ends play on it.
 func (b *Board) DoubleDown() *Board {
+	bet := b.Player.ActiveBet()
 
 	// Double bet.
-	b.action(func(b *Board) bool {
-		amount := *b.Player.Bets[0].amount
-		b.Player.Bets[0].amount.Add(&amount, &amount)
-		b.Player.Balance.Sub(b.Player.Balance, &amount)
-		return true
+	b.action(func(b *Board) error {
+		amount := new(big.Float).Copy(bet.amount)
+		bet.amount.Add(bet.amount, amount)
+		b.Player.Balance.Sub(b.Player.Balance, amount)
+		b.emit(DoubledDown{
+			Seat:    b.seatOf(b.Player),
+			Hand:    handNumber(b.Player, bet) - 1,
+			Amount:  floatOf(bet.amount),
+			Balance: floatOf(b.Player.Balance),
+		})
+		return nil
 	}).Wait()
 
 	// Hit player.
-	b.action(func(b *Board) bool {
-		card := b.Deck.Pop().FaceUp()
-		b.Log.Push(fmt.Sprintf("Player dealt %s", card.Render()))
-		b.Player.ActiveBet().Hand.Hit(card)
-
-		return true
-	}).Wait()
+	b.dealPlayerCard(b.Player, bet)
 
 	// Has player bust?
-	if b.Player.ActiveBet().Hand.IsBust() {
+	if bet.Hand.IsBust() {
 		b.Log.Push(fmt.Sprintf(
-			"Player busts at %d",
-			util.MinInt(b.Player.ActiveBet().Hand.Scores()),
+			"%s busts at %d",
+			b.Player.Name,
+			util.MinInt(bet.Hand.Scores()),
 		))
 	}
 
-	// Has player got blackjack?
-	if b.Player.ActiveBet().Hand.HasBlackJack() {
-		b.Log.Push("[Player has blackjack!](fg-cyan)")
-	}
-
 	// Always end a hand after doubling down on it.
-	b.Player.ActiveBet().stand = true
+	bet.stand = true
 
 	b.AssessPlayerStage()
 
 	return b
 }
 
-// AssessPlayerStage moves to the dealer stage if the player has finished all
-// of their hands.
+// Surrender gives up the current seat's active bet, getting half of it back.
+func (b *Board) Surrender() *Board {
+	player := b.Player
+	bet := player.ActiveBet()
+	b.action(func(b *Board) error {
+		bet.surrendered = true
+		bet.stand = true
+		bet.Conclude(b, player)
+		return nil
+	}).Wait()
+	return b
+}
+
+// dealPlayerCard deals a face up card to one of a seat's bets.
+func (b *Board) dealPlayerCard(player *Player, bet *Bet) *Board {
+	b.action(func(b *Board) error {
+		card := b.draw(true)
+		b.Log.Push(fmt.Sprintf("%s dealt %s", player.Name, card.Render()))
+		bet.Hand.Hit(card)
+		b.History.dealt(player.Name, b.seatOf(player)+1, handNumber(player, bet), card)
+		b.emit(CardDealt{b.seatOf(player), handNumber(player, bet) - 1, card.Notation()})
+		return nil
+	}).Wait()
+	return b
+}
+
+// revealDealerBlackjack turns over the dealer's hole card after they have
+// peeked and found blackjack, ending the round straight away.
+func (b *Board) revealDealerBlackjack() {
+	b.Stage = &Observing{}
+	b.Dealer.revealHoleCard(b)
+	b.Log.Push("Dealer has blackjack")
+	b.SettleInsurance()
+	b.ChangeStage(&Assessment{})
+}
+
+// SettleInsurance pays out or takes each seat's insurance once the dealer's
+// hole card is revealed. Insurance pays 2:1 if the dealer has blackjack.
+func (b *Board) SettleInsurance() {
+	for _, player := range b.Players {
+		insurance := player.insurance
+		if insurance == nil || insurance.Sign() == 0 {
+			continue
+		}
+		player := player
+		b.action(func(b *Board) error {
+			paid := big.NewFloat(0)
+			if b.Dealer.hand.HasBlackJack() {
+				winnings := new(big.Float).Mul(insurance, big.NewFloat(3))
+				paid = winnings
+				player.Balance.Add(player.Balance, winnings)
+				b.Log.Push(fmt.Sprintf(
+					"[%s's insurance pays %s](fg-green)",
+					player.Name,
+					ac.FormatMoneyBigFloat(winnings),
+				))
+			} else {
+				b.Log.Push(fmt.Sprintf(
+					"[%s loses %s insurance](fg-red)",
+					player.Name,
+					ac.FormatMoneyBigFloat(insurance),
+				))
+			}
+			b.History.record(HandEvent{
+				Type:       EventInsurance,
+				Seat:       player.Name,
+				SeatNumber: b.seatOf(player) + 1,
+				Staked:     floatOf(insurance),
+				Paid:       floatOf(paid),
+			})
+			b.emit(InsuranceSettled{
+				Seat:    b.seatOf(player),
+				Staked:  floatOf(insurance),
+				Paid:    floatOf(paid),
+				Balance: floatOf(player.Balance),
+			})
+			b.keepBooks(player, Insured, insurance, paid)
+			player.insurance = nil
+			return nil
+		}).Wait()
+	}
+}
+
+// AssessPlayerStage moves on to the next seat once the current seat has
+// finished all of their hands, and on to the dealer stage once every seat has.
 func (b *Board) AssessPlayerStage() {
-	if b.Player.IsFinished() {
-		b.ChangeStage(&DealerStage{})
+	if !b.Player.IsFinished() {
+		return
+	}
+	if b.nextSeat(unfinishedSeat) {
+		b.ChangeStage(&PlayerStage{})
+		return
 	}
+	b.ChangeStage(&DealerStage{})
 }
 
 //
@@ -299,10 +776,14 @@ func (b *Board) AssessPlayerStage() {
 type Log struct {
 	events []string
 	limit  int
+	// Events can be pushed from other goroutines, e.g. by the display.
+	mutex sync.Mutex
 }
 
 // Add a new event to the game log.
 func (l *Log) Push(event string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
 	l.events = append(l.events, event)
 	if l.limit == 0 {
 		l.limit = 20
@@ -312,14 +793,22 @@ func (l *Log) Push(event string) {
 	}
 }
 
+// Events gets a copy of the entries in the game log, oldest first.
+func (l *Log) Events() []string {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return append([]string{}, l.events...)
+}
+
 // Get a rendering of the game log as a string.
-func (l Log) Render() string {
+func (l *Log) Render() string {
+	events := l.Events()
 	buffer := bytes.Buffer{}
-	if len(l.events) > 0 {
-		buffer.WriteString(fmt.Sprintf("%s\n", l.events[0]))
+	if len(events) > 0 {
+		buffer.WriteString(fmt.Sprintf("%s\n", events[0]))
 	}
-	if len(l.events) > 1 {
-		for _, event := range l.events[1:] {
+	if len(events) > 1 {
+		for _, event := range events[1:] {
 			buffer.WriteString(fmt.Sprintf(" %s\n", event))
 		}
 	}
@@ -330,12 +819,37 @@ func (l Log) Render() string {
 // Player
 //
 type Player struct {
+	Name string
 	// Indexed bets corresponding to each player Hand
 	Bets    []*Bet
 	Balance *big.Float
+	// Chooses actions for the seat if it is not played from the keyboard.
+	Controller Controller
+	// The side bet against the dealer having blackjack, if any.
+	insurance *big.Float
+	// Optional wagers placed alongside the first bet, e.g. 21+3.
+	SideBets []*SideBet
 }
 
-// initPlayer constructs a new p instance for the board.
+// Controller chooses actions for a seat that isn't played from the keyboard,
+// e.g. a bot.
+type Controller interface {
+	// Choose picks the key of the action to take from those available.
+	Choose(board *Board, actions ActionSet) string
+}
+
+// NewPlayer constructs a seat to give to a board before it begins. A nil
+// controller means the seat is played from the keyboard.
+func NewPlayer(name string, balance float64, controller Controller) *Player {
+	return &Player{
+		Name:       name,
+		Bets:       []*Bet{},
+		Balance:    big.NewFloat(balance),
+		Controller: controller,
+	}
+}
+
+// initPlayer constructs a new p instance for the board as its only seat.
 func (b *Board) initPlayer(initialBet float64, balance float64) *Player {
 	if initialBet < 0 {
 		initialBet = 5
@@ -343,12 +857,80 @@ func (b *Board) initPlayer(initialBet float64, balance float64) *Player {
 	if balance < 0 {
 		balance = 95
 	}
-	b.Player = &Player{}
+	b.Player = &Player{Name: "Player"}
+	b.Players = []*Player{b.Player}
 	b.resetHands(initialBet)
 	b.Player.Balance = big.NewFloat(balance)
 	return b.Player
 }
 
+// Worth gets the player's balance plus whatever they have on the table that
+// hasn't been settled yet.
+func (p *Player) Worth() *big.Float {
+	worth := new(big.Float).Copy(p.Balance)
+	for _, bet := range p.Bets {
+		if !bet.concluded && bet.amount != nil {
+			worth.Add(worth, bet.amount)
+		}
+	}
+	if p.insurance != nil {
+		worth.Add(worth, p.insurance)
+	}
+	for _, sideBet := range p.SideBets {
+		if !sideBet.settled {
+			worth.Add(worth, sideBet.amount)
+		}
+	}
+	return worth
+}
+
+// IsBot sees if the seat is played by a controller rather than the keyboard.
+func (p *Player) IsBot() bool {
+	return p.Controller != nil
+}
+
+// CanAfford sees if the player's balance covers an amount, e.g. to double down
+// or split.
+func (p *Player) CanAfford(amount *big.Float) bool {
+	return p.Balance.Cmp(amount) >= 0
+}
+
+// Insure places an insurance side bet of up to half of the first bet.
+func (p *Player) Insure(amount *big.Float) error {
+	max := new(big.Float).Quo(p.Bets[0].amount, big.NewFloat(2))
+	switch {
+	case amount.Sign() <= 0:
+		return ErrInvalidAmount
+	case amount.Cmp(max) == 1:
+		return ErrOverInsured
+	case !p.CanAfford(amount):
+		return ErrCannotAfford
+	}
+	p.insurance = new(big.Float).Copy(amount)
+	p.Balance.Sub(p.Balance, amount)
+	return nil
+}
+
+// TakeEvenMoney settles the first bet at 1:1 straight away when the player has
+// blackjack and the dealer shows an ace, rather than risking a push.
+func (p *Player) TakeEvenMoney(board *Board) bool {
+	bet := p.Bets[0]
+	if !bet.Hand.HasBlackJack() || bet.concluded {
+		return false
+	}
+	winnings := new(big.Float).Mul(bet.amount, big.NewFloat(2))
+	p.Balance.Add(p.Balance, winnings)
+	board.settle(p, bet, Win, bet.amount, winnings)
+	board.Log.Push(fmt.Sprintf(
+		"[%s takes even money, gets %s](fg-cyan)",
+		p.Name,
+		ac.FormatMoneyBigFloat(winnings),
+	))
+	bet.amount = big.NewFloat(0)
+	bet.concluded = true
+	return true
+}
+
 // Raise the first bet.
 func (p *Player) Raise(amount float64) bool {
 	if p.Balance.Cmp(big.NewFloat(amount)) == 1 {
@@ -425,6 +1007,17 @@ func (p Player) Render() string {
 			buffer.WriteString(" | ")
 		}
 	}
+	for _, sideBet := range p.SideBets {
+		label := string(sideBet.Kind)
+		if sideBet.Result != "" {
+			label = fmt.Sprintf("%s %s", sideBet.Kind, sideBet.Result)
+		}
+		buffer.WriteString(fmt.Sprintf(
+			" | [%s {%s}](fg-yellow)",
+			label,
+			ac.FormatMoneyBigFloat(sideBet.amount),
+		))
+	}
 	return buffer.String()
 }
 
@@ -432,9 +1025,12 @@ func (p Player) Render() string {
 // Bets
 //
 type Bet struct {
-	amount *big.Float
-	Hand   *cards.Hand
-	stand  bool
+	amount      *big.Float
+	Hand        *cards.Hand
+	stand       bool
+	split       bool
+	surrendered bool
+	concluded   bool
 }
 
 // IsFinished shows if the bet is finished, i.e. its Hand is complete and the
@@ -466,53 +1062,107 @@ func (b *Bet) HasFocus(p *Player) bool {
 	return true
 }
 
-// Split turns this bet and Hand into two separate bets and hands.
+// isSplitAces sees if the bet is on a hand made from splitting aces.
+func (b *Bet) isSplitAces() bool {
+	return b.split &&
+		len(b.Hand.Cards) > 0 &&
+		b.Hand.Cards[0].Rank() == cards.Ace
+}
+
+// Split turns this bet and Hand into two separate bets and hands, and deals a
+// second card to each.
 func (b *Bet) Split(board *Board) {
-	newBet := &Bet{&*b.amount, &cards.Hand{}, false}
+	newBet := &Bet{
+		amount: new(big.Float).Copy(b.amount),
+		Hand:   &cards.Hand{},
+		split:  true,
+	}
+	b.split = true
 
 	board.Player.Balance.Sub(board.Player.Balance, b.amount)
-	board.Player.Bets = append(board.Player.Bets, newBet)
+
+	// The new bet is played straight after this one.
+	bets := []*Bet{}
+	for _, bet := range board.Player.Bets {
+		bets = append(bets, bet)
+		if bet == b {
+			bets = append(bets, newBet)
+		}
+	}
+	board.Player.Bets = bets
 
 	// Split the two cards between the bets.
 	newBet.Hand.Cards = []*cards.Card{b.Hand.Cards[1]}
 	b.Hand.Cards = []*cards.Card{b.Hand.Cards[0]}
+	board.emit(HandSplit{
+		Seat:    board.seatOf(board.Player),
+		Hand:    handNumber(board.Player, b) - 1,
+		Amount:  floatOf(newBet.amount),
+		Balance: floatOf(board.Player.Balance),
+	})
+
+	for _, bet := range []*Bet{b, newBet} {
+		board.dealPlayerCard(board.Player, bet)
+		// Split aces only get one more card unless the rules allow hitting
+		// them.
+		if bet.isSplitAces() && !board.Rules.HitSplitAces {
+			bet.stand = true
+		}
+	}
 }
 
-// Conclude ends the bet and pays the player's winnings (if any).
-func (b *Bet) Conclude(board *Board) {
+// Conclude ends the bet and pays the winnings (if any) to the seat it belongs
+// to.
+func (b *Bet) Conclude(board *Board, player *Player) {
+	// Bets can be settled early, e.g. by taking even money.
+	if b.concluded {
+		return
+	}
+	b.concluded = true
+
+	outcome := b.Outcome(board.Dealer.hand)
+
 	// Pay the winnings for this bet, if any.
-	amount := *b.amount
-	winnings := amount.Mul(b.amount, b.Hand.WinFactor(board.Dealer.hand))
-	board.Player.Balance.Add(board.Player.Balance, winnings)
-	winFactor, _ := b.Hand.WinFactor(board.Dealer.hand).Float64()
-	switch winFactor {
-	case 2.5:
+	winnings := new(big.Float).Mul(b.amount, board.Rules.PayoutFactor(outcome))
+	player.Balance.Add(player.Balance, winnings)
+	board.settle(player, b, outcome, b.amount, winnings)
+	switch outcome {
+	case Blackjack:
 		board.Log.Push(
 			fmt.Sprintf(
-				"[Player wins %s with blackjack](fg-cyan)",
+				"[%s wins %s with blackjack](fg-cyan)",
+				player.Name,
 				ac.FormatMoneyBigFloat(winnings)),
 		)
-		break
-	case 2:
+	case Win:
 		board.Log.Push(
 			fmt.Sprintf(
-				"[Player wins %s](fg-green)",
+				"[%s wins %s](fg-green)",
+				player.Name,
 				ac.FormatMoneyBigFloat(winnings),
 			),
 		)
-		break
-	case 1:
+	case Push:
 		board.Log.Push(
 			fmt.Sprintf(
-				"[Player gets %s back](fg-yellow)",
+				"[%s gets %s back](fg-yellow)",
+				player.Name,
 				ac.FormatMoneyBigFloat(winnings),
 			),
 		)
-		break
-	case 0:
+	case Surrendered:
 		board.Log.Push(
 			fmt.Sprintf(
-				"[Player loses %s](fg-red)",
+				"[%s surrenders, gets %s back](fg-yellow)",
+				player.Name,
+				ac.FormatMoneyBigFloat(winnings),
+			),
+		)
+	case Lose:
+		board.Log.Push(
+			fmt.Sprintf(
+				"[%s loses %s](fg-red)",
+				player.Name,
 				ac.FormatMoneyBigFloat(b.amount),
 			),
 		)
@@ -520,3 +1170,70 @@ func (b *Bet) Conclude(board *Board) {
 	// Reset the bet balance.
 	b.amount = big.NewFloat(0)
 }
+
+// Outcome is how a bet turned out against the dealer's hand.
+type Outcome int
+
+const (
+	Lose Outcome = iota
+	Push
+	Win
+	Blackjack
+	Surrendered
+	// Insured is the settlement of a seat's insurance, rather than a hand.
+	Insured
+	// SideWager is the settlement of a side bet, rather than a hand.
+	SideWager
+)
+
+// Outcome assesses the bet against the dealer's hand.
+func (b *Bet) Outcome(dealer *cards.Hand) Outcome {
+	if b.surrendered {
+		return Surrendered
+	}
+	if b.Hand.IsBust() {
+		return Lose
+	}
+	// 21 on a split hand is not blackjack.
+	natural := b.Hand.HasBlackJack() && !b.split
+	if natural && dealer.HasBlackJack() {
+		return Push
+	}
+	if natural {
+		return Blackjack
+	}
+	// Dealer blackjack beats any other 21.
+	if dealer.HasBlackJack() {
+		return Lose
+	}
+	if dealer.IsBust() {
+		return Win
+	}
+	ours, theirs := b.Hand.Score(), dealer.Score()
+	if ours > theirs {
+		return Win
+	}
+	if ours == theirs {
+		return Push
+	}
+	return Lose
+}
+
+// String gets a plain description of the outcome.
+func (o Outcome) String() string {
+	switch o {
+	case Push:
+		return "push"
+	case Win:
+		return "win"
+	case Blackjack:
+		return "blackjack"
+	case Surrendered:
+		return "surrender"
+	case Insured:
+		return "insurance"
+	case SideWager:
+		return "side bet"
+	}
+	return "lose"
+}
@@ -6,6 +6,7 @@ import (
 
 	"math/big"
 
+	"sync"
 	"time"
 
 	"github.com/hughgrigg/blackjack/cards"
@@ -20,11 +21,11 @@ import (
 // with what's happening.
 func TestBoard_ActionDelay(t *testing.T) {
 	board := Board{}
-	board.Begin(50)
+	board.Begin(50, DefaultRules())
 
 	start := time.Now()
-	board.action(func(b *Board) bool {
-		return true
+	board.action(func(b *Board) error {
+		return nil
 	}).Wait()
 
 	assert.True(
@@ -34,10 +35,42 @@ func TestBoard_ActionDelay(t *testing.T) {
 	)
 }
 
+// A board should use a shoe of several decks if it is given one.
+func TestBoard_Begin_Shoe(t *testing.T) {
+	board := Board{Deck: cards.NewShoe(6)}
+	board.Begin(0, DefaultRules()).Wait()
+
+	assert.Len(t, board.Deck.Cards, 312)
+}
+
+// Boards with their own seeded randomisers should deal the same cards even when
+// running concurrently.
+func TestBoard_Begin_Randomiser(t *testing.T) {
+	boards := []*Board{}
+	for i := 0; i < 4; i++ {
+		boards = append(boards, &Board{
+			Deck: &cards.Deck{Random: cards.NewSeededRandomiser(42)},
+		})
+	}
+	wg := sync.WaitGroup{}
+	for _, board := range boards {
+		wg.Add(1)
+		go func(board *Board) {
+			board.Begin(0, DefaultRules()).Wait()
+			wg.Done()
+		}(board)
+	}
+	wg.Wait()
+
+	for _, board := range boards[1:] {
+		assert.Equal(t, boards[0].Deck.Cards, board.Deck.Cards)
+	}
+}
+
 // The player should be able to hit and have the game proceed from there.
 func TestBoard_HitPlayer(t *testing.T) {
 	board := Board{}
-	board.Begin(0).Wait()
+	board.Begin(0, DefaultRules()).Wait()
 
 	board.HitPlayer().Wait()
 
@@ -48,7 +81,7 @@ func TestBoard_HitPlayer(t *testing.T) {
 // The player stage should end if the player gets blackjack.
 func TestBoard_HitPlayer_BlackJack(t *testing.T) {
 	board := &Board{}
-	board.Begin(0).Wait()
+	board.Begin(0, DefaultRules()).Wait()
 
 	board.Deck.ForceNext(cards.NewCard(cards.Ace, cards.Spades))
 	board.HitPlayer().Wait()
@@ -62,7 +95,7 @@ func TestBoard_HitPlayer_BlackJack(t *testing.T) {
 // The player stage should end if the player busts.
 func TestBoard_HitPlayer_Bust(t *testing.T) {
 	board := Board{}
-	board.Begin(0).Wait()
+	board.Begin(0, DefaultRules()).Wait()
 
 	board.Deck.ForceNext(cards.NewCard(cards.Queen, cards.Spades))
 	board.HitPlayer().Wait()
@@ -78,7 +111,7 @@ func TestBoard_HitPlayer_Bust(t *testing.T) {
 // Should be able to double down.
 func TestBoard_DoubleDown(t *testing.T) {
 	board := Board{}
-	board.Begin(0).Wait()
+	board.Begin(0, DefaultRules()).Wait()
 
 	// Force player win
 	board.Deck.Cards[51] = cards.NewCard(cards.Ten, cards.Clubs)     // dealer 1
@@ -113,7 +146,7 @@ func TestBoard_DoubleDown(t *testing.T) {
 // Should be able to hit the dealer's Hand.
 func TestBoard_HitDealer(t *testing.T) {
 	board := Board{}
-	board.Begin(0).Wait()
+	board.Begin(0, DefaultRules()).Wait()
 	board.Stage = &DealerStage{}
 
 	board.HitDealer().Wait()
@@ -125,7 +158,7 @@ func TestBoard_HitDealer(t *testing.T) {
 // Should advance to assessment stage if dealer busts.
 func TestBoard_HitDealerBust(t *testing.T) {
 	board := Board{}
-	board.Begin(0).Wait()
+	board.Begin(0, DefaultRules()).Wait()
 	board.Stage = &DealerStage{}
 
 	board.Deck.ForceNext(cards.NewCard(cards.Queen, cards.Spades))
@@ -144,7 +177,7 @@ func TestBoard_HitDealerBust(t *testing.T) {
 // Should advance to assessment stage if dealer has hard 17.
 func TestBoard_HitDealer_Hard17(t *testing.T) {
 	board := Board{}
-	board.Begin(0).Wait()
+	board.Begin(0, DefaultRules()).Wait()
 	board.Stage = &DealerStage{}
 
 	board.Deck.ForceNext(cards.NewCard(cards.Ten, cards.Spades))
@@ -158,10 +191,27 @@ func TestBoard_HitDealer_Hard17(t *testing.T) {
 	assert.Equal(t, &Conclusion{}, board.Stage)
 }
 
+// Dealer must stand on soft 17 if the house rules say so.
+func TestBoard_HitDealer_StandSoft17(t *testing.T) {
+	rules := DefaultRules()
+	rules.HitSoft17 = false
+
+	board := Board{}
+	board.Begin(0, rules)
+	board.Stage = &DealerStage{}
+
+	board.Deck.ForceNext(cards.NewCard(cards.Ace, cards.Spades))
+	board.HitDealer().Wait()
+	board.Deck.ForceNext(cards.NewCard(cards.Six, cards.Diamonds))
+	board.HitDealer().Wait()
+
+	assert.False(t, board.Dealer.MustHit())
+}
+
 // Dealer must hit on soft 17.
 func TestBoard_HitDealer_Soft17(t *testing.T) {
 	board := Board{}
-	board.Begin(0).Wait()
+	board.Begin(0, DefaultRules()).Wait()
 	board.Stage = &DealerStage{}
 
 	board.Deck.ForceNext(cards.NewCard(cards.Ace, cards.Spades))
@@ -177,20 +227,107 @@ func TestBoard_HitDealer_Soft17(t *testing.T) {
 // Should be able to change the game stage.
 func TestBoard_ChangeStage(t *testing.T) {
 	board := Board{}
-	board.Begin(0).Wait()
+	board.Begin(0, DefaultRules()).Wait()
 
 	board.ChangeStage(&PlayerStage{})
 
 	assert.Equal(t, &PlayerStage{}, board.Stage)
 }
 
+// Blackjack should pay out according to the house rules.
+func TestBoard_BlackjackPayout(t *testing.T) {
+	rules := DefaultRules()
+	rules.BlackjackPayout = SixToFive
+
+	board := &Board{}
+	board.Begin(0, rules)
+
+	board.Deck.Cards[51] = cards.NewCard(cards.Ten, cards.Clubs)     // dealer 1
+	board.Deck.Cards[50] = cards.NewCard(cards.Ace, cards.Spades)    // player 1
+	board.Deck.Cards[49] = cards.NewCard(cards.Seven, cards.Clubs)   // dealer 2
+	board.Deck.Cards[48] = cards.NewCard(cards.Jack, cards.Diamonds) // player 2
+	board.Deal().Wait()
+
+	// £95 balance plus £5 bet back plus £6 winnings.
+	assert.Equal(t, "106", board.Player.Balance.String())
+}
+
+// ledger is a bookkeeper that notes each settlement.
+type ledger []string
+
+func (l *ledger) Settle(player *Player, outcome Outcome, staked *big.Float, paid *big.Float) {
+	*l = append(*l, fmt.Sprintf("%s %s %s %s", player.Name, outcome, staked.String(), paid.String()))
+}
+
+// Bookkeepers should be told how each bet is settled.
+func TestBoard_Bookkeepers(t *testing.T) {
+	book := &ledger{}
+	board := &Board{Bookkeepers: []Bookkeeper{book}}
+	board.Begin(0, DefaultRules())
+
+	board.Deck.Cards[51] = cards.NewCard(cards.Ten, cards.Clubs)     // dealer 1
+	board.Deck.Cards[50] = cards.NewCard(cards.Ace, cards.Spades)    // player 1
+	board.Deck.Cards[49] = cards.NewCard(cards.Seven, cards.Clubs)   // dealer 2
+	board.Deck.Cards[48] = cards.NewCard(cards.Jack, cards.Diamonds) // player 2
+	board.Deal().Wait()
+
+	assert.Equal(t, &ledger{"Player blackjack 5 12.5"}, book)
+}
+
+// Even money should be settled as a win.
+func TestBoard_Bookkeepers_EvenMoney(t *testing.T) {
+	book := &ledger{}
+	board := &Board{Bookkeepers: []Bookkeeper{book}}
+	board.Begin(0, DefaultRules())
+
+	dealDealerAce(board, cards.Ace, cards.King, cards.Jack)
+	board.Stage.Actions(board)["e"].Execute(board)
+
+	assert.Equal(t, &ledger{"Player win 5 10"}, book)
+}
+
+// Bookkeepers should be told how insurance and side bets are settled too, so
+// that they add up to the change in balance.
+func TestBoard_Bookkeepers_Insurance(t *testing.T) {
+	book := &ledger{}
+	rules := DefaultRules()
+	rules.SideBets = DefaultSideBets(PerfectPairs)
+	board := &Board{Bookkeepers: []Bookkeeper{book}}
+	board.Begin(0, rules)
+	board.PlaceSideBet(board.Player, PerfectPairs, 5)
+
+	dealDealerAce(board, cards.Nine, cards.King, cards.Eight)
+	board.Stage.Actions(board)["i"].Execute(board)
+
+	assert.Equal(t, &ledger{
+		"Player insurance 2.5 7.5",
+		"Player lose 5 0",
+		"Player side bet 5 0",
+	}, book)
+}
+
 //
 // Dealer
 //
 
+// The unseen cards should be those left in the deck and the dealer's hole card.
+func TestBoard_Unseen(t *testing.T) {
+	board := &Board{}
+	board.Begin(0, DefaultRules())
+	board.Deck.Cards[51] = cards.NewCard(cards.Two, cards.Clubs)   // dealer 1
+	board.Deck.Cards[50] = cards.NewCard(cards.Three, cards.Clubs) // player 1
+	board.Deck.Cards[49] = cards.NewCard(cards.Four, cards.Clubs)  // dealer 2
+	board.Deal().Wait()
+
+	unseen := board.Unseen()
+	assert.Equal(t, 49, len(unseen.Cards))
+	assert.Equal(t, cards.Four, unseen.Cards[48].Rank())
+	assert.Equal(t, 48, len(board.Deck.Cards))
+}
+
 // Should be able to render the dealer's Hand.
 func TestDealer_Render(t *testing.T) {
-	dealer := Dealer{&cards.Hand{}}
+	dealer := Dealer{hand: &cards.Hand{}}
 	dealer.hand.Hit(cards.NewCard(cards.Ace, cards.Spades))
 	assert.Equal(t, "A♤  (1 / 11)", dealer.Render())
 }
@@ -217,17 +354,149 @@ func TestLog_Limit(t *testing.T) {
 	assert.Equal(t, "Event 2\n Event 3\n Event 4\n", log.Render())
 }
 
+//
+// Seats
+//
+
+// Begin a board with two seats played from the keyboard.
+func beginSeats(rules Rules, bob Controller) (*Board, *Player, *Player) {
+	ann := NewPlayer("Ann", 100, nil)
+	bobPlayer := NewPlayer("Bob", 100, bob)
+	board := &Board{Players: []*Player{ann, bobPlayer}}
+	board.Begin(0, rules)
+	return board, ann, bobPlayer
+}
+
+// Seats given to the board should each start off with a bet.
+func TestBoard_Begin_Seats(t *testing.T) {
+	board, ann, bob := beginSeats(DefaultRules(), nil)
+
+	assert.Equal(t, ann, board.Player)
+	assert.Equal(t, "95", ann.Balance.String())
+	assert.Equal(t, "5", ann.Bets[0].amount.String())
+	assert.Equal(t, "95", bob.Balance.String())
+}
+
+// Each seat should be dealt to in order, and then take their turn in order.
+func TestBoard_Deal_Seats(t *testing.T) {
+	board, ann, bob := beginSeats(DefaultRules(), nil)
+
+	board.Deck.Cards[51] = cards.NewCard(cards.Ten, cards.Clubs)      // dealer 1
+	board.Deck.Cards[50] = cards.NewCard(cards.Ten, cards.Spades)     // ann 1
+	board.Deck.Cards[49] = cards.NewCard(cards.Five, cards.Spades)    // bob 1
+	board.Deck.Cards[48] = cards.NewCard(cards.Eight, cards.Clubs)    // dealer 2
+	board.Deck.Cards[47] = cards.NewCard(cards.Nine, cards.Hearts)    // ann 2
+	board.Deck.Cards[46] = cards.NewCard(cards.Six, cards.Hearts)     // bob 2
+	board.Deck.Cards[45] = cards.NewCard(cards.Three, cards.Diamonds) // bob 3
+	board.Deal().Wait()
+
+	assert.Equal(t, 19, ann.ActiveBet().Hand.Score())
+	assert.Equal(t, 11, bob.ActiveBet().Hand.Score())
+	assert.IsType(t, &PlayerStage{}, board.Stage)
+	assert.Equal(t, ann, board.Player)
+
+	board.Stage.Actions(board)["s"].Execute(board)
+
+	assert.IsType(t, &PlayerStage{}, board.Stage)
+	assert.Equal(t, bob, board.Player)
+
+	board.Stage.Actions(board)["h"].Execute(board)
+	board.Stage.Actions(board)["s"].Execute(board)
+
+	// Dealer stands on 18, losing to Ann's 19 and beating Bob's 14.
+	assert.Equal(t, &Conclusion{}, board.Stage)
+	assert.Equal(t, "105", ann.Balance.String())
+	assert.Equal(t, "95", bob.Balance.String())
+	assert.Contains(t, board.Log.events, "[Ann wins £10.00](fg-green)")
+	assert.Contains(t, board.Log.events, "[Bob loses £5.00](fg-red)")
+}
+
+// A seat with a controller should take its own turn.
+func TestBoard_Seats_Bot(t *testing.T) {
+	board, ann, bob := beginSeats(DefaultRules(), MimicDealer{})
+
+	board.Deck.Cards[51] = cards.NewCard(cards.Ten, cards.Clubs)      // dealer 1
+	board.Deck.Cards[50] = cards.NewCard(cards.Ten, cards.Spades)     // ann 1
+	board.Deck.Cards[49] = cards.NewCard(cards.Five, cards.Spades)    // bob 1
+	board.Deck.Cards[48] = cards.NewCard(cards.Eight, cards.Clubs)    // dealer 2
+	board.Deck.Cards[47] = cards.NewCard(cards.Nine, cards.Hearts)    // ann 2
+	board.Deck.Cards[46] = cards.NewCard(cards.Six, cards.Hearts)     // bob 2
+	board.Deck.Cards[45] = cards.NewCard(cards.Three, cards.Diamonds) // bob 3
+	board.Deck.Cards[44] = cards.NewCard(cards.Four, cards.Diamonds)  // bob 4
+	board.Deal().Wait()
+
+	board.Stage.Actions(board)["s"].Execute(board)
+
+	// Bob hits to 18 and pushes with the dealer.
+	assert.Equal(t, &Conclusion{}, board.Stage)
+	assert.Equal(t, 18, bob.ActiveBet().Hand.Score())
+	assert.Equal(t, "100", bob.Balance.String())
+	assert.Contains(t, board.Log.events, ">> [Bob: Hit](fg-bold,fg-magenta)")
+
+	// The keyboard goes back to Ann for the next round.
+	assert.Equal(t, ann, board.Player)
+}
+
+// Insurance should be offered to each seat in turn.
+func TestInsurance_Seats(t *testing.T) {
+	board, ann, bob := beginSeats(DefaultRules(), nil)
+
+	board.Deck.Cards[51] = cards.NewCard(cards.Ace, cards.Clubs)   // dealer 1
+	board.Deck.Cards[50] = cards.NewCard(cards.Ten, cards.Spades)  // ann 1
+	board.Deck.Cards[49] = cards.NewCard(cards.Five, cards.Spades) // bob 1
+	board.Deck.Cards[48] = cards.NewCard(cards.King, cards.Clubs)  // dealer 2
+	board.Deck.Cards[47] = cards.NewCard(cards.Nine, cards.Hearts) // ann 2
+	board.Deck.Cards[46] = cards.NewCard(cards.Six, cards.Hearts)  // bob 2
+	board.Deal().Wait()
+
+	assert.IsType(t, &Insurance{}, board.Stage)
+	assert.Equal(t, ann, board.Player)
+	board.Stage.Actions(board)["i"].Execute(board)
+
+	assert.IsType(t, &Insurance{}, board.Stage)
+	assert.Equal(t, bob, board.Player)
+	board.Stage.Actions(board)["n"].Execute(board)
+
+	// Dealer peeks and has blackjack.
+	assert.Equal(t, &Conclusion{}, board.Stage)
+	assert.Equal(t, "100", ann.Balance.String())
+	assert.Equal(t, "95", bob.Balance.String())
+}
+
+// Each seat played from the keyboard should be able to set its own bet.
+func TestBetting_Actions_ChangeSeat(t *testing.T) {
+	board, ann, bob := beginSeats(DefaultRules(), nil)
+
+	board.Stage.Actions(board)["c"].Execute(board)
+	assert.Equal(t, bob, board.Player)
+	board.Stage.Actions(board)["r"].Execute(board)
+
+	board.Stage.Actions(board)["c"].Execute(board)
+	assert.Equal(t, ann, board.Player)
+
+	assert.Equal(t, "5", ann.Bets[0].amount.String())
+	assert.Equal(t, "10", bob.Bets[0].amount.String())
+}
+
+// Changing seat should not be offered with only one seat at the keyboard.
+func TestBetting_Actions_ChangeSeatAlone(t *testing.T) {
+	board, _, _ := beginSeats(DefaultRules(), MimicDealer{})
+
+	_, canChangeSeat := board.Stage.Actions(board)["c"]
+	assert.False(t, canChangeSeat)
+}
+
 //
 // Player
 //
 
 // Should be able to render the player's hands and bets.
 func TestPlayer_Render(t *testing.T) {
-	player := (&Board{}).Begin(0).initPlayer(5, 95)
+	player := (&Board{}).Begin(0, DefaultRules()).initPlayer(5, 95)
 	assert.Equal(t, "(0) {[£5.00](fg-bold,fg-cyan,fg-underline)}", player.Render())
 	player.Bets = append(
 		player.Bets,
-		&Bet{big.NewFloat(2), &cards.Hand{}, false},
+		&Bet{amount: big.NewFloat(2), Hand: &cards.Hand{}},
 	)
 	assert.Equal(
 		t,
@@ -236,9 +505,17 @@ func TestPlayer_Render(t *testing.T) {
 	)
 }
 
+// A player's worth should include what they have on the table.
+func TestPlayer_Worth(t *testing.T) {
+	player := (&Board{}).Begin(0, DefaultRules()).initPlayer(10, 15)
+	player.insurance = big.NewFloat(2)
+	assert.Equal(t, "27", player.Worth().String())
+	assert.Equal(t, "15", player.Balance.String())
+}
+
 // Should be able to raise the bet.
 func TestPlayer_Raise(t *testing.T) {
-	player := (&Board{}).Begin(0).initPlayer(10, 15)
+	player := (&Board{}).Begin(0, DefaultRules()).initPlayer(10, 15)
 	raised := player.Raise(5)
 	assert.True(t, raised, "Bet should be raised")
 	assert.Equal(t, big.NewFloat(15), player.Bets[0].amount)
@@ -247,7 +524,7 @@ func TestPlayer_Raise(t *testing.T) {
 
 // Should be able to lower the bet.
 func TestPlayer_Lower(t *testing.T) {
-	player := (&Board{}).Begin(0).initPlayer(15, 0)
+	player := (&Board{}).Begin(0, DefaultRules()).initPlayer(15, 0)
 	lowered := player.Lower(5)
 	assert.True(t, lowered, "Bet should be lowered")
 	assert.Equal(t, big.NewFloat(10), player.Bets[0].amount)
@@ -256,7 +533,7 @@ func TestPlayer_Lower(t *testing.T) {
 
 // Should not be able to raise the bet beyond the available balance.
 func TestPlayer_RaiseMax(t *testing.T) {
-	player := (&Board{}).Begin(0).initPlayer(0, 5)
+	player := (&Board{}).Begin(0, DefaultRules()).initPlayer(0, 5)
 	raised := player.Raise(10)
 	assert.False(t, raised, "Bet should not be raised")
 	assert.Equal(t, big.NewFloat(0), player.Bets[0].amount)
@@ -265,7 +542,7 @@ func TestPlayer_RaiseMax(t *testing.T) {
 
 // Should not be able to lower the bet beyond the minimum.
 func TestPlayer_LowerMin(t *testing.T) {
-	player := (&Board{}).Begin(0).initPlayer(5, 5)
+	player := (&Board{}).Begin(0, DefaultRules()).initPlayer(5, 5)
 	raised := player.Lower(10)
 	assert.False(t, raised, "Bet should not be lowered")
 	assert.Equal(t, big.NewFloat(5), player.Bets[0].amount)
@@ -274,20 +551,20 @@ func TestPlayer_LowerMin(t *testing.T) {
 
 // Should be able to get the bet being played.
 func TestPlayer_ActiveBet(t *testing.T) {
-	player := (&Board{}).Begin(0).initPlayer(5, 5)
+	player := (&Board{}).Begin(0, DefaultRules()).initPlayer(5, 5)
 	assert.IsType(t, &Bet{}, player.ActiveBet())
 }
 
 // The player should be seen as finished if they have no hands left to play on.
 func TestPlayer_IsFinishedTrue(t *testing.T) {
-	player := (&Board{}).Begin(0).initPlayer(5, 5)
+	player := (&Board{}).Begin(0, DefaultRules()).initPlayer(5, 5)
 	player.ActiveBet().stand = true
 	assert.True(t, player.IsFinished())
 }
 
 // The player should be seen as not finished if they have a hand left to play.
 func TestPlayer_IsFinishedFalse(t *testing.T) {
-	player := (&Board{}).Begin(0).initPlayer(5, 5)
+	player := (&Board{}).Begin(0, DefaultRules()).initPlayer(5, 5)
 	assert.False(t, player.IsFinished())
 }
 
@@ -297,7 +574,7 @@ func TestPlayer_IsFinishedFalse(t *testing.T) {
 
 // A bet should be finished if its hand has been stood on.
 func TestBet_IsFinished_Stand(t *testing.T) {
-	bet := &Bet{big.NewFloat(0), &cards.Hand{}, false}
+	bet := &Bet{amount: big.NewFloat(0), Hand: &cards.Hand{}}
 	assert.False(t, bet.IsFinished())
 	bet.stand = true
 	assert.True(t, bet.IsFinished())
@@ -305,7 +582,7 @@ func TestBet_IsFinished_Stand(t *testing.T) {
 
 // A bet should be finished if its hand has blackjack.
 func TestBet_IsFinished_Blackjack(t *testing.T) {
-	bet := &Bet{big.NewFloat(0), &cards.Hand{}, false}
+	bet := &Bet{amount: big.NewFloat(0), Hand: &cards.Hand{}}
 	assert.False(t, bet.IsFinished())
 	bet.Hand.Hit(cards.NewCard(cards.Ace, cards.Spades))
 	bet.Hand.Hit(cards.NewCard(cards.Jack, cards.Diamonds))
@@ -314,7 +591,7 @@ func TestBet_IsFinished_Blackjack(t *testing.T) {
 
 // A bet should be finished if its hand is bust.
 func TestBet_IsFinished_Bust(t *testing.T) {
-	bet := &Bet{big.NewFloat(0), &cards.Hand{}, false}
+	bet := &Bet{amount: big.NewFloat(0), Hand: &cards.Hand{}}
 	assert.False(t, bet.IsFinished())
 	bet.Hand.Hit(cards.NewCard(cards.Queen, cards.Spades))
 	bet.Hand.Hit(cards.NewCard(cards.Jack, cards.Diamonds))
@@ -324,22 +601,22 @@ func TestBet_IsFinished_Bust(t *testing.T) {
 
 // A bet should have focus if it is the only bet.
 func TestBet_HasFocus_Alone(t *testing.T) {
-	player := (&Board{}).Begin(0).initPlayer(5, 95)
+	player := (&Board{}).Begin(0, DefaultRules()).initPlayer(5, 95)
 	assert.True(t, player.Bets[0].HasFocus(player))
 }
 
 // A bet should have focus if it is the first bet and is not finished.
 func TestBet_HasFocus_FirstNotFinished(t *testing.T) {
-	player := (&Board{}).Begin(0).initPlayer(5, 95)
-	player.Bets = append(player.Bets, &Bet{big.NewFloat(0), &cards.Hand{}, false})
+	player := (&Board{}).Begin(0, DefaultRules()).initPlayer(5, 95)
+	player.Bets = append(player.Bets, &Bet{amount: big.NewFloat(0), Hand: &cards.Hand{}})
 	assert.True(t, player.Bets[0].HasFocus(player))
 	assert.False(t, player.Bets[1].HasFocus(player))
 }
 
 // A bet should have focus if it is second bet and the first is finished.
 func TestBet_HasFocus_SecondFirstFinished(t *testing.T) {
-	player := (&Board{}).Begin(0).initPlayer(5, 95)
-	player.Bets = append(player.Bets, &Bet{big.NewFloat(0), &cards.Hand{}, false})
+	player := (&Board{}).Begin(0, DefaultRules()).initPlayer(5, 95)
+	player.Bets = append(player.Bets, &Bet{amount: big.NewFloat(0), Hand: &cards.Hand{}})
 	player.Bets[0].stand = true
 	assert.False(t, player.Bets[0].HasFocus(player))
 	assert.True(t, player.Bets[1].HasFocus(player))
@@ -348,15 +625,58 @@ func TestBet_HasFocus_SecondFirstFinished(t *testing.T) {
 // A bet should have focus if it is second bet and the first is finished, even
 // if the third bet is not finished.
 func TestBet_HasFocus_SecondFirstFinishedThirdNot(t *testing.T) {
-	player := (&Board{}).Begin(0).initPlayer(5, 95)
-	player.Bets = append(player.Bets, &Bet{big.NewFloat(0), &cards.Hand{}, false})
-	player.Bets = append(player.Bets, &Bet{big.NewFloat(0), &cards.Hand{}, false})
+	player := (&Board{}).Begin(0, DefaultRules()).initPlayer(5, 95)
+	player.Bets = append(player.Bets, &Bet{amount: big.NewFloat(0), Hand: &cards.Hand{}})
+	player.Bets = append(player.Bets, &Bet{amount: big.NewFloat(0), Hand: &cards.Hand{}})
 	player.Bets[0].stand = true
 	assert.False(t, player.Bets[0].HasFocus(player))
 	assert.True(t, player.Bets[1].HasFocus(player))
 	assert.False(t, player.Bets[2].HasFocus(player))
 }
 
+// A bet's outcome should be assessed against the dealer's hand.
+func TestBet_Outcome(t *testing.T) {
+	hand := func(ranks ...cards.Rank) *cards.Hand {
+		h := &cards.Hand{}
+		for _, rank := range ranks {
+			h.Hit(cards.NewCard(rank, cards.Spades))
+		}
+		return h
+	}
+	bet := func(split bool, ranks ...cards.Rank) *Bet {
+		return &Bet{amount: big.NewFloat(5), Hand: hand(ranks...), split: split}
+	}
+
+	dealer := hand(cards.Ten, cards.Eight)
+	assert.Equal(t, Win, bet(false, cards.Ten, cards.Nine).Outcome(dealer))
+	assert.Equal(t, Push, bet(false, cards.Ten, cards.Eight).Outcome(dealer))
+	assert.Equal(t, Lose, bet(false, cards.Ten, cards.Seven).Outcome(dealer))
+	assert.Equal(
+		t,
+		Lose,
+		bet(false, cards.Ten, cards.Seven, cards.Six).Outcome(dealer),
+	)
+	assert.Equal(t, Blackjack, bet(false, cards.Ace, cards.King).Outcome(dealer))
+	assert.Equal(t, Win, bet(true, cards.Ace, cards.King).Outcome(dealer))
+
+	// A player blackjack beats a dealer 21 made from more cards.
+	dealer = hand(cards.Ten, cards.Eight, cards.Three)
+	assert.Equal(t, Blackjack, bet(false, cards.Ace, cards.King).Outcome(dealer))
+
+	// A dealer blackjack beats a player 21 made from more cards.
+	dealer = hand(cards.Ace, cards.Queen)
+	assert.Equal(
+		t,
+		Lose,
+		bet(false, cards.Ten, cards.Eight, cards.Three).Outcome(dealer),
+	)
+	assert.Equal(t, Push, bet(false, cards.Ace, cards.King).Outcome(dealer))
+
+	surrendered := bet(false, cards.Ten, cards.Six)
+	surrendered.surrendered = true
+	assert.Equal(t, Surrendered, surrendered.Outcome(dealer))
+}
+
 //
 // Game stages and actions
 //
@@ -370,7 +690,7 @@ func TestBetting_Begin(t *testing.T) {
 	betting := Betting{}
 
 	board := &Board{}
-	board.Begin(0)
+	board.Begin(0, DefaultRules())
 
 	board.ChangeStage(&betting)
 
@@ -382,15 +702,48 @@ func TestBetting_Begin(t *testing.T) {
 	)
 }
 
+// The deck should not be shuffled between rounds before the cut card.
+func TestBetting_Begin_KeepsDeck(t *testing.T) {
+	board := &Board{}
+	board.Begin(0, DefaultRules())
+	board.Deck.Pop()
+	board.Deck.Pop()
+
+	board.ChangeStage(&Betting{})
+
+	assert.Len(t, board.Deck.Cards, 50)
+}
+
+// The deck should be shuffled at the start of a round once the cut card has
+// been reached.
+func TestBetting_Begin_CutCard(t *testing.T) {
+	board := &Board{}
+	board.Begin(0, DefaultRules())
+	for i := 0; i < 40; i++ {
+		board.Deck.Pop()
+	}
+
+	board.ChangeStage(&Betting{})
+
+	assert.Len(t, board.Deck.Cards, 52)
+	assert.Equal(
+		t,
+		"Cut card reached, deck shuffled",
+		board.Log.events[len(board.Log.events)-1],
+	)
+}
+
 // The player should be able to end the betting stage by dealing.
 func TestBetting_Actions_Deal(t *testing.T) {
 	betting := Betting{}
 
 	board := &Board{}
-	board.Begin(0)
+	board.Begin(0, DefaultRules())
 
-	// make sure we don't get blackjack
-	board.Deck.ForceNext(cards.NewCard(cards.Two, cards.Diamonds))
+	// make sure we don't get blackjack or an insurance offer
+	board.Deck.Cards[51] = cards.NewCard(cards.Nine, cards.Clubs)   // dealer 1
+	board.Deck.Cards[50] = cards.NewCard(cards.Two, cards.Diamonds) // player 1
+	board.Deck.Cards[48] = cards.NewCard(cards.Three, cards.Hearts) // player 2
 
 	deal := betting.Actions(board)["d"]
 	deal.Execute(board)
@@ -405,7 +758,7 @@ func TestBetting_Actions_Raise(t *testing.T) {
 	betting := Betting{}
 
 	board := &Board{}
-	board.Begin(0)
+	board.Begin(0, DefaultRules())
 
 	originalBetAmount := *board.Player.Bets[0].amount
 
@@ -427,7 +780,7 @@ func TestBetting_Actions_Lower(t *testing.T) {
 	betting := Betting{}
 
 	board := &Board{}
-	board.Begin(0)
+	board.Begin(0, DefaultRules())
 
 	raise := betting.Actions(board)["r"]
 	raise.Execute(board)
@@ -468,6 +821,296 @@ func TestObserving_Actions(t *testing.T) {
 	assert.Empty(t, observing.Actions(board))
 }
 
+//
+// Insurance
+//
+
+// Deal cards to the board with the dealer showing an ace.
+func dealDealerAce(board *Board, player1, dealer2, player2 cards.Rank) {
+	board.Deck.Cards[51] = cards.NewCard(cards.Ace, cards.Clubs)      // dealer 1
+	board.Deck.Cards[50] = cards.NewCard(player1, cards.Spades)       // player 1
+	board.Deck.Cards[49] = cards.NewCard(dealer2, cards.Clubs)        // dealer 2
+	board.Deck.Cards[48] = cards.NewCard(player2, cards.Diamonds)     // player 2
+	board.Deck.Cards[47] = cards.NewCard(cards.Seven, cards.Diamonds) // dealer 3
+	board.Deal().Wait()
+}
+
+// Insurance should be offered when the dealer shows an ace.
+func TestInsurance_Offered(t *testing.T) {
+	board := &Board{}
+	board.Begin(0, DefaultRules())
+
+	dealDealerAce(board, cards.Ten, cards.Five, cards.Eight)
+
+	assert.IsType(t, &Insurance{}, board.Stage)
+	_, canInsure := board.Stage.Actions(board)["i"]
+	assert.True(t, canInsure)
+	_, canTakeEvenMoney := board.Stage.Actions(board)["e"]
+	assert.False(t, canTakeEvenMoney)
+}
+
+// Declining insurance should move on to the player stage.
+func TestInsurance_Actions_Decline(t *testing.T) {
+	board := &Board{}
+	board.Begin(0, DefaultRules())
+
+	dealDealerAce(board, cards.Ten, cards.Five, cards.Eight)
+	board.Stage.Actions(board)["n"].Execute(board)
+
+	assert.IsType(t, &PlayerStage{}, board.Stage)
+	assert.Equal(t, big.NewFloat(95), board.Player.Balance)
+}
+
+// Insurance should pay 2:1 when the dealer has blackjack.
+func TestInsurance_Actions_InsureWin(t *testing.T) {
+	board := &Board{}
+	board.Begin(0, DefaultRules())
+
+	dealDealerAce(board, cards.Ten, cards.King, cards.Eight)
+	board.Stage.Actions(board)["i"].Execute(board)
+
+	// Dealer peeks and has blackjack. Lose the £5 bet, but get the £2.50
+	// insurance back plus £5.
+	assert.Equal(t, &Conclusion{}, board.Stage)
+	assert.Equal(t, "100", board.Player.Balance.String())
+}
+
+// Insurance should be lost when the dealer does not have blackjack.
+func TestInsurance_Actions_InsureLose(t *testing.T) {
+	board := &Board{}
+	board.Begin(0, DefaultRules())
+
+	dealDealerAce(board, cards.Ten, cards.Nine, cards.Nine)
+	board.Stage.Actions(board)["i"].Execute(board)
+	board.Stage.Actions(board)["s"].Execute(board)
+
+	// Dealer has 20, beating the player's 19.
+	assert.Equal(t, &Conclusion{}, board.Stage)
+	assert.Equal(t, "92.5", board.Player.Balance.String())
+	assert.Contains(t, board.Log.events, "[Player loses £2.50 insurance](fg-red)")
+}
+
+// Partial insurance should insure a quarter of the bet.
+func TestInsurance_Actions_Partial(t *testing.T) {
+	board := &Board{}
+	board.Begin(0, DefaultRules())
+
+	dealDealerAce(board, cards.Ten, cards.Nine, cards.Nine)
+	board.Stage.Actions(board)["j"].Execute(board)
+	board.Stage.Actions(board)["s"].Execute(board)
+
+	// Dealer has 20, so both the £5 bet and the £1.25 insurance are lost.
+	assert.Equal(t, &Conclusion{}, board.Stage)
+	assert.Equal(t, "93.75", board.Player.Balance.String())
+	assert.Contains(t, board.Log.events, "[Player loses £1.25 insurance](fg-red)")
+}
+
+// A player with blackjack should be able to take even money.
+func TestInsurance_Actions_EvenMoney(t *testing.T) {
+	board := &Board{}
+	board.Begin(0, DefaultRules())
+
+	dealDealerAce(board, cards.Ace, cards.King, cards.Jack)
+	_, canInsure := board.Stage.Actions(board)["i"]
+	assert.False(t, canInsure)
+
+	board.Stage.Actions(board)["e"].Execute(board)
+
+	// The £5 bet is paid at 1:1 even though the dealer had blackjack.
+	assert.Equal(t, &Conclusion{}, board.Stage)
+	assert.Equal(t, "105", board.Player.Balance.String())
+}
+
+//
+// Dealer peek
+//
+
+// The round should end straight after dealing if the dealer peeks and has
+// blackjack.
+func TestBoard_Deal_PeekBlackjack(t *testing.T) {
+	board := &Board{}
+	board.Begin(0, DefaultRules())
+
+	board.Deck.Cards[51] = cards.NewCard(cards.King, cards.Clubs)  // dealer 1
+	board.Deck.Cards[50] = cards.NewCard(cards.Six, cards.Spades)  // player 1
+	board.Deck.Cards[49] = cards.NewCard(cards.Ace, cards.Clubs)   // dealer 2
+	board.Deck.Cards[48] = cards.NewCard(cards.Five, cards.Hearts) // player 2
+	board.Deal().Wait()
+
+	// Only the original £5 bet is lost.
+	assert.Equal(t, &Conclusion{}, board.Stage)
+	assert.Equal(t, big.NewFloat(95), board.Player.Balance)
+	assert.True(t, board.Dealer.hand.Cards[1].IsFaceUp())
+}
+
+// Play should carry on after the dealer peeks without blackjack.
+func TestBoard_Deal_PeekNoBlackjack(t *testing.T) {
+	board := &Board{}
+	board.Begin(0, DefaultRules())
+
+	board.Deck.Cards[51] = cards.NewCard(cards.King, cards.Clubs)  // dealer 1
+	board.Deck.Cards[50] = cards.NewCard(cards.Six, cards.Spades)  // player 1
+	board.Deck.Cards[49] = cards.NewCard(cards.Nine, cards.Clubs)  // dealer 2
+	board.Deck.Cards[48] = cards.NewCard(cards.Five, cards.Hearts) // player 2
+	board.Deal().Wait()
+
+	assert.IsType(t, &PlayerStage{}, board.Stage)
+	assert.False(t, board.Dealer.hand.Cards[1].IsFaceUp())
+	assert.Equal(t, "Dealer peeks, no blackjack", board.Log.events[len(board.Log.events)-1])
+}
+
+// The dealer should take no hole card under European rules, drawing their
+// second card during their turn.
+func TestBoard_Deal_NoHoleCard(t *testing.T) {
+	rules := DefaultRules()
+	rules.HoleCard = EuropeanNoHoleCard
+
+	board := &Board{}
+	board.Begin(0, rules)
+
+	board.Deck.Cards[51] = cards.NewCard(cards.King, cards.Clubs)  // dealer 1
+	board.Deck.Cards[50] = cards.NewCard(cards.Six, cards.Spades)  // player 1
+	board.Deck.Cards[49] = cards.NewCard(cards.Five, cards.Hearts) // player 2
+	board.Deck.Cards[48] = cards.NewCard(cards.Ace, cards.Clubs)   // dealer 2
+	board.Deal().Wait()
+
+	assert.IsType(t, &PlayerStage{}, board.Stage)
+	assert.Len(t, board.Dealer.hand.Cards, 1)
+
+	board.Stage.Actions(board)["s"].Execute(board)
+
+	assert.Equal(t, &Conclusion{}, board.Stage)
+	assert.True(t, board.Dealer.hand.HasBlackJack())
+}
+
+//
+// Surrender
+//
+
+// Surrender should not be offered unless the house rules allow it.
+func TestPlayerStage_Actions_NoSurrender(t *testing.T) {
+	board := &Board{}
+	board.Begin(0, DefaultRules())
+
+	board.Deck.Cards[51] = cards.NewCard(cards.Ten, cards.Clubs)   // dealer 1
+	board.Deck.Cards[50] = cards.NewCard(cards.Ten, cards.Spades)  // player 1
+	board.Deck.Cards[49] = cards.NewCard(cards.Seven, cards.Clubs) // dealer 2
+	board.Deck.Cards[48] = cards.NewCard(cards.Six, cards.Hearts)  // player 2
+	board.Deal().Wait()
+
+	_, canSurrender := board.Stage.Actions(board)["u"]
+	assert.False(t, canSurrender)
+}
+
+// The player should be able to surrender late, getting half their bet back.
+func TestPlayerStage_Actions_LateSurrender(t *testing.T) {
+	rules := DefaultRules()
+	rules.Surrender = SurrenderLate
+
+	board := &Board{}
+	board.Begin(0, rules)
+
+	board.Deck.Cards[51] = cards.NewCard(cards.Ten, cards.Clubs)   // dealer 1
+	board.Deck.Cards[50] = cards.NewCard(cards.Ten, cards.Spades)  // player 1
+	board.Deck.Cards[49] = cards.NewCard(cards.Seven, cards.Clubs) // dealer 2
+	board.Deck.Cards[48] = cards.NewCard(cards.Six, cards.Hearts)  // player 2
+	board.Deck.Cards[47] = cards.NewCard(cards.Two, cards.Hearts)  // player 3
+	board.Deal().Wait()
+
+	surrender, canSurrender := board.Stage.Actions(board)["u"]
+	assert.True(t, canSurrender)
+
+	surrender.Execute(board)
+
+	assert.Equal(t, &Conclusion{}, board.Stage)
+	assert.Equal(t, "97.5", board.Player.Balance.String())
+	assert.Contains(
+		t,
+		board.Log.events,
+		"[Player surrenders, gets £2.50 back](fg-yellow)",
+	)
+}
+
+// Surrender should only be offered on the first two cards.
+func TestPlayerStage_Actions_SurrenderAfterHit(t *testing.T) {
+	rules := DefaultRules()
+	rules.Surrender = SurrenderLate
+
+	board := &Board{}
+	board.Begin(0, rules)
+
+	board.Deck.Cards[51] = cards.NewCard(cards.Ten, cards.Clubs)    // dealer 1
+	board.Deck.Cards[50] = cards.NewCard(cards.Two, cards.Spades)   // player 1
+	board.Deck.Cards[49] = cards.NewCard(cards.Seven, cards.Clubs)  // dealer 2
+	board.Deck.Cards[48] = cards.NewCard(cards.Three, cards.Hearts) // player 2
+	board.Deck.Cards[47] = cards.NewCard(cards.Two, cards.Hearts)   // player 3
+	board.Deal().Wait()
+	board.HitPlayer().Wait()
+
+	_, canSurrender := board.Stage.Actions(board)["u"]
+	assert.False(t, canSurrender)
+}
+
+// Late surrender is too late once the dealer has peeked and found blackjack.
+func TestPlayerStage_Actions_LateSurrenderDealerBlackjack(t *testing.T) {
+	rules := DefaultRules()
+	rules.Surrender = SurrenderLate
+
+	board := &Board{}
+	board.Begin(0, rules)
+
+	board.Deck.Cards[51] = cards.NewCard(cards.Ten, cards.Clubs)  // dealer 1
+	board.Deck.Cards[50] = cards.NewCard(cards.Ten, cards.Spades) // player 1
+	board.Deck.Cards[49] = cards.NewCard(cards.Ace, cards.Clubs)  // dealer 2
+	board.Deck.Cards[48] = cards.NewCard(cards.Six, cards.Hearts) // player 2
+	board.Deal().Wait()
+
+	assert.Equal(t, &Conclusion{}, board.Stage)
+	assert.Equal(t, big.NewFloat(95), board.Player.Balance)
+}
+
+// Early surrender should be offered before the dealer peeks, so the player
+// gets half their bet back even against a dealer blackjack.
+func TestEarlySurrender_Actions_Surrender(t *testing.T) {
+	rules := DefaultRules()
+	rules.Surrender = SurrenderEarly
+
+	board := &Board{}
+	board.Begin(0, rules)
+
+	board.Deck.Cards[51] = cards.NewCard(cards.Ten, cards.Clubs)  // dealer 1
+	board.Deck.Cards[50] = cards.NewCard(cards.Ten, cards.Spades) // player 1
+	board.Deck.Cards[49] = cards.NewCard(cards.Ace, cards.Clubs)  // dealer 2
+	board.Deck.Cards[48] = cards.NewCard(cards.Six, cards.Hearts) // player 2
+	board.Deal().Wait()
+
+	assert.IsType(t, &EarlySurrender{}, board.Stage)
+
+	board.Stage.Actions(board)["u"].Execute(board)
+
+	assert.Equal(t, &Conclusion{}, board.Stage)
+	assert.Equal(t, "97.5", board.Player.Balance.String())
+}
+
+// Playing on from early surrender should move on to the player stage.
+func TestEarlySurrender_Actions_PlayOn(t *testing.T) {
+	rules := DefaultRules()
+	rules.Surrender = SurrenderEarly
+
+	board := &Board{}
+	board.Begin(0, rules)
+
+	board.Deck.Cards[51] = cards.NewCard(cards.Ten, cards.Clubs)   // dealer 1
+	board.Deck.Cards[50] = cards.NewCard(cards.Ten, cards.Spades)  // player 1
+	board.Deck.Cards[49] = cards.NewCard(cards.Seven, cards.Clubs) // dealer 2
+	board.Deck.Cards[48] = cards.NewCard(cards.Six, cards.Hearts)  // player 2
+	board.Deal().Wait()
+
+	board.Stage.Actions(board)["c"].Execute(board)
+
+	assert.IsType(t, &PlayerStage{}, board.Stage)
+}
+
 //
 // Player stage
 //
@@ -477,7 +1120,7 @@ func TestPlayerStage_Actions_Hit(t *testing.T) {
 	playerStage := PlayerStage{}
 
 	board := &Board{}
-	board.Begin(0)
+	board.Begin(0, DefaultRules())
 
 	originalHandSize := len(board.Player.ActiveBet().Hand.Cards)
 
@@ -498,7 +1141,7 @@ func TestPlayerStage_Actions_Stand(t *testing.T) {
 	playerStage := PlayerStage{}
 
 	board := &Board{}
-	board.Begin(0)
+	board.Begin(0, DefaultRules())
 
 	stand := playerStage.Actions(board)["s"]
 	stand.Execute(board)
@@ -512,7 +1155,13 @@ func TestPlayerStage_Actions_DoubleDown(t *testing.T) {
 	playerStage := PlayerStage{}
 
 	board := &Board{}
-	board.Begin(0)
+	board.Begin(0, DefaultRules())
+
+	board.Deck.Cards[51] = cards.NewCard(cards.Ten, cards.Clubs)     // dealer 1
+	board.Deck.Cards[50] = cards.NewCard(cards.Six, cards.Spades)    // player 1
+	board.Deck.Cards[49] = cards.NewCard(cards.Seven, cards.Clubs)   // dealer 2
+	board.Deck.Cards[48] = cards.NewCard(cards.Five, cards.Diamonds) // player 2
+	board.Deal().Wait()
 
 	doubleDown, canDoubleDown := playerStage.Actions(board)["d"]
 	assert.True(t, canDoubleDown)
@@ -523,11 +1172,139 @@ func TestPlayerStage_Actions_DoubleDown(t *testing.T) {
 	assert.Equal(t, &Conclusion{}, board.Stage)
 }
 
+// Doubling down should only be offered on the first two cards.
+func TestPlayerStage_Actions_CanNotDoubleAfterHit(t *testing.T) {
+	board := &Board{}
+	board.Begin(0, DefaultRules())
+
+	board.Deck.Cards[51] = cards.NewCard(cards.Ten, cards.Clubs)      // dealer 1
+	board.Deck.Cards[50] = cards.NewCard(cards.Two, cards.Spades)     // player 1
+	board.Deck.Cards[49] = cards.NewCard(cards.Seven, cards.Clubs)    // dealer 2
+	board.Deck.Cards[48] = cards.NewCard(cards.Three, cards.Diamonds) // player 2
+	board.Deck.Cards[47] = cards.NewCard(cards.Four, cards.Hearts)    // player 3
+	board.Deal().Wait()
+
+	_, canDoubleDown := board.Stage.Actions(board)["d"]
+	assert.True(t, canDoubleDown)
+
+	board.HitPlayer().Wait()
+
+	_, canDoubleDown = board.Stage.Actions(board)["d"]
+	assert.False(t, canDoubleDown)
+}
+
+// Doubling down should only be offered on the totals the house rules allow.
+func TestPlayerStage_Actions_DoubleRestricted(t *testing.T) {
+	rules := DefaultRules()
+	rules.Double = DoubleTenToEleven
+
+	board := &Board{}
+	board.Begin(0, rules)
+
+	board.Deck.Cards[51] = cards.NewCard(cards.Ten, cards.Clubs)     // dealer 1
+	board.Deck.Cards[50] = cards.NewCard(cards.Four, cards.Spades)   // player 1
+	board.Deck.Cards[49] = cards.NewCard(cards.Seven, cards.Clubs)   // dealer 2
+	board.Deck.Cards[48] = cards.NewCard(cards.Five, cards.Diamonds) // player 2
+	board.Deal().Wait()
+
+	_, canDoubleDown := board.Stage.Actions(board)["d"]
+	assert.False(t, canDoubleDown)
+}
+
+// Doubling down after splitting should double the split hand's bet.
+func TestPlayerStage_Actions_DoubleAfterSplit(t *testing.T) {
+	board := &Board{}
+	board.Begin(0, DefaultRules())
+
+	board.Deck.Cards[51] = cards.NewCard(cards.Ten, cards.Clubs)     // dealer 1
+	board.Deck.Cards[50] = cards.NewCard(cards.Five, cards.Spades)   // player 1
+	board.Deck.Cards[49] = cards.NewCard(cards.Seven, cards.Clubs)   // dealer 2
+	board.Deck.Cards[48] = cards.NewCard(cards.Five, cards.Diamonds) // player 2
+	board.Deck.Cards[47] = cards.NewCard(cards.Six, cards.Hearts)    // split 1
+	board.Deck.Cards[46] = cards.NewCard(cards.Two, cards.Hearts)    // split 2
+	board.Deal().Wait()
+
+	board.Stage.Actions(board)["p"].Execute(board)
+	board.Stage.Actions(board)["d"].Execute(board)
+
+	assert.Equal(t, big.NewFloat(10), board.Player.Bets[0].amount)
+	assert.Equal(t, big.NewFloat(5), board.Player.Bets[1].amount)
+}
+
+// Doubling down after splitting should not be offered if the house rules do
+// not allow it.
+func TestPlayerStage_Actions_NoDoubleAfterSplit(t *testing.T) {
+	rules := DefaultRules()
+	rules.DoubleAfterSplit = false
+
+	board := &Board{}
+	board.Begin(0, rules)
+
+	board.Deck.Cards[51] = cards.NewCard(cards.Ten, cards.Clubs)     // dealer 1
+	board.Deck.Cards[50] = cards.NewCard(cards.Five, cards.Spades)   // player 1
+	board.Deck.Cards[49] = cards.NewCard(cards.Seven, cards.Clubs)   // dealer 2
+	board.Deck.Cards[48] = cards.NewCard(cards.Five, cards.Diamonds) // player 2
+	board.Deck.Cards[47] = cards.NewCard(cards.Six, cards.Hearts)    // split 1
+	board.Deck.Cards[46] = cards.NewCard(cards.Two, cards.Hearts)    // split 2
+	board.Deal().Wait()
+
+	board.Stage.Actions(board)["p"].Execute(board)
+
+	_, canDoubleDown := board.Stage.Actions(board)["d"]
+	assert.False(t, canDoubleDown)
+}
+
+// Split aces should only get one card each unless the house rules allow hitting
+// them.
+func TestPlayerStage_Actions_SplitAces(t *testing.T) {
+	rules := DefaultRules()
+	rules.HitSplitAces = false
+
+	board := &Board{}
+	board.Begin(0, rules)
+
+	board.Deck.Cards[51] = cards.NewCard(cards.Ten, cards.Clubs)    // dealer 1
+	board.Deck.Cards[50] = cards.NewCard(cards.Ace, cards.Spades)   // player 1
+	board.Deck.Cards[49] = cards.NewCard(cards.Seven, cards.Clubs)  // dealer 2
+	board.Deck.Cards[48] = cards.NewCard(cards.Ace, cards.Diamonds) // player 2
+	board.Deck.Cards[47] = cards.NewCard(cards.Six, cards.Hearts)   // split 1
+	board.Deck.Cards[46] = cards.NewCard(cards.Two, cards.Hearts)   // split 2
+	board.Deal().Wait()
+
+	board.Stage.Actions(board)["p"].Execute(board)
+
+	// Both hands are finished so the round should play through.
+	assert.Len(t, board.Player.Bets, 2)
+	assert.Equal(t, &Conclusion{}, board.Stage)
+}
+
+// Should not be able to split more times than the house rules allow.
+func TestPlayerStage_Actions_MaxSplits(t *testing.T) {
+	rules := DefaultRules()
+	rules.MaxSplits = 1
+
+	board := &Board{}
+	board.Begin(0, rules)
+
+	board.Deck.Cards[51] = cards.NewCard(cards.Ten, cards.Clubs)      // dealer 1
+	board.Deck.Cards[50] = cards.NewCard(cards.Eight, cards.Spades)   // player 1
+	board.Deck.Cards[49] = cards.NewCard(cards.Seven, cards.Clubs)    // dealer 2
+	board.Deck.Cards[48] = cards.NewCard(cards.Eight, cards.Diamonds) // player 2
+	board.Deck.Cards[47] = cards.NewCard(cards.Eight, cards.Hearts)   // split 1
+	board.Deck.Cards[46] = cards.NewCard(cards.Two, cards.Hearts)     // split 2
+	board.Deal().Wait()
+
+	board.Stage.Actions(board)["p"].Execute(board)
+
+	_, canSplit := board.Stage.Actions(board)["p"]
+	assert.False(t, canSplit)
+}
+
 // Splitting should not be possible when the active Hand does not consist of two
 // cards of the same value.
 func TestPlayerStage_Actions_CanNotSplit(t *testing.T) {
 	board := &Board{}
-	board.Begin(0)
+	board.Begin(0, DefaultRules())
 
 	// Ensure splitting is not allowed first.
 	board.Deck.Cards[51] = cards.NewCard(cards.Ten, cards.Clubs)     // dealer 1
@@ -546,7 +1323,7 @@ func TestPlayerStage_Actions_CanNotSplit(t *testing.T) {
 // the same value.
 func TestPlayerStage_Actions_CanSplit(t *testing.T) {
 	board := &Board{}
-	board.Begin(0)
+	board.Begin(0, DefaultRules())
 
 	// Ensure splitting is allowed.
 	board.Deck.Cards[51] = cards.NewCard(cards.Ten, cards.Clubs)     // dealer 1
@@ -574,7 +1351,7 @@ func TestPlayerStage_Actions_CanSplit(t *testing.T) {
 // player stage should be skipped and we should go through to the conclusion.
 func TestPlayerStage_SkippedOnBlackjack(t *testing.T) {
 	board := &Board{}
-	board.Begin(0)
+	board.Begin(0, DefaultRules())
 
 	// Force blackjack for player.
 	board.Deck.Cards[51] = cards.NewCard(cards.Ten, cards.Clubs)     // dealer 1
@@ -595,7 +1372,7 @@ func TestPlayerStage_SkippedOnBlackjack(t *testing.T) {
 // Should be able to start a new round.
 func TestConclusion_Actions_NewRound(t *testing.T) {
 	board := &Board{}
-	board.Begin(0)
+	board.Begin(0, DefaultRules())
 
 	// Force blackjack for player.
 	board.Deck.Cards[51] = cards.NewCard(cards.Ten, cards.Clubs)     // dealer 1
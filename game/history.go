@@ -0,0 +1,311 @@
+package game
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/hughgrigg/blackjack/cards"
+)
+
+//
+// Hand history
+//
+
+// History records every round played at a board as a structured hand history,
+// from the shoe it was dealt from to how each bet was settled.
+type History struct {
+	// Finished rounds, oldest first.
+	Rounds []*Round
+	// Each finished round is written to this as a line of JSON, if it is set.
+	Out io.Writer
+	// The latest error writing a round out, if any.
+	Err     error
+	current *Round
+}
+
+// Round is the hand history of a single round.
+type Round struct {
+	Number int `json:"round"`
+	// The seed the shoe was shuffled with, and the number of cards that had
+	// been dealt from it before the round started.
+	Seed     int64       `json:"seed"`
+	Decks    int         `json:"decks"`
+	Position int         `json:"position"`
+	Rules    Rules       `json:"rules"`
+	Seats    []SeatEntry `json:"seats"`
+	Events   []HandEvent `json:"events"`
+}
+
+// SeatEntry is a seat as it was when the cards were dealt.
+type SeatEntry struct {
+	Name    string  `json:"name"`
+	Bot     bool    `json:"bot,omitempty"`
+	Balance float64 `json:"balance"`
+	Bet     float64 `json:"bet"`
+	// Any side bets, by kind.
+	SideBets map[SideBetKind]float64 `json:"side_bets,omitempty"`
+}
+
+// HandEvent is something that happened during a round. Which fields are set
+// depends on its type.
+type HandEvent struct {
+	Type HandEventType `json:"type"`
+	// The seat the event is for, or the dealer.
+	Seat string `json:"seat,omitempty"`
+	// The number of the seat at the table, counting from 1, so that seats with
+	// the same name can be told apart. The dealer has no number.
+	SeatNumber int `json:"seat_number,omitempty"`
+	// The number of the seat's hand, counting from 1, if it has split.
+	Hand     int         `json:"hand,omitempty"`
+	Card     string      `json:"card,omitempty"`
+	FaceDown bool        `json:"face_down,omitempty"`
+	Command  CommandType `json:"command,omitempty"`
+	SideBet  SideBetKind `json:"side_bet,omitempty"`
+	Outcome  string      `json:"outcome,omitempty"`
+	Staked   float64     `json:"staked,omitempty"`
+	Paid     float64     `json:"paid,omitempty"`
+	// How much a decision was made for, e.g. the amount insured.
+	Amount float64 `json:"amount,omitempty"`
+	// The seed of a shoe shuffled part way through the round.
+	Seed int64 `json:"seed,omitempty"`
+}
+
+// HandEventType is the kind of thing that happened in a hand event.
+type HandEventType string
+
+// The kinds of hand event.
+const (
+	EventDeal      HandEventType = "deal"
+	EventReveal    HandEventType = "reveal"
+	EventDecision  HandEventType = "decision"
+	EventSettle    HandEventType = "settle"
+	EventInsurance HandEventType = "insurance"
+	EventShuffle   HandEventType = "shuffle"
+	EventSideBet   HandEventType = "side bet"
+)
+
+// The name the dealer goes by in hand histories.
+const dealerSeat = "Dealer"
+
+// begin starts recording a round as the cards are about to be dealt.
+func (h *History) begin(b *Board) {
+	if h == nil {
+		return
+	}
+	h.current = &Round{
+		Number:   len(h.Rounds) + 1,
+		Seed:     b.Deck.Seed,
+		Decks:    b.Deck.DeckCount(),
+		Position: b.Deck.Dealt(),
+		Rules:    b.Rules,
+		Events:   []HandEvent{},
+	}
+	for _, player := range b.Players {
+		seat := SeatEntry{
+			Name:    player.Name,
+			Bot:     player.IsBot(),
+			Balance: floatOf(player.Balance),
+			Bet:     floatOf(player.Bets[0].amount),
+		}
+		for _, sideBet := range player.SideBets {
+			if seat.SideBets == nil {
+				seat.SideBets = map[SideBetKind]float64{}
+			}
+			seat.SideBets[sideBet.Kind] = floatOf(sideBet.amount)
+		}
+		h.current.Seats = append(h.current.Seats, seat)
+	}
+}
+
+// record adds an event to the round being played, if there is one, giving its
+// place in the round.
+func (h *History) record(event HandEvent) int {
+	if h == nil || h.current == nil {
+		return -1
+	}
+	h.current.Events = append(h.current.Events, event)
+	return len(h.current.Events) - 1
+}
+
+// withdraw takes an event recorded in the round being played back out, along
+// with anything recorded after it.
+func (h *History) withdraw(at int) {
+	if h == nil || h.current == nil || at < 0 || at >= len(h.current.Events) {
+		return
+	}
+	h.current.Events = h.current.Events[:at]
+}
+
+// stake records the amount the decision just made in the round being played
+// was for.
+func (h *History) stake(amount float64) {
+	if h == nil || h.current == nil || len(h.current.Events) == 0 {
+		return
+	}
+	last := &h.current.Events[len(h.current.Events)-1]
+	if last.Type == EventDecision {
+		last.Amount = amount
+	}
+}
+
+// dealt records a card dealt to one of a seat's hands, or to the dealer.
+func (h *History) dealt(seat string, number int, hand int, card *cards.Card) {
+	h.record(HandEvent{
+		Type:       EventDeal,
+		Seat:       seat,
+		SeatNumber: number,
+		Hand:       hand,
+		Card:       cardName(card),
+		FaceDown:   !card.IsFaceUp(),
+	})
+}
+
+// finish stores the round being played and writes it out.
+func (h *History) finish() {
+	if h == nil || h.current == nil {
+		return
+	}
+	round := h.current
+	h.current = nil
+	h.Rounds = append(h.Rounds, round)
+	if h.Out != nil {
+		if err := WriteRounds(h.Out, []*Round{round}); err != nil {
+			h.Err = err
+		}
+	}
+}
+
+// handNumber gets the number of a bet among a seat's hands, counting from 1.
+func handNumber(player *Player, bet *Bet) int {
+	for i, other := range player.Bets {
+		if other == bet {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// cardName gets the notation of a card whichever way it is facing.
+func cardName(card *cards.Card) string {
+	return string(card.Rank()) + string(card.Suit())
+}
+
+// WriteRounds writes hand histories as JSON Lines, one round per line.
+func WriteRounds(w io.Writer, rounds []*Round) error {
+	encoder := json.NewEncoder(w)
+	for _, round := range rounds {
+		if err := encoder.Encode(round); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadRounds reads hand histories written as JSON Lines.
+func ReadRounds(r io.Reader) ([]*Round, error) {
+	rounds := []*Round{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		round := &Round{}
+		if err := json.Unmarshal(scanner.Bytes(), round); err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err)
+		}
+		rounds = append(rounds, round)
+	}
+	return rounds, scanner.Err()
+}
+
+// Text gets the round written out for people to read.
+func (r *Round) Text() string {
+	buffer := bytes.Buffer{}
+	buffer.WriteString(fmt.Sprintf(
+		"Round %d | shoe of %d decks, seed %d, %d cards in\n",
+		r.Number,
+		r.Decks,
+		r.Seed,
+		r.Position,
+	))
+	for _, seat := range r.Seats {
+		buffer.WriteString(fmt.Sprintf(
+			"%s bets %s, balance %s\n",
+			seat.Name,
+			ac.FormatMoney(seat.Bet),
+			ac.FormatMoney(seat.Balance),
+		))
+		for _, kind := range SideBetKinds {
+			if amount, ok := seat.SideBets[kind]; ok {
+				buffer.WriteString(fmt.Sprintf(
+					"%s side bets %s on %s\n",
+					seat.Name,
+					ac.FormatMoney(amount),
+					kind,
+				))
+			}
+		}
+	}
+	for _, event := range r.Events {
+		buffer.WriteString(event.Text())
+		buffer.WriteString("\n")
+	}
+	return buffer.String()
+}
+
+// Text gets the event written out for people to read.
+func (e HandEvent) Text() string {
+	who := e.Seat
+	if e.Hand > 1 {
+		who = fmt.Sprintf("%s hand %d", e.Seat, e.Hand)
+	}
+	switch e.Type {
+	case EventDeal:
+		if e.FaceDown {
+			return fmt.Sprintf("%s dealt %s face down", who, e.Card)
+		}
+		return fmt.Sprintf("%s dealt %s", who, e.Card)
+	case EventReveal:
+		return fmt.Sprintf("%s reveals %s", who, e.Card)
+	case EventDecision:
+		if e.Amount != 0 {
+			return fmt.Sprintf("%s: %s %s", who, e.Command, ac.FormatMoney(e.Amount))
+		}
+		return fmt.Sprintf("%s: %s", who, e.Command)
+	case EventSettle:
+		return fmt.Sprintf(
+			"%s %s, staked %s, paid %s",
+			who,
+			e.Outcome,
+			ac.FormatMoney(e.Staked),
+			ac.FormatMoney(e.Paid),
+		)
+	case EventInsurance:
+		return fmt.Sprintf(
+			"%s insurance staked %s, paid %s",
+			who,
+			ac.FormatMoney(e.Staked),
+			ac.FormatMoney(e.Paid),
+		)
+	case EventShuffle:
+		return fmt.Sprintf("Shoe shuffled, seed %d", e.Seed)
+	case EventSideBet:
+		outcome := e.Outcome
+		if outcome == "" {
+			outcome = "lose"
+		}
+		return fmt.Sprintf(
+			"%s %s %s, staked %s, paid %s",
+			who,
+			e.SideBet,
+			outcome,
+			ac.FormatMoney(e.Staked),
+			ac.FormatMoney(e.Paid),
+		)
+	}
+	return string(e.Type)
+}
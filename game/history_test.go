@@ -0,0 +1,111 @@
+package game
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/hughgrigg/blackjack/cards"
+	"github.com/stretchr/testify/assert"
+)
+
+//
+// Hand history
+//
+
+// Play a round of 6 and 5 doubled against the dealer's 6 and 7, recording it.
+func recordRound() (*Engine, *bytes.Buffer) {
+	out := &bytes.Buffer{}
+	board := &Board{History: &History{Out: out}}
+	engine := NewEngine(board, DefaultRules())
+	deck := board.Deck
+	deck.Cards[51] = cards.NewCard(cards.Six, cards.Clubs)    // dealer 1
+	deck.Cards[50] = cards.NewCard(cards.Six, cards.Spades)   // player 1
+	deck.Cards[49] = cards.NewCard(cards.Seven, cards.Clubs)  // dealer 2
+	deck.Cards[48] = cards.NewCard(cards.Five, cards.Hearts)  // player 2
+	deck.Cards[47] = cards.NewCard(cards.King, cards.Hearts)  // player 3
+	deck.Cards[46] = cards.NewCard(cards.Ten, cards.Diamonds) // dealer 3
+	engine.Execute(0, Command{Type: CommandBet, Amount: 10})
+	engine.Execute(0, Command{Type: CommandDeal})
+	engine.Execute(0, Command{Type: CommandDouble})
+	return engine, out
+}
+
+// A round should be recorded from the deal to the settlement.
+func TestHistory_Round(t *testing.T) {
+	engine, _ := recordRound()
+	history := engine.Board.History
+	assert.Len(t, history.Rounds, 1)
+
+	round := history.Rounds[0]
+	assert.Equal(t, 1, round.Number)
+	assert.Equal(t, engine.Board.Deck.Seed, round.Seed)
+	assert.Equal(t, 0, round.Position)
+	assert.Equal(t, []SeatEntry{{Name: "Player", Balance: 90, Bet: 10}}, round.Seats)
+	assert.Equal(t, []HandEvent{
+		{Type: EventDeal, Seat: "Dealer", Card: "6♧"},
+		{Type: EventDeal, Seat: "Player", SeatNumber: 1, Hand: 1, Card: "6♤"},
+		{Type: EventDeal, Seat: "Dealer", Card: "7♧", FaceDown: true},
+		{Type: EventDeal, Seat: "Player", SeatNumber: 1, Hand: 1, Card: "5♥"},
+		{Type: EventDecision, Seat: "Player", SeatNumber: 1, Hand: 1, Command: CommandDouble},
+		{Type: EventDeal, Seat: "Player", SeatNumber: 1, Hand: 1, Card: "K♥"},
+		{Type: EventReveal, Seat: "Dealer", Card: "7♧"},
+		{Type: EventDeal, Seat: "Dealer", Card: "X♦"},
+		{Type: EventSettle, Seat: "Player", SeatNumber: 1, Hand: 1, Outcome: "win", Staked: 20, Paid: 40},
+	}, round.Events)
+}
+
+// Decisions that fail shouldn't be recorded.
+func TestHistory_FailedDecision(t *testing.T) {
+	board := &Board{
+		Deck:    &cards.Deck{Random: cards.NewSeededRandomiser(1)},
+		History: &History{},
+	}
+	engine := NewEngine(board, DefaultRules())
+	engine.Execute(0, Command{Type: CommandDeal})
+	recorded := len(board.History.current.Events)
+
+	refused := PlayerAction{
+		func(b *Board) error { return ErrCannotAfford },
+		"Double",
+		CommandDouble,
+	}
+	assert.Equal(t, ErrCannotAfford, board.Take(refused))
+	assert.Len(t, board.History.current.Events, recorded)
+}
+
+// Rounds should be written out as JSON Lines that read back the same.
+func TestHistory_Out(t *testing.T) {
+	engine, out := recordRound()
+	assert.Equal(t, 1, strings.Count(out.String(), "\n"))
+
+	rounds, err := ReadRounds(out)
+	assert.NoError(t, err)
+	assert.Equal(t, engine.Board.History.Rounds, rounds)
+}
+
+// Nothing should be recorded between rounds.
+func TestHistory_BetweenRounds(t *testing.T) {
+	engine, _ := recordRound()
+	engine.Execute(0, Command{Type: CommandNewRound})
+	engine.Execute(0, Command{Type: CommandRaise})
+	assert.Len(t, engine.Board.History.Rounds, 1)
+	assert.Nil(t, engine.Board.History.current)
+}
+
+// Rounds should be readable as text.
+func TestRound_Text(t *testing.T) {
+	engine, _ := recordRound()
+	text := engine.Board.History.Rounds[0].Text()
+	assert.Contains(t, text, "Player bets £10.00, balance £90.00\n")
+	assert.Contains(t, text, "Dealer dealt 7♧ face down\n")
+	assert.Contains(t, text, "Player: double\n")
+	assert.Contains(t, text, "Dealer reveals 7♧\n")
+	assert.Contains(t, text, "Player win, staked £20.00, paid £40.00\n")
+}
+
+// Broken lines should be reported when reading hand histories.
+func TestReadRounds_Invalid(t *testing.T) {
+	_, err := ReadRounds(strings.NewReader("{\"round\": 1}\n\nnot json\n"))
+	assert.EqualError(t, err, "line 3: invalid character 'o' in literal null (expecting 'u')")
+}
@@ -0,0 +1,218 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+)
+
+//
+// Replay
+//
+
+// Replay steps through a recorded round on a board of its own. The board is
+// rebuilt from the shoe's seed and position each time, then the recorded
+// decisions are made on it again up to the current step, so the round can be
+// played forwards and back exactly as it happened.
+type Replay struct {
+	Round *Round
+	// The board as it is at the current step.
+	Board     *Board
+	step      int
+	decisions []HandEvent
+	shuffles  []int64
+}
+
+// Errors from replaying a round.
+var (
+	ErrNoSeed   = errors.New("the round's shoe has no seed, so it can't be dealt again")
+	ErrNoSeats  = errors.New("the round has no seats")
+	ErrDiverged = errors.New("the replay no longer matches the recorded round")
+)
+
+// NewReplay sets up a replay of a recorded round, at the point where the cards
+// have just been dealt.
+func NewReplay(round *Round) (*Replay, error) {
+	if round.Seed == 0 {
+		return nil, ErrNoSeed
+	}
+	if len(round.Seats) == 0 {
+		return nil, ErrNoSeats
+	}
+	if err := round.Rules.Validate(); err != nil {
+		return nil, err
+	}
+	r := &Replay{Round: round}
+	for _, event := range round.Events {
+		switch event.Type {
+		case EventDecision:
+			r.decisions = append(r.decisions, event)
+		case EventShuffle:
+			r.shuffles = append(r.shuffles, event.Seed)
+		}
+	}
+	return r, r.Seek(0)
+}
+
+// Step gets the number of recorded decisions that have been made so far.
+func (r *Replay) Step() int {
+	return r.step
+}
+
+// Steps gets the number of recorded decisions in the round.
+func (r *Replay) Steps() int {
+	return len(r.decisions)
+}
+
+// Next gets the recorded decision that is made by stepping forward, if there
+// is one.
+func (r *Replay) Next() (HandEvent, bool) {
+	if r.step >= len(r.decisions) {
+		return HandEvent{}, false
+	}
+	return r.decisions[r.step], true
+}
+
+// Forward makes the next recorded decision.
+func (r *Replay) Forward() error {
+	if r.step >= len(r.decisions) {
+		return nil
+	}
+	return r.Seek(r.step + 1)
+}
+
+// Back takes the last decision back.
+func (r *Replay) Back() error {
+	if r.step == 0 {
+		return nil
+	}
+	return r.Seek(r.step - 1)
+}
+
+// Seek rebuilds the board as it was after a number of the recorded decisions.
+func (r *Replay) Seek(step int) error {
+	if step < 0 || step > len(r.decisions) {
+		return fmt.Errorf("there is no step %d of %d", step, len(r.decisions))
+	}
+	board, err := r.deal()
+	if err != nil {
+		return err
+	}
+	r.Board, r.step = board, step
+	engine := &Engine{board}
+	for _, decision := range r.decisions[:step] {
+		seat := r.seat(decision)
+		command := Command{Type: decision.Command, Amount: decision.Amount}
+		if err := engine.Execute(seat, command); err != nil {
+			return fmt.Errorf("%s: %s: %s", decision.Seat, decision.Command, err)
+		}
+	}
+	return r.check()
+}
+
+// deal sets up a board with the round's seats, bets and shoe, and deals the
+// cards.
+func (r *Replay) deal() (*Board, error) {
+	round := r.Round
+	board := &Board{History: &History{}}
+	for _, seat := range round.Seats {
+		// Every seat is played from the record, so none has a controller.
+		balance := seat.Balance + seat.Bet
+		for _, amount := range seat.SideBets {
+			balance += amount
+		}
+		board.Players = append(board.Players, NewPlayer(seat.Name, balance, nil))
+	}
+	board.BeginHeadless(round.Rules)
+	for i, seat := range round.Seats {
+		player := board.Players[i]
+		if seat.Bet == 0 {
+			// A seat that couldn't afford to bet was dealt in with nothing on
+			// it, which can't be bet again.
+			player.Balance = new(big.Float).Add(player.Balance, player.Bets[0].amount)
+			player.Bets[0].amount = big.NewFloat(0)
+		} else if err := player.PlaceBet(seat.Bet); err != nil {
+			return nil, fmt.Errorf("%s: %s", seat.Name, err)
+		}
+		for _, kind := range SideBetKinds {
+			amount, ok := seat.SideBets[kind]
+			if !ok {
+				continue
+			}
+			if err := board.PlaceSideBet(player, kind, amount); err != nil {
+				return nil, fmt.Errorf("%s: %s: %s", seat.Name, kind, err)
+			}
+		}
+	}
+
+	// Put the shoe back as it was, and have it shuffle as it did if it ran
+	// out part way through the round.
+	board.Deck.Decks = round.Decks
+	board.Deck.Init()
+	board.Deck.ShuffleSeed(round.Seed)
+	if round.Position > len(board.Deck.Cards) {
+		return nil, fmt.Errorf("the shoe only has %d cards", len(board.Deck.Cards))
+	}
+	board.Deck.Cards = board.Deck.Cards[:len(board.Deck.Cards)-round.Position]
+	board.Deck.Random = &recordedSeeds{seeds: r.shuffles}
+
+	if err := (&Engine{board}).Execute(0, Command{Type: CommandDeal}); err != nil {
+		return nil, err
+	}
+	return board, nil
+}
+
+// seat gets the position in the round of the seat a decision was made for, by
+// its number. Only histories recorded without seat numbers fall back to the
+// seat's name.
+func (r *Replay) seat(decision HandEvent) int {
+	if decision.SeatNumber > 0 {
+		return decision.SeatNumber - 1
+	}
+	for i, seat := range r.Round.Seats {
+		if seat.Name == decision.Seat {
+			return i
+		}
+	}
+	return -1
+}
+
+// check makes sure that everything that has happened on the board so far
+// happened in the recorded round.
+func (r *Replay) check() error {
+	history := r.Board.History
+	replayed := []HandEvent{}
+	if history.current != nil {
+		replayed = history.current.Events
+	} else if len(history.Rounds) > 0 {
+		replayed = history.Rounds[0].Events
+	}
+	if len(replayed) > len(r.Round.Events) ||
+		!reflect.DeepEqual(replayed, r.Round.Events[:len(replayed)]) {
+		return ErrDiverged
+	}
+	return nil
+}
+
+// recordedSeeds is a randomiser that gives a shoe the seeds it was shuffled
+// with in a recorded round, when it reshuffles. Reshuffle shuffles the shoe
+// with ShuffleSeed from each seed just as it was given.
+type recordedSeeds struct {
+	seeds []int64
+}
+
+// Int63 gets the next recorded seed.
+func (rs *recordedSeeds) Int63() int64 {
+	if len(rs.seeds) == 0 {
+		return 0
+	}
+	seed := rs.seeds[0]
+	rs.seeds = rs.seeds[1:]
+	return seed
+}
+
+// Intn isn't needed, as the shoe is only ever shuffled from whole seeds.
+func (rs *recordedSeeds) Intn(n int) int {
+	return 0
+}
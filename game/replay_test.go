@@ -0,0 +1,175 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/hughgrigg/blackjack/cards"
+	"github.com/stretchr/testify/assert"
+)
+
+//
+// Replay
+//
+
+// Play a round from a seeded shoe with two seats, hitting below 12, and get its
+// hand history. The shoe can be cut short to make it run out during the round.
+func playRound(t *testing.T, seed int64, left int) *Round {
+	board := &Board{
+		Deck:    &cards.Deck{Random: cards.NewSeededRandomiser(seed)},
+		Players: []*Player{NewPlayer("Ann", 100, nil), NewPlayer("Bob", 50, nil)},
+		History: &History{},
+	}
+	engine := NewEngine(board, DefaultRules())
+	if left > 0 {
+		board.Deck.Cards = board.Deck.Cards[:left]
+	}
+	engine.Execute(0, Command{Type: CommandBet, Amount: 10})
+	engine.Execute(1, Command{Type: CommandBet, Amount: 20})
+	assert.NoError(t, engine.Execute(0, Command{Type: CommandDeal}))
+	for i := 0; i < 20 && len(board.History.Rounds) == 0; i++ {
+		seat := 0
+		if board.Player == board.Players[1] {
+			seat = 1
+		}
+		command := CommandStand
+		if board.Player.ActiveBet().Hand.Score() < 12 {
+			command = CommandHit
+		}
+		switch board.Stage.(type) {
+		case *Insurance, Insurance:
+			command = CommandDeclineInsurance
+		}
+		assert.NoError(t, engine.Execute(seat, Command{Type: command}))
+	}
+	assert.Len(t, board.History.Rounds, 1)
+	return board.History.Rounds[0]
+}
+
+// Replaying a round to the end should play it out exactly as it was recorded.
+func TestReplay(t *testing.T) {
+	for seed := int64(1); seed <= 20; seed++ {
+		round := playRound(t, seed, 0)
+		replay, err := NewReplay(round)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, replay.Step())
+		for replay.Step() < replay.Steps() {
+			assert.NoError(t, replay.Forward())
+		}
+		assert.Equal(t, []*Round{round}, replay.Board.History.Rounds, "seed %d", seed)
+	}
+}
+
+// Seats with the same name should each be replayed with their own decisions.
+func TestReplay_SameNames(t *testing.T) {
+	for seed := int64(1); seed <= 20; seed++ {
+		round := playRound(t, seed, 0)
+		round.Seats[1].Name = "Ann"
+		for i := range round.Events {
+			if round.Events[i].Seat == "Bob" {
+				round.Events[i].Seat = "Ann"
+			}
+		}
+		replay, err := NewReplay(round)
+		assert.NoError(t, err)
+		assert.NoError(t, replay.Seek(replay.Steps()), "seed %d", seed)
+		assert.Equal(t, []*Round{round}, replay.Board.History.Rounds, "seed %d", seed)
+	}
+}
+
+// A seat that was dealt in without being able to afford a bet should be
+// replayed with nothing on it.
+func TestReplay_Broke(t *testing.T) {
+	board := &Board{
+		Deck:    &cards.Deck{Random: cards.NewSeededRandomiser(5)},
+		Players: []*Player{NewPlayer("Ann", 100, nil), NewPlayer("Bob", 3, nil)},
+		History: &History{},
+	}
+	engine := NewEngine(board, DefaultRules())
+	assert.NoError(t, engine.Execute(0, Command{Type: CommandDeal}))
+	for i := 0; i < 10 && len(board.History.Rounds) == 0; i++ {
+		assert.NoError(t, engine.Execute(board.seatOf(board.Player), Command{Type: CommandStand}))
+	}
+	assert.Len(t, board.History.Rounds, 1)
+	round := board.History.Rounds[0]
+	assert.Equal(t, 0.0, round.Seats[1].Bet)
+
+	replay, err := NewReplay(round)
+	assert.NoError(t, err)
+	assert.NoError(t, replay.Seek(replay.Steps()))
+	assert.Equal(t, []*Round{round}, replay.Board.History.Rounds)
+}
+
+// Insurance taken for less than half the bet should be replayed for the same
+// amount.
+func TestReplay_PartialInsurance(t *testing.T) {
+	board := &Board{
+		Deck:    &cards.Deck{Random: cards.NewSeededRandomiser(1)},
+		History: &History{},
+	}
+	engine := NewEngine(board, DefaultRules())
+	assert.NoError(t, engine.Execute(0, Command{Type: CommandBet, Amount: 10}))
+	assert.NoError(t, engine.Execute(0, Command{Type: CommandDeal}))
+	assert.NoError(t, engine.Execute(0, Command{Type: CommandInsure, Amount: 3}))
+	assert.NoError(t, engine.Execute(0, Command{Type: CommandStand}))
+	assert.Len(t, board.History.Rounds, 1)
+	round := board.History.Rounds[0]
+	insured := HandEvent{}
+	for _, event := range round.Events {
+		if event.Command == CommandInsure {
+			insured = event
+		}
+	}
+	assert.Equal(t, "Player: insure £3.00", insured.Text())
+
+	replay, err := NewReplay(round)
+	assert.NoError(t, err)
+	assert.NoError(t, replay.Seek(replay.Steps()))
+	assert.Equal(t, []*Round{round}, replay.Board.History.Rounds)
+}
+
+// Going back should put the board as it was before the last decision.
+func TestReplay_Back(t *testing.T) {
+	round := playRound(t, 3, 0)
+	replay, _ := NewReplay(round)
+	assert.NoError(t, replay.Seek(replay.Steps()))
+	assert.Equal(t, "conclusion", StageName(replay.Board.Stage))
+
+	assert.NoError(t, replay.Back())
+	assert.Equal(t, replay.Steps()-1, replay.Step())
+	next, ok := replay.Next()
+	assert.True(t, ok)
+	assert.Equal(t, EventDecision, next.Type)
+	assert.Len(t, replay.Board.History.Rounds, 0)
+
+	assert.NoError(t, replay.Forward())
+	assert.Equal(t, []*Round{round}, replay.Board.History.Rounds)
+	_, ok = replay.Next()
+	assert.False(t, ok)
+}
+
+// The shoe should shuffle with the recorded seed if it runs out in the round.
+func TestReplay_Shuffle(t *testing.T) {
+	round := playRound(t, 5, 3)
+	shuffles := 0
+	for _, event := range round.Events {
+		if event.Type == EventShuffle {
+			shuffles++
+		}
+	}
+	assert.Equal(t, 1, shuffles)
+
+	replay, err := NewReplay(round)
+	assert.NoError(t, err)
+	assert.NoError(t, replay.Seek(replay.Steps()))
+	assert.Equal(t, []*Round{round}, replay.Board.History.Rounds)
+}
+
+// Rounds dealt from a stacked shoe can't be replayed.
+func TestReplay_Diverged(t *testing.T) {
+	engine, _ := recordRound()
+	_, err := NewReplay(engine.Board.History.Rounds[0])
+	assert.Equal(t, ErrDiverged, err)
+
+	_, err = NewReplay(&Round{Seats: []SeatEntry{{Name: "Ann"}}})
+	assert.Equal(t, ErrNoSeed, err)
+}
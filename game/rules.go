@@ -0,0 +1,241 @@
+package game
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/hughgrigg/blackjack/cards"
+)
+
+//
+// House rules
+//
+
+// Rules is the set of house rules the board is played under.
+type Rules struct {
+	// The number of decks in the shoe.
+	Decks int
+	// Whether the dealer hits on soft 17 (H17) rather than standing (S17).
+	HitSoft17 bool
+	// What a player blackjack pays, e.g. 3:2.
+	BlackjackPayout Payout
+	// Which hands the player is allowed to double down on.
+	Double DoubleRule
+	// Whether the player can double down on a hand after splitting.
+	DoubleAfterSplit bool
+	// The most times the player can split in one round. Splitting isn't
+	// allowed at all if this is 0.
+	MaxSplits int
+	// Whether split aces can be split again.
+	ResplitAces bool
+	// Whether split aces can be hit, rather than getting one card each.
+	HitSplitAces bool
+	// How the dealer deals and checks their second card.
+	HoleCard HoleCardRule
+	// Whether the player can surrender, and when.
+	Surrender SurrenderRule
+	// The side bets offered, with what each result pays. None are offered if
+	// this is nil.
+	SideBets *SideBetRules `json:",omitempty"`
+}
+
+// DefaultRules gets the rules the game is played under unless told otherwise:
+// a single deck, dealer hits soft 17 and peeks for blackjack, blackjack pays 3:2,
+// the player can double any two cards including after a split, split up to three
+// times, resplit and hit aces, and there's no surrender.
+func DefaultRules() Rules {
+	return Rules{
+		Decks:            1,
+		HitSoft17:        true,
+		BlackjackPayout:  ThreeToTwo,
+		Double:           DoubleAnyTwo,
+		DoubleAfterSplit: true,
+		MaxSplits:        3,
+		ResplitAces:      true,
+		HitSplitAces:     true,
+		HoleCard:         AmericanHoleCard,
+		Surrender:        SurrenderNone,
+	}
+}
+
+// The most decks a shoe can be made up of.
+const MaxDecks = 8
+
+// Validate makes sure the rules can be played under, e.g. that the blackjack
+// payout has a stake to divide by. Boards can only be set up with valid rules.
+func (r Rules) Validate() error {
+	if r.Decks < 1 || r.Decks > MaxDecks {
+		return fmt.Errorf("the shoe needs from 1 to %d decks, not %d", MaxDecks, r.Decks)
+	}
+	if r.BlackjackPayout.Stake <= 0 || r.BlackjackPayout.Win < 0 {
+		return fmt.Errorf("%s isn't a valid blackjack payout", r.BlackjackPayout)
+	}
+	if r.MaxSplits < 0 {
+		return fmt.Errorf("can't split %d times", r.MaxSplits)
+	}
+	if r.Double < DoubleAnyTwo || r.Double > DoubleTenToEleven {
+		return fmt.Errorf("unknown double rule %d", r.Double)
+	}
+	if r.HoleCard < AmericanHoleCard || r.HoleCard > EuropeanNoHoleCard {
+		return fmt.Errorf("unknown hole card rule %d", r.HoleCard)
+	}
+	if r.Surrender < SurrenderNone || r.Surrender > SurrenderEarly {
+		return fmt.Errorf("unknown surrender rule %d", r.Surrender)
+	}
+	if r.SideBets != nil {
+		for kind, paytable := range r.SideBets.Paytables {
+			for result, odds := range paytable {
+				if !(odds >= 0) {
+					return fmt.Errorf("%s can't pay %v to one for %s", kind, odds, result)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Paytable gets what a side bet pays, if the house offers it.
+func (r Rules) Paytable(kind SideBetKind) (Paytable, bool) {
+	if r.SideBets == nil {
+		return nil, false
+	}
+	paytable, ok := r.SideBets.Paytables[kind]
+	return paytable, ok
+}
+
+// CanDouble sees if the rules allow the player to double down on a bet.
+func (r Rules) CanDouble(bet *Bet) bool {
+	if len(bet.Hand.Cards) != 2 || bet.IsFinished() {
+		return false
+	}
+	if bet.split && !r.DoubleAfterSplit {
+		return false
+	}
+	return r.Double.Allows(bet.Hand)
+}
+
+// CanSplit sees if the rules allow the player to split a bet.
+func (r Rules) CanSplit(player *Player, bet *Bet) bool {
+	if !bet.Hand.CanSplit() || bet.IsFinished() {
+		return false
+	}
+	if len(player.Bets)-1 >= r.MaxSplits {
+		return false
+	}
+	if bet.split && bet.Hand.Cards[0].Rank() == cards.Ace && !r.ResplitAces {
+		return false
+	}
+	return true
+}
+
+// CanSurrender sees if the rules allow the player to surrender a bet. This is
+// only allowed on the first two cards, before splitting.
+func (r Rules) CanSurrender(player *Player, bet *Bet) bool {
+	return r.Surrender != SurrenderNone &&
+		len(player.Bets) == 1 &&
+		!bet.split &&
+		len(bet.Hand.Cards) == 2 &&
+		!bet.IsFinished()
+}
+
+// PayoutFactor gets the multiplier for calculating the amount returned on a bet
+// with an outcome, including the bet itself.
+func (r Rules) PayoutFactor(outcome Outcome) *big.Float {
+	switch outcome {
+	case Blackjack:
+		return r.BlackjackPayout.Factor()
+	case Win:
+		return big.NewFloat(2)
+	case Push:
+		return big.NewFloat(1)
+	case Surrendered:
+		return big.NewFloat(0.5)
+	}
+	return big.NewFloat(0)
+}
+
+// Payout is the ratio of winnings to stake for a winning hand, e.g. 3:2.
+type Payout struct {
+	Win   int64
+	Stake int64
+}
+
+// Common blackjack payouts.
+var (
+	ThreeToTwo = Payout{3, 2}
+	SixToFive  = Payout{6, 5}
+	OneToOne   = Payout{1, 1}
+)
+
+// Factor gets the multiplier for calculating the amount returned on a bet at
+// this payout, including the bet itself. E.g. 2.5 for 3:2. A payout with no
+// stake isn't valid, and only returns the bet.
+func (p Payout) Factor() *big.Float {
+	if p.Stake <= 0 {
+		return big.NewFloat(1)
+	}
+	factor := new(big.Float).Quo(
+		big.NewFloat(float64(p.Win)),
+		big.NewFloat(float64(p.Stake)),
+	)
+	return factor.Add(factor, big.NewFloat(1))
+}
+
+// String gets the payout in the usual notation, e.g. "3:2".
+func (p Payout) String() string {
+	return fmt.Sprintf("%d:%d", p.Win, p.Stake)
+}
+
+// DoubleRule restricts which hands the player is allowed to double down on.
+type DoubleRule int
+
+const (
+	// Double down on any first two cards.
+	DoubleAnyTwo DoubleRule = iota
+	// Double down on hard 9, 10 or 11 only.
+	DoubleNineToEleven
+	// Double down on hard 10 or 11 only.
+	DoubleTenToEleven
+)
+
+// Allows sees if the double rule allows doubling down on a hand.
+func (dr DoubleRule) Allows(hand *cards.Hand) bool {
+	var min int
+	switch dr {
+	case DoubleNineToEleven:
+		min = 9
+	case DoubleTenToEleven:
+		min = 10
+	default:
+		return true
+	}
+	score := hand.Score()
+	return !hand.IsSoft() && score >= min && score <= 11
+}
+
+// HoleCardRule is how the dealer deals and checks their second card.
+type HoleCardRule int
+
+const (
+	// The dealer takes a face down hole card, and peeks at it when showing an
+	// ace or a ten. If they have blackjack the round ends straight away, so the
+	// player only loses their original bet.
+	AmericanHoleCard HoleCardRule = iota
+	// The dealer takes no hole card, only drawing their second card once the
+	// player has finished. Anything doubled or split is lost to a dealer
+	// blackjack.
+	EuropeanNoHoleCard
+)
+
+// SurrenderRule is whether the player can give up half their bet rather than
+// playing the hand out, and when.
+type SurrenderRule int
+
+const (
+	// The player can't surrender.
+	SurrenderNone SurrenderRule = iota
+	// The player can surrender after the dealer has peeked for blackjack.
+	SurrenderLate
+	// The player can surrender before the dealer peeks for blackjack.
+	SurrenderEarly
+)
@@ -0,0 +1,122 @@
+package game
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/hughgrigg/blackjack/cards"
+	"github.com/stretchr/testify/assert"
+)
+
+//
+// Rules
+//
+
+// Should be able to tell which hands the player can double down on.
+func TestRules_CanDouble(t *testing.T) {
+	rules := DefaultRules()
+
+	bet := &Bet{amount: big.NewFloat(5), Hand: &cards.Hand{}}
+	bet.Hand.Hit(cards.NewCard(cards.Four, cards.Clubs))
+	assert.False(t, rules.CanDouble(bet), "Can't double on one card.")
+
+	bet.Hand.Hit(cards.NewCard(cards.Five, cards.Clubs))
+	assert.True(t, rules.CanDouble(bet))
+
+	bet.split = true
+	assert.True(t, rules.CanDouble(bet))
+	rules.DoubleAfterSplit = false
+	assert.False(t, rules.CanDouble(bet))
+}
+
+// Should be able to restrict doubling down to certain hard totals.
+func TestDoubleRule_Allows(t *testing.T) {
+	hand := func(ranks ...cards.Rank) *cards.Hand {
+		h := &cards.Hand{}
+		for _, rank := range ranks {
+			h.Hit(cards.NewCard(rank, cards.Spades))
+		}
+		return h
+	}
+
+	assert.True(t, DoubleAnyTwo.Allows(hand(cards.Two, cards.Three)))
+	assert.True(t, DoubleAnyTwo.Allows(hand(cards.Ace, cards.Seven)))
+
+	assert.True(t, DoubleNineToEleven.Allows(hand(cards.Four, cards.Five)))
+	assert.True(t, DoubleNineToEleven.Allows(hand(cards.Six, cards.Five)))
+	assert.False(t, DoubleNineToEleven.Allows(hand(cards.Three, cards.Five)))
+	assert.False(t, DoubleNineToEleven.Allows(hand(cards.Ace, cards.Eight)))
+
+	assert.False(t, DoubleTenToEleven.Allows(hand(cards.Four, cards.Five)))
+	assert.True(t, DoubleTenToEleven.Allows(hand(cards.Four, cards.Six)))
+	assert.False(t, DoubleTenToEleven.Allows(hand(cards.Ten, cards.Two)))
+}
+
+// Should be able to tell when the player can split.
+func TestRules_CanSplit(t *testing.T) {
+	rules := DefaultRules()
+	player := (&Board{}).Begin(0, rules).initPlayer(5, 95)
+	bet := player.Bets[0]
+	bet.Hand.Hit(cards.NewCard(cards.Ace, cards.Clubs))
+	bet.Hand.Hit(cards.NewCard(cards.Ace, cards.Hearts))
+	assert.True(t, rules.CanSplit(player, bet))
+
+	bet.split = true
+	assert.True(t, rules.CanSplit(player, bet))
+	rules.ResplitAces = false
+	assert.False(t, rules.CanSplit(player, bet), "Can't resplit aces.")
+
+	rules = DefaultRules()
+	rules.MaxSplits = 0
+	assert.False(t, rules.CanSplit(player, bet), "Can't split at all.")
+}
+
+// Each outcome should pay out the right multiple of the bet.
+func TestRules_PayoutFactor(t *testing.T) {
+	rules := DefaultRules()
+	assert.Equal(t, "0", rules.PayoutFactor(Lose).String())
+	assert.Equal(t, "1", rules.PayoutFactor(Push).String())
+	assert.Equal(t, "2", rules.PayoutFactor(Win).String())
+	assert.Equal(t, "2.5", rules.PayoutFactor(Blackjack).String())
+	assert.Equal(t, "0.5", rules.PayoutFactor(Surrendered).String())
+
+	rules.BlackjackPayout = OneToOne
+	assert.Equal(t, "2", rules.PayoutFactor(Blackjack).String())
+}
+
+// Payouts should give the multiplier for the returned amount.
+func TestPayout_Factor(t *testing.T) {
+	assert.Equal(t, "2.5", ThreeToTwo.Factor().String())
+	assert.Equal(t, "2.2", SixToFive.Factor().String())
+	assert.Equal(t, "2", OneToOne.Factor().String())
+	assert.Equal(t, "6:5", SixToFive.String())
+	assert.Equal(t, "1", Payout{3, 0}.Factor().String(), "No stake only returns the bet.")
+}
+
+// Only rules that can be played under should be valid.
+func TestRules_Validate(t *testing.T) {
+	assert.NoError(t, DefaultRules().Validate())
+	assert.Error(t, Rules{}.Validate())
+
+	for _, invalid := range []func(r *Rules){
+		func(r *Rules) { r.Decks = 0 },
+		func(r *Rules) { r.Decks = MaxDecks + 1 },
+		func(r *Rules) { r.BlackjackPayout = Payout{0, 0} },
+		func(r *Rules) { r.BlackjackPayout = Payout{3, 0} },
+		func(r *Rules) { r.BlackjackPayout = Payout{-1, 2} },
+		func(r *Rules) { r.MaxSplits = -1 },
+		func(r *Rules) { r.Double = DoubleTenToEleven + 1 },
+		func(r *Rules) { r.HoleCard = -1 },
+		func(r *Rules) { r.Surrender = SurrenderEarly + 1 },
+		func(r *Rules) {
+			r.SideBets = &SideBetRules{Paytables: map[SideBetKind]Paytable{
+				TwentyOnePlusThree: {"flush": -5},
+			}}
+		},
+	} {
+		rules := DefaultRules()
+		invalid(&rules)
+		assert.Error(t, rules.Validate())
+		assert.Panics(t, func() { NewEngine(&Board{}, rules) })
+	}
+}
@@ -0,0 +1,289 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/hughgrigg/blackjack/cards"
+)
+
+//
+// Sessions
+//
+
+// ErrBusy is given when saving a board that is part way through dealing or
+// playing out a turn, which can't be picked up again later.
+var ErrBusy = errors.New("the board is in the middle of play")
+
+// session is everything about a board that is saved to disk, so that the game
+// can carry on where it left off.
+type session struct {
+	Rules  Rules         `json:"rules"`
+	Stage  string        `json:"stage"`
+	Turn   int           `json:"turn"`
+	Shoe   savedDeck     `json:"shoe"`
+	Dealer []savedCard   `json:"dealer"`
+	Seats  []savedPlayer `json:"seats"`
+	Log    []string      `json:"log"`
+}
+
+// savedDeck is the order and make up of the shoe, with the seed it was
+// shuffled with so that hand histories can go on recording it.
+type savedDeck struct {
+	Decks       int         `json:"decks"`
+	Penetration float64     `json:"penetration,omitempty"`
+	Seed        int64       `json:"seed,omitempty"`
+	Cards       []savedCard `json:"cards"`
+}
+
+// savedCard is a card with the way it's facing.
+type savedCard struct {
+	Rank   string `json:"rank"`
+	Suit   string `json:"suit"`
+	FaceUp bool   `json:"up"`
+}
+
+// savedPlayer is a seat with its balance and bets.
+type savedPlayer struct {
+	Name      string         `json:"name"`
+	Bot       bool           `json:"bot,omitempty"`
+	Balance   *big.Float     `json:"balance"`
+	Insurance *big.Float     `json:"insurance,omitempty"`
+	Bets      []savedBet     `json:"bets"`
+	SideBets  []savedSideBet `json:"side_bets,omitempty"`
+}
+
+// savedSideBet is a side bet with the cards it is judged on.
+type savedSideBet struct {
+	Kind    SideBetKind `json:"kind"`
+	Amount  *big.Float  `json:"amount"`
+	Cards   []savedCard `json:"cards,omitempty"`
+	Result  string      `json:"result,omitempty"`
+	Settled bool        `json:"settled,omitempty"`
+}
+
+// savedBet is a bet with its hand and how far it has been played.
+type savedBet struct {
+	Amount      *big.Float  `json:"amount"`
+	Hand        []savedCard `json:"hand"`
+	Stand       bool        `json:"stand,omitempty"`
+	Split       bool        `json:"split,omitempty"`
+	Surrendered bool        `json:"surrendered,omitempty"`
+	Concluded   bool        `json:"concluded,omitempty"`
+}
+
+// The stages a board can be saved in, as they wait for a seat to decide.
+var savedStages = map[string]func() Stage{
+	"betting":         func() Stage { return &Betting{} },
+	"insurance":       func() Stage { return &Insurance{} },
+	"early surrender": func() Stage { return &EarlySurrender{} },
+	"player":          func() Stage { return &PlayerStage{} },
+	"conclusion":      func() Stage { return &Conclusion{} },
+}
+
+// Save writes the state of the board to a session file, including the order of
+// the shoe, so that it can be loaded later to carry on playing. Boards can only
+// be saved when waiting for a seat played from the keyboard, or between
+// rounds, otherwise ErrBusy is given.
+func (b *Board) Save(w io.Writer) error {
+	stage := StageName(b.Stage)
+	if _, ok := savedStages[stage]; !ok {
+		return ErrBusy
+	}
+	switch stage {
+	case "betting", "conclusion":
+	default:
+		if b.Player == nil || b.Player.IsBot() {
+			return ErrBusy
+		}
+	}
+
+	s := session{
+		Rules: b.Rules,
+		Stage: stage,
+		Shoe: savedDeck{
+			Decks:       b.Deck.Decks,
+			Penetration: b.Deck.Penetration,
+			Seed:        b.Deck.Seed,
+			Cards:       saveCards(b.Deck.Cards),
+		},
+		Dealer: saveCards(b.Dealer.hand.Cards),
+		Log:    b.Log.Events(),
+	}
+	for i, player := range b.Players {
+		if player == b.Player {
+			s.Turn = i
+		}
+		seat := savedPlayer{
+			Name:      player.Name,
+			Bot:       player.IsBot(),
+			Balance:   player.Balance,
+			Insurance: player.insurance,
+		}
+		for _, bet := range player.Bets {
+			seat.Bets = append(seat.Bets, savedBet{
+				Amount:      bet.amount,
+				Hand:        saveCards(bet.Hand.Cards),
+				Stand:       bet.stand,
+				Split:       bet.split,
+				Surrendered: bet.surrendered,
+				Concluded:   bet.concluded,
+			})
+		}
+		for _, sideBet := range player.SideBets {
+			seat.SideBets = append(seat.SideBets, savedSideBet{
+				Kind:    sideBet.Kind,
+				Amount:  sideBet.amount,
+				Cards:   saveCards(sideBet.cards),
+				Result:  sideBet.Result,
+				Settled: sideBet.settled,
+			})
+		}
+		s.Seats = append(s.Seats, seat)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(s)
+}
+
+// LoadBoard reads a board from a session file written by Save. Seats that were
+// played by bots are given the controller. The board carries on from where it
+// was saved once it is resumed.
+func LoadBoard(r io.Reader, bot Controller) (*Board, error) {
+	s := session{}
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, err
+	}
+	stage, ok := savedStages[s.Stage]
+	if !ok {
+		return nil, fmt.Errorf("can't carry on from the %s stage", s.Stage)
+	}
+	if len(s.Seats) == 0 || s.Turn < 0 || s.Turn >= len(s.Seats) {
+		return nil, fmt.Errorf("the session has no seat to play")
+	}
+	if err := s.Rules.Validate(); err != nil {
+		return nil, err
+	}
+
+	b := &Board{
+		Stage: stage(),
+		Rules: s.Rules,
+		Log:   &Log{events: s.Log},
+		Deck: &cards.Deck{
+			Decks:       s.Shoe.Decks,
+			Penetration: s.Shoe.Penetration,
+			Seed:        s.Shoe.Seed,
+		},
+		Dealer: &Dealer{hitSoft17: s.Rules.HitSoft17},
+	}
+	var err error
+	if b.Deck.Cards, err = loadCards(s.Shoe.Cards); err != nil {
+		return nil, err
+	}
+	dealer, err := loadCards(s.Dealer)
+	if err != nil {
+		return nil, err
+	}
+	b.Dealer.hand = &cards.Hand{Cards: dealer}
+
+	for _, seat := range s.Seats {
+		if seat.Balance == nil {
+			return nil, fmt.Errorf("%s has no balance", seat.Name)
+		}
+		player := &Player{
+			Name:      seat.Name,
+			Balance:   seat.Balance,
+			insurance: seat.Insurance,
+		}
+		if seat.Bot {
+			player.Controller = bot
+		}
+		for _, saved := range seat.Bets {
+			hand, err := loadCards(saved.Hand)
+			if err != nil {
+				return nil, err
+			}
+			amount := saved.Amount
+			if amount == nil {
+				amount = big.NewFloat(0)
+			}
+			player.Bets = append(player.Bets, &Bet{
+				amount:      amount,
+				Hand:        &cards.Hand{Cards: hand},
+				stand:       saved.Stand,
+				split:       saved.Split,
+				surrendered: saved.Surrendered,
+				concluded:   saved.Concluded,
+			})
+		}
+		for _, saved := range seat.SideBets {
+			held, err := loadCards(saved.Cards)
+			if err != nil {
+				return nil, err
+			}
+			if saved.Amount == nil {
+				return nil, fmt.Errorf("%s's %s side bet has no amount", seat.Name, saved.Kind)
+			}
+			player.SideBets = append(player.SideBets, &SideBet{
+				Kind:    saved.Kind,
+				amount:  saved.Amount,
+				cards:   held,
+				Result:  saved.Result,
+				settled: saved.Settled,
+			})
+		}
+		if len(player.Bets) == 0 {
+			player.Bets = []*Bet{{amount: big.NewFloat(0), Hand: &cards.Hand{}}}
+		}
+		b.Players = append(b.Players, player)
+	}
+	b.Player = b.Players[s.Turn]
+	return b, nil
+}
+
+// saveCards gets cards as they are saved.
+func saveCards(cs []*cards.Card) []savedCard {
+	saved := []savedCard{}
+	for _, card := range cs {
+		saved = append(saved, savedCard{
+			Rank:   string(card.Rank()),
+			Suit:   string(card.Suit()),
+			FaceUp: card.IsFaceUp(),
+		})
+	}
+	return saved
+}
+
+// loadCards gets saved cards back, checking they are real cards.
+func loadCards(saved []savedCard) ([]*cards.Card, error) {
+	loaded := []*cards.Card{}
+	for _, s := range saved {
+		rank, suit := []rune(s.Rank), []rune(s.Suit)
+		if len(rank) != 1 || len(suit) != 1 {
+			return nil, fmt.Errorf("%q of %q is not a card", s.Rank, s.Suit)
+		}
+		if _, ok := cards.RankValues[cards.Rank(rank[0])]; !ok || !isSuit(suit[0]) {
+			return nil, fmt.Errorf("%q of %q is not a card", s.Rank, s.Suit)
+		}
+		card := cards.NewCard(cards.Rank(rank[0]), cards.Suit(suit[0]))
+		if !s.FaceUp {
+			card.FaceDown()
+		}
+		loaded = append(loaded, card)
+	}
+	return loaded, nil
+}
+
+// isSuit sees if a rune is one of the suits.
+func isSuit(r rune) bool {
+	for _, suit := range cards.Suits {
+		if cards.Suit(r) == suit {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package game
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/hughgrigg/blackjack/cards"
+	"github.com/stretchr/testify/assert"
+)
+
+//
+// Sessions
+//
+
+// Make a board part way through a round, waiting for the player to act with a
+// hand of 11 against a 6.
+func midRound() *Engine {
+	engine := NewEngine(&Board{Deck: cards.NewShoe(2)}, DefaultRules())
+	deck := engine.Board.Deck
+	deck.Cards[len(deck.Cards)-1] = cards.NewCard(cards.Six, cards.Clubs)   // dealer 1
+	deck.Cards[len(deck.Cards)-2] = cards.NewCard(cards.Six, cards.Spades)  // player 1
+	deck.Cards[len(deck.Cards)-3] = cards.NewCard(cards.Seven, cards.Clubs) // dealer 2
+	deck.Cards[len(deck.Cards)-4] = cards.NewCard(cards.Five, cards.Hearts) // player 2
+	engine.Execute(0, Command{Type: CommandBet, Amount: 20})
+	engine.Execute(0, Command{Type: CommandDeal})
+	return engine
+}
+
+// A saved board should load exactly as it was, and play on the same way.
+func TestBoard_Save(t *testing.T) {
+	engine := midRound()
+	buffer := bytes.Buffer{}
+	assert.NoError(t, engine.Board.Save(&buffer))
+
+	loaded, err := LoadBoard(&buffer, nil)
+	assert.NoError(t, err)
+	resumed := &Engine{loaded}
+	assert.Equal(t, engine.Snapshot(), resumed.Snapshot())
+	assert.Equal(t, engine.Board.Log.Render(), loaded.Log.Render())
+	assert.Equal(t, "🂠 ?", loaded.Dealer.hand.Cards[1].Notation())
+	assert.Equal(t, engine.Board.Deck.Seed, loaded.Deck.Seed)
+	assert.NotZero(t, loaded.Deck.Seed)
+
+	before, _ := engine.Submit(0, Command{Type: CommandDouble})
+	after, _ := resumed.Submit(0, Command{Type: CommandDouble})
+	assert.Equal(t, before, after)
+	assert.Equal(t, "conclusion", after.Stage)
+}
+
+// Bots should be given back their controller when their board is loaded.
+func TestBoard_Save_Bots(t *testing.T) {
+	board := &Board{Players: []*Player{
+		NewPlayer("Ann", 100, nil),
+		NewPlayer("Bob", 100, MimicDealer{}),
+	}}
+	board.BeginHeadless(DefaultRules())
+	buffer := bytes.Buffer{}
+	assert.NoError(t, board.Save(&buffer))
+
+	loaded, err := LoadBoard(&buffer, MimicDealer{})
+	assert.NoError(t, err)
+	assert.False(t, loaded.Players[0].IsBot())
+	assert.True(t, loaded.Players[1].IsBot())
+	assert.Equal(t, loaded.Players[0], loaded.Player)
+}
+
+// Boards shouldn't be saved while they are in the middle of play.
+func TestBoard_Save_Busy(t *testing.T) {
+	board := &Board{}
+	board.BeginHeadless(DefaultRules())
+	board.Stage = &DealerStage{}
+	assert.Equal(t, ErrBusy, board.Save(&bytes.Buffer{}))
+
+	board.Stage = &PlayerStage{}
+	board.Player.Controller = MimicDealer{}
+	assert.Equal(t, ErrBusy, board.Save(&bytes.Buffer{}))
+}
+
+// Sessions that have been tampered with should not load.
+func TestLoadBoard_Invalid(t *testing.T) {
+	buffer := bytes.Buffer{}
+	midRound().Board.Save(&buffer)
+
+	for _, tamper := range []struct {
+		old string
+		new string
+	}{
+		{`"stage": "player"`, `"stage": "dealer"`},
+		{`"rank": "6"`, `"rank": "Z"`},
+		{`"turn": 0`, `"turn": 3`},
+		{`"Stake": 2`, `"Stake": 0`},
+	} {
+		saved := strings.Replace(buffer.String(), tamper.old, tamper.new, 1)
+		_, err := LoadBoard(strings.NewReader(saved), nil)
+		assert.Error(t, err, tamper.new)
+	}
+}
+
+// Play out a round on a single seat, standing on every hand.
+func standRound(t *testing.T, engine *Engine) {
+	assert.NoError(t, engine.Execute(0, Command{Type: CommandDeal}))
+	for i := 0; i < 10 && StageName(engine.Board.Stage) != "conclusion"; i++ {
+		command := CommandStand
+		if StageName(engine.Board.Stage) == "insurance" {
+			command = CommandDeclineInsurance
+		}
+		assert.NoError(t, engine.Execute(0, Command{Type: command}))
+	}
+}
+
+// Rounds recorded after carrying on from a session should replay from the
+// shoe's seed, as the seed is saved with it.
+func TestBoard_Save_Replay(t *testing.T) {
+	engine := NewEngine(&Board{Deck: &cards.Deck{Random: cards.NewSeededRandomiser(7)}}, DefaultRules())
+	standRound(t, engine)
+	engine.Execute(0, Command{Type: CommandNewRound})
+	buffer := bytes.Buffer{}
+	assert.NoError(t, engine.Board.Save(&buffer))
+
+	loaded, err := LoadBoard(&buffer, nil)
+	assert.NoError(t, err)
+	loaded.History = &History{}
+	standRound(t, &Engine{loaded})
+	assert.Len(t, loaded.History.Rounds, 1)
+
+	round := loaded.History.Rounds[0]
+	assert.Equal(t, engine.Board.Deck.Seed, round.Seed)
+	_, err = NewReplay(round)
+	assert.NoError(t, err)
+}
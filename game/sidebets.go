@@ -0,0 +1,335 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/hughgrigg/blackjack/cards"
+)
+
+//
+// Side bets
+//
+
+// SideBetKind is one of the optional wagers a seat can place alongside its main
+// bet, judged on the first cards dealt.
+type SideBetKind string
+
+// The side bets the house can offer.
+const (
+	// 21+3 pays on a three card poker hand made from the seat's first two
+	// cards and the dealer's up card.
+	TwentyOnePlusThree SideBetKind = "21+3"
+	// Perfect Pairs pays on the seat's first two cards making a pair.
+	PerfectPairs SideBetKind = "perfect pairs"
+	// Lucky Ladies pays on the seat's first two cards making 20.
+	LuckyLadies SideBetKind = "lucky ladies"
+)
+
+// SideBetKinds are the side bets in the order they're shown and keyed, e.g. 1
+// for 21+3.
+var SideBetKinds = []SideBetKind{TwentyOnePlusThree, PerfectPairs, LuckyLadies}
+
+// Paytable is what each result of a side bet pays, as odds to one, e.g. 9 for
+// 9:1. Results not in the paytable lose.
+type Paytable map[string]float64
+
+// SideBetRules are the side bets a house offers, with their paytables. They are
+// kept apart from the rest of the rules so that the rules can be compared.
+type SideBetRules struct {
+	Paytables map[SideBetKind]Paytable
+}
+
+// DefaultSideBets offers a set of side bets with common paytables. Unknown
+// kinds are left out.
+func DefaultSideBets(kinds ...SideBetKind) *SideBetRules {
+	offered := &SideBetRules{Paytables: map[SideBetKind]Paytable{}}
+	for _, kind := range kinds {
+		if paytable, ok := defaultPaytables()[kind]; ok {
+			offered.Paytables[kind] = paytable
+		}
+	}
+	return offered
+}
+
+// defaultPaytables gets common paytables for each side bet.
+func defaultPaytables() map[SideBetKind]Paytable {
+	return map[SideBetKind]Paytable{
+		TwentyOnePlusThree: {
+			"suited trips":    100,
+			"straight flush":  40,
+			"three of a kind": 30,
+			"straight":        10,
+			"flush":           5,
+		},
+		PerfectPairs: {
+			"perfect pair":  25,
+			"coloured pair": 12,
+			"mixed pair":    6,
+		},
+		LuckyLadies: {
+			"queen of hearts pair and dealer blackjack": 1000,
+			"queen of hearts pair":                      200,
+			"matched 20":                                25,
+			"suited 20":                                 10,
+			"any 20":                                    4,
+		},
+	}
+}
+
+// Errors from placing side bets.
+var (
+	ErrNoSideBet = errors.New("the house doesn't offer that side bet")
+)
+
+// SideBet is an optional wager placed during betting.
+type SideBet struct {
+	Kind   SideBetKind
+	amount *big.Float
+	// The cards the side bet is judged on: the seat's first two cards and the
+	// dealer's up card.
+	cards []*cards.Card
+	// What the side bet was settled as, e.g. "flush", or empty if it lost.
+	Result  string
+	settled bool
+}
+
+// Amount gets the amount staked on the side bet.
+func (sb *SideBet) Amount() *big.Float {
+	return new(big.Float).Copy(sb.amount)
+}
+
+// Judge works out what a side bet's cards make, e.g. "flush" for 21+3, or
+// empty if they make nothing.
+func (k SideBetKind) Judge(cs []*cards.Card, dealerBlackjack bool) string {
+	if len(cs) < 2 {
+		return ""
+	}
+	switch k {
+	case TwentyOnePlusThree:
+		if len(cs) < 3 {
+			return ""
+		}
+		return pokerHand(cs[0], cs[1], cs[2])
+	case PerfectPairs:
+		return pair(cs[0], cs[1])
+	case LuckyLadies:
+		return ladies(cs[0], cs[1], dealerBlackjack)
+	}
+	return ""
+}
+
+// pokerHand gets the three card poker hand the cards make.
+func pokerHand(a, b, c *cards.Card) string {
+	ranks := []int{rankOrder(a), rankOrder(b), rankOrder(c)}
+	sort.Ints(ranks)
+	flush := a.Suit() == b.Suit() && b.Suit() == c.Suit()
+	trips := ranks[0] == ranks[2]
+	// Aces count high or low.
+	straight := (ranks[1] == ranks[0]+1 && ranks[2] == ranks[1]+1) ||
+		(ranks[0] == 0 && ranks[1] == 11 && ranks[2] == 12)
+	switch {
+	case trips && flush:
+		return "suited trips"
+	case straight && flush:
+		return "straight flush"
+	case trips:
+		return "three of a kind"
+	case straight:
+		return "straight"
+	case flush:
+		return "flush"
+	}
+	return ""
+}
+
+// pair gets the kind of pair the cards make, by suit and colour.
+func pair(a, b *cards.Card) string {
+	switch {
+	case a.Rank() != b.Rank():
+		return ""
+	case a.Suit() == b.Suit():
+		return "perfect pair"
+	case isRed(a) == isRed(b):
+		return "coloured pair"
+	}
+	return "mixed pair"
+}
+
+// ladies gets the kind of 20 the cards make.
+func ladies(a, b *cards.Card, dealerBlackjack bool) string {
+	hand := &cards.Hand{Cards: []*cards.Card{
+		cards.NewCard(a.Rank(), a.Suit()),
+		cards.NewCard(b.Rank(), b.Suit()),
+	}}
+	queenOfHearts := func(c *cards.Card) bool {
+		return c.Rank() == cards.Queen && c.Suit() == cards.Hearts
+	}
+	switch {
+	case hand.Score() != 20:
+		return ""
+	case queenOfHearts(a) && queenOfHearts(b) && dealerBlackjack:
+		return "queen of hearts pair and dealer blackjack"
+	case queenOfHearts(a) && queenOfHearts(b):
+		return "queen of hearts pair"
+	case a.Rank() == b.Rank() && a.Suit() == b.Suit():
+		return "matched 20"
+	case a.Suit() == b.Suit():
+		return "suited 20"
+	}
+	return "any 20"
+}
+
+// rankOrder gets the position of a card's rank from ace to king.
+func rankOrder(c *cards.Card) int {
+	for i, rank := range cards.Ranks {
+		if c.Rank() == rank {
+			return i
+		}
+	}
+	return -1
+}
+
+// isRed sees if a card is a heart or diamond.
+func isRed(c *cards.Card) bool {
+	return c.Suit() == cards.Hearts || c.Suit() == cards.Diamonds
+}
+
+// SideBet gets the seat's side bet of a kind, if it has one.
+func (p *Player) SideBet(kind SideBetKind) *SideBet {
+	for _, sideBet := range p.SideBets {
+		if sideBet.Kind == kind {
+			return sideBet
+		}
+	}
+	return nil
+}
+
+// placeSideBet sets a side bet to an amount, moving the difference to or from
+// the balance. Zero takes the side bet off.
+func (p *Player) placeSideBet(kind SideBetKind, amount float64) error {
+	if amount < 0 {
+		return ErrInvalidAmount
+	}
+	staked := big.NewFloat(0)
+	existing := p.SideBet(kind)
+	if existing != nil {
+		staked = existing.amount
+	}
+	available := new(big.Float).Add(p.Balance, staked)
+	if available.Cmp(big.NewFloat(amount)) == -1 {
+		return ErrCannotAfford
+	}
+	p.Balance = available.Sub(available, big.NewFloat(amount))
+	// Keep the side bets in the usual order, so they're settled in it.
+	sideBets := []*SideBet{}
+	for _, other := range SideBetKinds {
+		switch {
+		case other == kind && amount > 0:
+			sideBets = append(sideBets, &SideBet{Kind: kind, amount: big.NewFloat(amount)})
+		case other != kind && p.SideBet(other) != nil:
+			sideBets = append(sideBets, p.SideBet(other))
+		}
+	}
+	p.SideBets = sideBets
+	return nil
+}
+
+// PlaceSideBet sets a seat's side bet of a kind to an amount during betting,
+// if the house offers it. Zero takes the side bet off.
+func (b *Board) PlaceSideBet(player *Player, kind SideBetKind, amount float64) error {
+	if _, ok := b.Rules.Paytable(kind); !ok {
+		return ErrNoSideBet
+	}
+	if err := player.placeSideBet(kind, amount); err != nil {
+		return err
+	}
+	b.emit(SideBetPlaced{
+		Seat:    b.seatOf(player),
+		SideBet: kind,
+		Amount:  amount,
+		Balance: floatOf(player.Balance),
+	})
+	return nil
+}
+
+// renewSideBets places each of a seat's side bets from the last round again
+// for the new round, while the seat can afford them.
+func (b *Board) renewSideBets(player *Player) {
+	last := player.SideBets
+	player.SideBets = nil
+	for _, sideBet := range last {
+		amount := floatOf(sideBet.amount)
+		if _, ok := b.Rules.Paytable(sideBet.Kind); !ok || !player.CanAfford(sideBet.amount) {
+			continue
+		}
+		b.PlaceSideBet(player, sideBet.Kind, amount)
+	}
+}
+
+// holdSideBetCards keeps the cards each side bet is judged on once the first
+// cards are dealt, as the seat's hand can change after, e.g. by splitting.
+func (b *Board) holdSideBetCards() {
+	for _, player := range b.Players {
+		for _, sideBet := range player.SideBets {
+			sideBet.cards = append([]*cards.Card{}, player.Bets[0].Hand.Cards...)
+			if up := b.Dealer.UpCard(); up != nil {
+				sideBet.cards = append(sideBet.cards, up)
+			}
+		}
+	}
+}
+
+// settleSideBets judges each of a seat's side bets and pays out those that won
+// according to the paytables.
+func (b *Board) settleSideBets(player *Player) {
+	for _, sideBet := range player.SideBets {
+		if sideBet.settled {
+			continue
+		}
+		sideBet.settled = true
+		result := sideBet.Kind.Judge(sideBet.cards, b.Dealer.hand.HasBlackJack())
+		paid := big.NewFloat(0)
+		paytable, _ := b.Rules.Paytable(sideBet.Kind)
+		odds, ok := paytable[result]
+		if result != "" && ok {
+			sideBet.Result = result
+			paid.Mul(sideBet.amount, big.NewFloat(odds+1))
+			player.Balance.Add(player.Balance, paid)
+			b.Log.Push(fmt.Sprintf(
+				"[%s's %s wins %s with %s](fg-green)",
+				player.Name,
+				sideBet.Kind,
+				ac.FormatMoneyBigFloat(paid),
+				result,
+			))
+		} else {
+			b.Log.Push(fmt.Sprintf(
+				"[%s loses %s on %s](fg-red)",
+				player.Name,
+				ac.FormatMoneyBigFloat(sideBet.amount),
+				sideBet.Kind,
+			))
+		}
+		b.History.record(HandEvent{
+			Type:       EventSideBet,
+			Seat:       player.Name,
+			SeatNumber: b.seatOf(player) + 1,
+			SideBet:    sideBet.Kind,
+			Outcome:    sideBet.Result,
+			Staked:     floatOf(sideBet.amount),
+			Paid:       floatOf(paid),
+		})
+		b.emit(SideBetSettled{
+			Seat:    b.seatOf(player),
+			SideBet: sideBet.Kind,
+			Result:  sideBet.Result,
+			Staked:  floatOf(sideBet.amount),
+			Paid:    floatOf(paid),
+			Balance: floatOf(player.Balance),
+		})
+		b.keepBooks(player, SideWager, sideBet.amount, paid)
+	}
+}
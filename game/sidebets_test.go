@@ -0,0 +1,270 @@
+package game
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hughgrigg/blackjack/cards"
+	"github.com/stretchr/testify/assert"
+)
+
+//
+// Side bets
+//
+
+// Should be able to judge 21+3 on the three card poker hand made.
+func TestSideBetKind_Judge_TwentyOnePlusThree(t *testing.T) {
+	for expected, hand := range map[string][]*cards.Card{
+		"suited trips": {
+			cards.NewCard(cards.Seven, cards.Hearts),
+			cards.NewCard(cards.Seven, cards.Hearts),
+			cards.NewCard(cards.Seven, cards.Hearts),
+		},
+		"straight flush": {
+			cards.NewCard(cards.Queen, cards.Clubs),
+			cards.NewCard(cards.Ace, cards.Clubs),
+			cards.NewCard(cards.King, cards.Clubs),
+		},
+		"three of a kind": {
+			cards.NewCard(cards.Seven, cards.Hearts),
+			cards.NewCard(cards.Seven, cards.Spades),
+			cards.NewCard(cards.Seven, cards.Clubs),
+		},
+		"straight": {
+			cards.NewCard(cards.Two, cards.Hearts),
+			cards.NewCard(cards.Ace, cards.Spades),
+			cards.NewCard(cards.Three, cards.Clubs),
+		},
+		"flush": {
+			cards.NewCard(cards.Two, cards.Diamonds),
+			cards.NewCard(cards.Nine, cards.Diamonds),
+			cards.NewCard(cards.Jack, cards.Diamonds),
+		},
+		"": {
+			cards.NewCard(cards.King, cards.Diamonds),
+			cards.NewCard(cards.Ace, cards.Spades),
+			cards.NewCard(cards.Two, cards.Diamonds),
+		},
+	} {
+		assert.Equal(t, expected, TwentyOnePlusThree.Judge(hand, false), expected)
+	}
+}
+
+// Should be able to judge Perfect Pairs on the suits and colours of a pair.
+func TestSideBetKind_Judge_PerfectPairs(t *testing.T) {
+	for expected, hand := range map[string][]*cards.Card{
+		"perfect pair": {
+			cards.NewCard(cards.Four, cards.Spades),
+			cards.NewCard(cards.Four, cards.Spades),
+		},
+		"coloured pair": {
+			cards.NewCard(cards.Four, cards.Hearts),
+			cards.NewCard(cards.Four, cards.Diamonds),
+		},
+		"mixed pair": {
+			cards.NewCard(cards.Four, cards.Hearts),
+			cards.NewCard(cards.Four, cards.Clubs),
+		},
+		"": {
+			cards.NewCard(cards.King, cards.Hearts),
+			cards.NewCard(cards.Queen, cards.Hearts),
+		},
+	} {
+		assert.Equal(t, expected, PerfectPairs.Judge(hand, false), expected)
+	}
+}
+
+// Should be able to judge Lucky Ladies on the kind of 20 made.
+func TestSideBetKind_Judge_LuckyLadies(t *testing.T) {
+	queens := []*cards.Card{
+		cards.NewCard(cards.Queen, cards.Hearts),
+		cards.NewCard(cards.Queen, cards.Hearts),
+	}
+	assert.Equal(t, "queen of hearts pair and dealer blackjack", LuckyLadies.Judge(queens, true))
+	assert.Equal(t, "queen of hearts pair", LuckyLadies.Judge(queens, false))
+	for expected, hand := range map[string][]*cards.Card{
+		"matched 20": {
+			cards.NewCard(cards.Jack, cards.Clubs),
+			cards.NewCard(cards.Jack, cards.Clubs),
+		},
+		"suited 20": {
+			cards.NewCard(cards.Ace, cards.Clubs),
+			cards.NewCard(cards.Nine, cards.Clubs),
+		},
+		"any 20": {
+			cards.NewCard(cards.Ten, cards.Clubs),
+			cards.NewCard(cards.King, cards.Hearts),
+		},
+		"": {
+			cards.NewCard(cards.Ten, cards.Clubs),
+			cards.NewCard(cards.Nine, cards.Clubs),
+		},
+	} {
+		assert.Equal(t, expected, LuckyLadies.Judge(hand, false), expected)
+	}
+}
+
+// Make a headless board offering every side bet, with a pair of eights dealt
+// against the dealer's six and seven.
+func sideBetEngine(subscribers ...Subscriber) *Engine {
+	rules := DefaultRules()
+	rules.SideBets = DefaultSideBets(SideBetKinds...)
+	board := &Board{History: &History{}, Subscribers: subscribers}
+	engine := NewEngine(board, rules)
+	deck := board.Deck
+	deck.Cards[51] = cards.NewCard(cards.Six, cards.Hearts)     // dealer 1
+	deck.Cards[50] = cards.NewCard(cards.Eight, cards.Hearts)   // player 1
+	deck.Cards[49] = cards.NewCard(cards.Seven, cards.Clubs)    // dealer 2
+	deck.Cards[48] = cards.NewCard(cards.Eight, cards.Diamonds) // player 2
+	deck.Cards[47] = cards.NewCard(cards.Ten, cards.Diamonds)   // dealer 3
+	return engine
+}
+
+// Side bets should be taken from the balance during betting, and settled on
+// the first cards dealt alongside the main bet.
+func TestBoard_SideBets(t *testing.T) {
+	engine := sideBetEngine()
+	board := engine.Board
+	assert.NoError(t, engine.Execute(0, Command{Type: CommandSideBet, SideBet: PerfectPairs, Amount: 10}))
+	assert.NoError(t, engine.Execute(0, Command{Type: CommandSideBet, SideBet: TwentyOnePlusThree, Amount: 5}))
+	assert.Equal(t, "80", board.Player.Balance.String())
+	assert.Equal(t, "100", board.Player.Worth().String())
+
+	engine.Execute(0, Command{Type: CommandDeal})
+	engine.Execute(0, Command{Type: CommandStand})
+
+	// The main bet of 5 wins 10, the coloured pair pays 12:1 and 21+3 loses.
+	assert.Equal(t, "conclusion", StageName(board.Stage))
+	assert.Equal(t, "220", board.Player.Balance.String())
+	assert.Equal(t, "coloured pair", board.Player.SideBet(PerfectPairs).Result)
+	assert.Equal(t, "", board.Player.SideBet(TwentyOnePlusThree).Result)
+	events := board.History.Rounds[0].Events
+	assert.Equal(t, HandEvent{
+		Type:       EventSideBet,
+		Seat:       "Player",
+		SeatNumber: 1,
+		SideBet:    TwentyOnePlusThree,
+		Staked:     5,
+	}, events[len(events)-2])
+	assert.Equal(t, HandEvent{
+		Type:       EventSideBet,
+		Seat:       "Player",
+		SeatNumber: 1,
+		SideBet:    PerfectPairs,
+		Outcome:    "coloured pair",
+		Staked:     10,
+		Paid:       130,
+	}, events[len(events)-1])
+
+	// Side bets carry on into the next round.
+	engine.Execute(0, Command{Type: CommandNewRound})
+	assert.Len(t, board.Player.SideBets, 2)
+	assert.Equal(t, "", board.Player.SideBet(PerfectPairs).Result)
+	assert.Equal(t, "200", board.Player.Balance.String())
+}
+
+// Side bets should only be placed when the house offers them, during betting.
+func TestBoard_PlaceSideBet(t *testing.T) {
+	engine := NewEngine(&Board{}, DefaultRules())
+	assert.Equal(
+		t,
+		ErrNoSideBet,
+		engine.Execute(0, Command{Type: CommandSideBet, SideBet: LuckyLadies, Amount: 5}),
+	)
+
+	engine = sideBetEngine()
+	assert.Equal(
+		t,
+		ErrCannotAfford,
+		engine.Execute(0, Command{Type: CommandSideBet, SideBet: LuckyLadies, Amount: 500}),
+	)
+	assert.Equal(
+		t,
+		ErrInvalidAmount,
+		engine.Execute(0, Command{Type: CommandSideBet, SideBet: LuckyLadies, Amount: -5}),
+	)
+	assert.NoError(t, engine.Execute(0, Command{Type: CommandSideBet, SideBet: LuckyLadies, Amount: 5}))
+	assert.NoError(t, engine.Execute(0, Command{Type: CommandSideBet, SideBet: LuckyLadies}))
+	assert.Empty(t, engine.Board.Player.SideBets)
+	assert.Equal(t, "95", engine.Board.Player.Balance.String())
+
+	engine.Execute(0, Command{Type: CommandDeal})
+	_, err := engine.Submit(0, Command{Type: CommandSideBet, SideBet: LuckyLadies, Amount: 5})
+	assert.IsType(t, &IllegalCommandError{}, err)
+}
+
+// Each side bet offered should have a key to put it on and take it off.
+func TestBetting_Actions_SideBets(t *testing.T) {
+	rules := DefaultRules()
+	rules.SideBets = DefaultSideBets(LuckyLadies)
+	board := &Board{}
+	engine := NewEngine(board, rules)
+
+	actions := board.Stage.Actions(board)
+	assert.Equal(t, "Side bet lucky ladies", actions["3"].Description)
+	assert.NotContains(t, actions, "1")
+	assert.NoError(t, board.Take(actions["3"]))
+	assert.Equal(t, "No lucky ladies", board.Stage.Actions(board)["3"].Description)
+	assert.Equal(t, []CommandType{CommandSideBet, CommandDeal, CommandLower, CommandRaise}, engine.Snapshot().Commands)
+}
+
+// Side bets should be kept in snapshots and folded from events.
+func TestFold_SideBets(t *testing.T) {
+	log := &eventLog{}
+	engine := sideBetEngine(log)
+	engine.Execute(0, Command{Type: CommandSideBet, SideBet: LuckyLadies, Amount: 5})
+	engine.Execute(0, Command{Type: CommandSideBet, SideBet: PerfectPairs, Amount: 5})
+	engine.Execute(0, Command{Type: CommandDeal})
+	engine.Execute(0, Command{Type: CommandStand})
+	assertFolded(t, engine.Board, append([]Event{}, log.events...))
+
+	folded := Fold(Snapshot{}, log.events...)
+	assert.Equal(t, []SideBetSnapshot{
+		{Kind: PerfectPairs, Amount: 5, Settled: true, Result: "coloured pair"},
+		{Kind: LuckyLadies, Amount: 5, Settled: true},
+	}, folded.Seats[0].SideBets)
+}
+
+// Side bets should be saved with the session.
+func TestBoard_SideBets_Saved(t *testing.T) {
+	engine := sideBetEngine()
+	engine.Execute(0, Command{Type: CommandSideBet, SideBet: PerfectPairs, Amount: 10})
+	engine.Execute(0, Command{Type: CommandDeal})
+
+	buffer := bytes.Buffer{}
+	assert.NoError(t, engine.Board.Save(&buffer))
+	loaded, err := LoadBoard(&buffer, nil)
+	assert.NoError(t, err)
+	loaded.BeginHeadless(loaded.Rules)
+	assert.Equal(t, "10", loaded.Player.SideBet(PerfectPairs).Amount().String())
+	assert.Len(t, loaded.Player.SideBet(PerfectPairs).cards, 3)
+}
+
+// Replaying a round should place and settle its side bets as they were.
+func TestReplay_SideBets(t *testing.T) {
+	for seed := int64(1); seed <= 20; seed++ {
+		rules := DefaultRules()
+		rules.SideBets = DefaultSideBets(SideBetKinds...)
+		board := &Board{
+			Deck:    &cards.Deck{Random: cards.NewSeededRandomiser(seed)},
+			History: &History{},
+		}
+		engine := NewEngine(board, rules)
+		for _, kind := range SideBetKinds {
+			engine.Execute(0, Command{Type: CommandSideBet, SideBet: kind, Amount: 5})
+		}
+		engine.Execute(0, Command{Type: CommandDeal})
+		for i := 0; i < 20 && len(board.History.Rounds) == 0; i++ {
+			command := CommandStand
+			if StageName(board.Stage) == "insurance" {
+				command = CommandDeclineInsurance
+			}
+			assert.NoError(t, engine.Execute(0, Command{Type: command}))
+		}
+
+		replay, err := NewReplay(board.History.Rounds[0])
+		assert.NoError(t, err)
+		assert.NoError(t, replay.Seek(replay.Steps()))
+		assert.Equal(t, board.History.Rounds, replay.Board.History.Rounds, "seed %d", seed)
+	}
+}
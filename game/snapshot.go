@@ -0,0 +1,155 @@
+package game
+
+import (
+	"math/big"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/hughgrigg/blackjack/cards"
+)
+
+//
+// Snapshots
+//
+
+// Snapshot is a plain copy of the state of the board, e.g. for returning from
+// the engine or encoding as JSON. Face down cards stay hidden.
+type Snapshot struct {
+	Stage string `json:"stage"`
+	// The index of the seat whose turn it is.
+	Turn   int            `json:"turn"`
+	Dealer HandSnapshot   `json:"dealer"`
+	Seats  []SeatSnapshot `json:"seats"`
+	// The commands available to the seat whose turn it is.
+	Commands []CommandType `json:"commands"`
+	// The number of cards left in the deck or shoe.
+	Remaining int `json:"remaining"`
+}
+
+// HandSnapshot is a plain copy of a hand.
+type HandSnapshot struct {
+	Cards []string `json:"cards"`
+	Score int      `json:"score"`
+}
+
+// SeatSnapshot is a plain copy of a seat at the board.
+type SeatSnapshot struct {
+	Name      string        `json:"name"`
+	Bot       bool          `json:"bot"`
+	Balance   float64       `json:"balance"`
+	Insurance float64       `json:"insurance"`
+	Bets      []BetSnapshot `json:"bets"`
+	// Side bets are only listed if the seat has placed any.
+	SideBets []SideBetSnapshot `json:"side_bets,omitempty"`
+}
+
+// SideBetSnapshot is a plain copy of a side bet.
+type SideBetSnapshot struct {
+	Kind    SideBetKind `json:"kind"`
+	Amount  float64     `json:"amount"`
+	Settled bool        `json:"settled"`
+	// What the side bet won with, e.g. "flush", or empty.
+	Result string `json:"result,omitempty"`
+}
+
+// BetSnapshot is a plain copy of a bet and its hand.
+type BetSnapshot struct {
+	Hand     HandSnapshot `json:"hand"`
+	Amount   float64      `json:"amount"`
+	Finished bool         `json:"finished"`
+}
+
+// Snapshot takes a plain copy of the state of the board.
+func (b *Board) Snapshot() Snapshot {
+	snapshot := Snapshot{
+		Stage:     StageName(b.Stage),
+		Turn:      -1,
+		Seats:     []SeatSnapshot{},
+		Commands:  []CommandType{},
+		Remaining: len(b.Deck.Cards),
+	}
+	if b.Dealer != nil {
+		snapshot.Dealer = snapshotHand(b.Dealer.hand)
+	}
+	for i, player := range b.Players {
+		if player == b.Player {
+			snapshot.Turn = i
+		}
+		seat := SeatSnapshot{
+			Name:      player.Name,
+			Bot:       player.IsBot(),
+			Balance:   floatOf(player.Balance),
+			Insurance: floatOf(player.insurance),
+			Bets:      []BetSnapshot{},
+		}
+		for _, bet := range player.Bets {
+			seat.Bets = append(seat.Bets, BetSnapshot{
+				Hand:     snapshotHand(bet.Hand),
+				Amount:   floatOf(bet.amount),
+				Finished: bet.IsFinished(),
+			})
+		}
+		for _, sideBet := range player.SideBets {
+			seat.SideBets = append(seat.SideBets, SideBetSnapshot{
+				Kind:    sideBet.Kind,
+				Amount:  floatOf(sideBet.amount),
+				Settled: sideBet.settled,
+				Result:  sideBet.Result,
+			})
+		}
+		snapshot.Seats = append(snapshot.Seats, seat)
+	}
+	keys := []string{}
+	actions := b.Stage.Actions(b)
+	for key := range actions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	listed := map[CommandType]bool{}
+	for _, key := range keys {
+		// Several actions can share a command, e.g. each side bet.
+		if command := actions[key].Command; !listed[command] {
+			listed[command] = true
+			snapshot.Commands = append(snapshot.Commands, command)
+		}
+	}
+	return snapshot
+}
+
+// snapshotHand takes a plain copy of a hand.
+func snapshotHand(hand *cards.Hand) HandSnapshot {
+	snapshot := HandSnapshot{Cards: []string{}}
+	if hand == nil {
+		return snapshot
+	}
+	for _, card := range hand.Cards {
+		snapshot.Cards = append(snapshot.Cards, card.Notation())
+	}
+	snapshot.Score = hand.Score()
+	return snapshot
+}
+
+// Hand rebuilds the hand from the notation of its cards, e.g. to render it. Face
+// down cards stay face down, with no rank or suit to give them away.
+func (h HandSnapshot) Hand() *cards.Hand {
+	hand := &cards.Hand{Cards: []*cards.Card{}}
+	for _, notation := range h.Cards {
+		rank, size := utf8.DecodeRuneInString(notation)
+		suit, _ := utf8.DecodeRuneInString(notation[size:])
+		if _, ok := cards.RankValues[cards.Rank(rank)]; !ok {
+			hand.Hit(cards.NewCard(cards.Ace, cards.Spades).FaceDown())
+			continue
+		}
+		hand.Hit(cards.NewCard(cards.Rank(rank), cards.Suit(suit)))
+	}
+	return hand
+}
+
+// floatOf gets an amount of money as a float, treating nil as zero.
+func floatOf(amount *big.Float) float64 {
+	if amount == nil {
+		return 0
+	}
+	f, _ := amount.Float64()
+	return f
+}
@@ -1,9 +1,8 @@
 package game
 
 import (
+	"fmt"
 	"math/big"
-
-	"github.com/hughgrigg/blackjack/cards"
 )
 
 //
@@ -15,45 +14,108 @@ type Stage interface {
 	Actions(board *Board) ActionSet
 }
 
-// Betting is when the player can place their bet and then ask to deal.
+// Betting is when the players can place their bets and then ask to deal.
 type Betting struct {
 }
 
-// Begin resets the hands and bets.
+// Begin resets the hands and bets, and shuffles the deck if the cut card has
+// been reached.
 func (b Betting) Begin(board *Board) {
 	board.Log.Push("Round started")
 	board.resetHands(-1)
-	board.Deck.Init()
-	board.Deck.Shuffle(cards.UniqueShuffle)
-	board.Player.Bets = []*Bet{
-		{amount: big.NewFloat(0), Hand: board.Player.ActiveBet().Hand},
+	if board.Deck.NeedsShuffle() {
+		board.Deck.Reshuffle()
+		board.shuffled()
+		board.Log.Push("Cut card reached, deck shuffled")
+	}
+	for _, player := range board.Players {
+		player.Bets = []*Bet{
+			{amount: big.NewFloat(0), Hand: player.ActiveBet().Hand},
+		}
+		player.Raise(5) // Try to bet if possible.
+		board.betPlaced(player)
+		board.renewSideBets(player)
+	}
+	if !board.firstSeat(humanSeat) {
+		board.firstSeat(anySeat)
 	}
-	board.Player.Raise(5) // Try to bet if possible.
 }
 
-// Actions during betting are dealing, raising and lowering.
+// Actions during betting are dealing, raising and lowering, and moving on to
+// the next seat's bet if more than one seat is played from the keyboard.
 func (b Betting) Actions(board *Board) ActionSet {
-	return map[string]PlayerAction{
+	actions := map[string]PlayerAction{
 		"d": {
-			func(b *Board) bool {
+			func(b *Board) error {
 				b.Deal()
-				return true
+				return nil
 			},
 			"Deal",
+			CommandDeal,
 		},
 		"r": {
-			func(b *Board) bool {
-				return b.Player.Raise(5)
+			func(b *Board) error {
+				if !b.Player.Raise(5) {
+					return ErrCannotAfford
+				}
+				b.betPlaced(b.Player)
+				return nil
 			},
 			"Raise",
+			CommandRaise,
 		},
 		"l": {
-			func(b *Board) bool {
-				return b.Player.Lower(5)
+			func(b *Board) error {
+				if !b.Player.Lower(5) {
+					return ErrMinimumBet
+				}
+				b.betPlaced(b.Player)
+				return nil
 			},
 			"Lower",
+			CommandLower,
 		},
 	}
+	for i, kind := range SideBetKinds {
+		if _, ok := board.Rules.Paytable(kind); !ok {
+			continue
+		}
+		kind := kind
+		description := fmt.Sprintf("Side bet %s", kind)
+		if board.Player != nil && board.Player.SideBet(kind) != nil {
+			description = fmt.Sprintf("No %s", kind)
+		}
+		actions[fmt.Sprintf("%d", i+1)] = PlayerAction{
+			func(b *Board) error {
+				amount := 5.0
+				if b.Player.SideBet(kind) != nil {
+					amount = 0
+				}
+				return b.PlaceSideBet(b.Player, kind, amount)
+			},
+			description,
+			CommandSideBet,
+		}
+	}
+	humans := 0
+	for _, player := range board.Players {
+		if humanSeat(player) {
+			humans++
+		}
+	}
+	if humans > 1 {
+		actions["c"] = PlayerAction{
+			func(b *Board) error {
+				if !b.nextSeat(humanSeat) {
+					b.firstSeat(humanSeat)
+				}
+				return nil
+			},
+			"Change seat",
+			CommandChangeSeat,
+		}
+	}
+	return actions
 }
 
 // Observing is when the player can watch events unfold until the next stage,
@@ -70,26 +132,143 @@ func (o Observing) Actions(board *Board) ActionSet {
 	return map[string]PlayerAction{}
 }
 
-// PlayerStage is when the player can hit or stand.
+// Insurance is when the dealer shows an ace, and each seat in turn can take an
+// insurance side bet against the dealer having blackjack. A player who has
+// blackjack can take even money instead.
+type Insurance struct {
+}
+
+// Begin lets a bot decide on insurance.
+func (i Insurance) Begin(board *Board) {
+	board.playBot(i, "n")
+}
+
+// Actions during insurance are taking full or partial insurance (or even money)
+// or declining.
+func (i Insurance) Actions(board *Board) ActionSet {
+	actions := map[string]PlayerAction{
+		"n": {
+			func(b *Board) error {
+				b.Log.Push(fmt.Sprintf("%s declines insurance", b.Player.Name))
+				b.NextInsurance()
+				return nil
+			},
+			"No insurance",
+			CommandDeclineInsurance,
+		},
+	}
+	bet := board.Player.Bets[0]
+	if bet.Hand.HasBlackJack() {
+		actions["e"] = PlayerAction{
+			func(b *Board) error {
+				if !b.Player.TakeEvenMoney(b) {
+					return &IllegalCommandError{CommandEvenMoney, StageName(b.Stage)}
+				}
+				b.NextInsurance()
+				return nil
+			},
+			"Even money",
+			CommandEvenMoney,
+		}
+		return actions
+	}
+	// Insurance can be for up to half the bet, or half as much again.
+	half := new(big.Float).Quo(bet.amount, big.NewFloat(2))
+	if half.Sign() == 1 && board.Player.CanAfford(half) {
+		actions["i"] = insure(half, "Insurance")
+	}
+	quarter := new(big.Float).Quo(half, big.NewFloat(2))
+	if quarter.Sign() == 1 && board.Player.CanAfford(quarter) {
+		actions["j"] = insure(quarter, "Partial insurance")
+	}
+	return actions
+}
+
+// insure is the action of the seat whose turn it is taking insurance for an
+// amount.
+func insure(amount *big.Float, description string) PlayerAction {
+	return PlayerAction{
+		func(b *Board) error {
+			if err := b.Player.Insure(amount); err != nil {
+				return err
+			}
+			// Insurance can be for less than half the bet, so replays need to
+			// know how much was taken.
+			b.History.stake(floatOf(amount))
+			b.emit(InsuranceTaken{
+				Seat:    b.seatOf(b.Player),
+				Amount:  floatOf(amount),
+				Balance: floatOf(b.Player.Balance),
+			})
+			b.Log.Push(fmt.Sprintf(
+				"%s takes %s insurance",
+				b.Player.Name,
+				ac.FormatMoneyBigFloat(amount),
+			))
+			b.NextInsurance()
+			return nil
+		},
+		description,
+		CommandInsure,
+	}
+}
+
+// EarlySurrender is when each seat in turn can surrender before the dealer
+// peeks for blackjack.
+type EarlySurrender struct {
+}
+
+// Begin lets a bot decide on early surrender.
+func (es EarlySurrender) Begin(board *Board) {
+	board.playBot(es, "c")
+}
+
+// Actions during early surrender are surrendering or playing on.
+func (es EarlySurrender) Actions(board *Board) ActionSet {
+	return map[string]PlayerAction{
+		"u": {
+			func(b *Board) error {
+				b.Surrender()
+				b.NextEarlySurrender()
+				return nil
+			},
+			"Surrender",
+			CommandSurrender,
+		},
+		"c": {
+			func(b *Board) error {
+				b.NextEarlySurrender()
+				return nil
+			},
+			"Play on",
+			CommandPlayOn,
+		},
+	}
+}
+
+// PlayerStage is when the seat whose turn it is can hit or stand.
 type PlayerStage struct {
 }
 
-// Begin does nothing during the player stage.
+// Begin lets a bot play its turn during the player stage.
 func (ps PlayerStage) Begin(board *Board) {
+	board.playBot(ps, "s")
 }
 
-// Actions are hit or stand during the player stage.
+// Actions are hit or stand during the player stage, and doubling down,
+// splitting or surrendering when the house rules allow it.
 func (ps PlayerStage) Actions(board *Board) ActionSet {
 	actions := map[string]PlayerAction{
 		"h": {
-			func(b *Board) bool {
+			func(b *Board) error {
 				b.HitPlayer()
-				return true
+				return nil
 			},
 			"Hit",
+			CommandHit,
 		},
 		"s": {
-			func(b *Board) bool {
+			func(b *Board) error {
 				b.Stage = &Observing{}
 				b.Player.ActiveBet().stand = true
 				if !b.Player.ActiveBet().IsFinished() {
@@ -97,25 +276,44 @@ func (ps PlayerStage) Actions(board *Board) ActionSet {
 				} else {
 					b.AssessPlayerStage()
 				}
-				return true
+				return nil
 			},
 			"Stand",
+			CommandStand,
 		},
-		"d": {
-			func(b *Board) bool {
+	}
+	bet := board.Player.ActiveBet()
+	canAfford := board.Player.CanAfford(bet.amount)
+	if canAfford && board.Rules.CanDouble(bet) {
+		actions["d"] = PlayerAction{
+			func(b *Board) error {
 				b.DoubleDown()
-				return true
+				return nil
 			},
 			"Double Down",
-		},
+			CommandDouble,
+		}
+	}
+	if board.Rules.CanSurrender(board.Player, bet) {
+		actions["u"] = PlayerAction{
+			func(b *Board) error {
+				b.Surrender()
+				b.AssessPlayerStage()
+				return nil
+			},
+			"Surrender",
+			CommandSurrender,
+		}
 	}
-	if board.Player.ActiveBet().Hand.CanSplit() {
+	if canAfford && board.Rules.CanSplit(board.Player, bet) {
 		actions["p"] = PlayerAction{
-			func(b *Board) bool {
+			func(b *Board) error {
 				b.Player.ActiveBet().Split(b)
-				return true
+				b.AssessPlayerStage()
+				return nil
 			},
 			"Split",
+			CommandSplit,
 		}
 	}
 	return actions
@@ -136,14 +334,26 @@ type Assessment struct {
 	Observing
 }
 
-// Begin triggers the end game reckoning to take place during assessment.
+// Begin triggers the end game reckoning to take place during assessment,
+// settling side bets alongside each seat's bets.
 func (a Assessment) Begin(board *Board) {
-	for _, bet := range board.Player.Bets {
-		board.action(func(b *Board) bool {
-			bet.Conclude(b)
-			return true
+	for _, player := range board.Players {
+		for _, bet := range player.Bets {
+			player, bet := player, bet
+			board.action(func(b *Board) error {
+				bet.Conclude(b, player)
+				return nil
+			}).Wait()
+		}
+		player := player
+		board.action(func(b *Board) error {
+			b.settleSideBets(player)
+			return nil
 		}).Wait()
 	}
+	board.History.finish()
+	// Hand the keyboard back for the next round.
+	board.firstSeat(humanSeat)
 	board.ChangeStage(&Conclusion{})
 }
 
@@ -158,11 +368,34 @@ func (c Conclusion) Begin(board *Board) {
 func (c Conclusion) Actions(board *Board) ActionSet {
 	return map[string]PlayerAction{
 		"n": {
-			func(b *Board) bool {
+			func(b *Board) error {
 				b.ChangeStage(&Betting{})
-				return true
+				return nil
 			},
 			"New round",
+			CommandNewRound,
 		},
 	}
 }
+
+// StageName gets a short name for a stage, e.g. for reporting the state of the
+// game outside of the display.
+func StageName(stage Stage) string {
+	switch stage.(type) {
+	case Betting, *Betting:
+		return "betting"
+	case Insurance, *Insurance:
+		return "insurance"
+	case EarlySurrender, *EarlySurrender:
+		return "early surrender"
+	case PlayerStage, *PlayerStage:
+		return "player"
+	case DealerStage, *DealerStage:
+		return "dealer"
+	case Assessment, *Assessment:
+		return "assessment"
+	case Conclusion, *Conclusion:
+		return "conclusion"
+	}
+	return "observing"
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gizak/termui"
+	"github.com/hughgrigg/blackjack/game"
+)
+
+// openHistory records hand histories to the end of a JSON Lines file.
+func openHistory(path string) (*game.History, *os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, nil, err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &game.History{Out: file}, file, nil
+}
+
+// exportHistory prints the rounds recorded in a hand history file as text or
+// JSON Lines.
+func exportHistory(args []string) {
+	flags := flag.NewFlagSet("history", flag.ExitOnError)
+	format := flags.String("format", "text", "format to print rounds in: text or jsonl")
+	last := flags.Int("last", 0, "only print this many of the latest rounds")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: blackjack history [-format text|jsonl] [-last N] FILE")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 || (*format != "text" && *format != "jsonl") {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	rounds := readHistory(flags.Arg(0))
+	if *last > 0 && *last < len(rounds) {
+		rounds = rounds[len(rounds)-*last:]
+	}
+
+	if *format == "jsonl" {
+		if err := game.WriteRounds(os.Stdout, rounds); err != nil {
+			fail(err)
+		}
+		return
+	}
+	for i, round := range rounds {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Print(round.Text())
+	}
+}
+
+// replayHistory steps through a round from a hand history file in the display.
+func replayHistory(args []string) {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	number := flags.Int("round", 0, "round to replay, counting from 1 in the file, or 0 for the last")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: blackjack replay [-round N] FILE")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	rounds := readHistory(flags.Arg(0))
+	if len(rounds) == 0 {
+		fail(fmt.Errorf("there are no rounds in %s", flags.Arg(0)))
+	}
+	if *number < 0 || *number > len(rounds) {
+		fail(fmt.Errorf("there are only %d rounds in %s", len(rounds), flags.Arg(0)))
+	}
+	round := rounds[len(rounds)-1]
+	if *number > 0 {
+		round = rounds[*number-1]
+	}
+	replay, err := game.NewReplay(round)
+	if err != nil {
+		fail(fmt.Errorf("can't replay the round: %s", err))
+	}
+
+	err = termui.Init()
+	if err != nil {
+		panic(err)
+	}
+	display := newDisplay(nil)
+	display.AttachReplay(replay)
+	termui.Loop()
+	termui.Close()
+}
+
+// readHistory reads all of the rounds in a hand history file.
+func readHistory(path string) []*game.Round {
+	file, err := os.Open(path)
+	if err != nil {
+		fail(err)
+	}
+	defer file.Close()
+	rounds, err := game.ReadRounds(file)
+	if err != nil {
+		fail(fmt.Errorf("can't read %s: %s", path, err))
+	}
+	return rounds
+}
@@ -1,36 +1,212 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
 	"time"
 
 	"github.com/gizak/termui"
+	"github.com/hughgrigg/blackjack/counting"
 	"github.com/hughgrigg/blackjack/game"
+	"github.com/hughgrigg/blackjack/profile"
 	"github.com/hughgrigg/blackjack/ui"
 )
 
+var (
+	players     = flag.Int("players", 1, "number of seats played from the keyboard")
+	bots        = flag.Int("bots", 0, "number of seats played by bots")
+	botPlay     = flag.String("bot-play", "dealer", "how bots play: dealer, basic or exec:COMMAND")
+	trainer     = flag.String("trainer", "", "drill the count with a system: hilo, ko, omegaii, zen or wonghalves")
+	rules       = ruleFlags(flag.CommandLine)
+	sessionFile = flag.String("session", defaultSessionFile(), "file to save the game to and carry on from")
+	fresh       = flag.Bool("new", false, "start a new game instead of carrying on the saved one")
+	profileName = flag.String("profile", "", "play as a named profile, keeping its bankroll, statistics and rules")
+	historyFile = flag.String("history", "", "file to record hand histories to as JSON Lines")
+	botLog      = flag.String("bot-log", "", "file to write what exec: bots print to standard error")
+)
+
 func main() {
-	err := termui.Init()
+	if len(os.Args) > 1 && os.Args[1] == "simulate" {
+		simulate(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "profile" {
+		manageProfiles(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "history" {
+		exportHistory(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		replayHistory(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		serveTable(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "join" {
+		joinTable(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "api" {
+		serveAPI(os.Args[2:])
+		return
+	}
+
+	flag.Parse()
+	if *players < 1 || *bots < 0 {
+		fail(fmt.Errorf("need at least one player and no fewer than zero bots"))
+	}
+	// Bots played by other programs can't print over the display.
+	var botStderr io.Writer
+	if *botLog != "" {
+		file, err := os.OpenFile(*botLog, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			fail(err)
+		}
+		defer file.Close()
+		botStderr = file
+	}
+	bot, err := controller(*botPlay, botStderr)
 	if err != nil {
-		panic(err)
+		fail(err)
 	}
-	defer termui.Close()
 
-	board := newBoard()
+	// Play as a profile, with the rules it prefers save for any given.
+	store := profile.DefaultStore()
+	var player *profile.Profile
+	houseRules := game.DefaultRules()
+	if *profileName != "" {
+		if player, err = store.Open(*profileName); err != nil {
+			fail(err)
+		}
+		houseRules = player.Rules
+	}
+	if houseRules, err = rules(houseRules); err != nil {
+		fail(err)
+	}
+	if player != nil {
+		if rulesGiven(flag.CommandLine) {
+			player.Rules = houseRules
+		}
+		if !flagGiven("session") {
+			if *sessionFile, err = store.Path(player.Name, ".session.json"); err != nil {
+				fail(err)
+			}
+		}
+	}
 
-	display := newDisplay()
+	var system counting.System
+	if *trainer != "" {
+		var ok bool
+		if system, ok = counting.Find(*trainer); !ok {
+			fail(fmt.Errorf("unknown counting system %q", *trainer))
+		}
+		// Count with a casino shoe unless told otherwise.
+		if !flagGiven("decks") {
+			houseRules.Decks = 6
+		}
+	}
+
+	var history *game.History
+	if *historyFile != "" {
+		var file *os.File
+		if history, file, err = openHistory(*historyFile); err != nil {
+			fail(err)
+		}
+		defer file.Close()
+	}
+
+	// Carry on with the saved game, if there is one.
+	var board *game.Board
+	if !*fresh {
+		board, err = loadSession(*sessionFile, bot)
+		if err != nil {
+			fail(fmt.Errorf("can't carry on from %s, use -new to start again: %s", *sessionFile, err))
+		}
+	}
+	var counter *counting.Counter
+	if board != nil {
+		if *trainer != "" {
+			counter = counting.NewCounter(system, board.Deck.DeckCount())
+			board.Watchers = append(board.Watchers, counter)
+			catchUp(counter, board)
+		}
+		if player != nil {
+			takeSeat(player, board)
+		}
+		board.History = history
+		board.Resume(500)
+	} else {
+		if *trainer != "" {
+			counter = counting.NewCounter(system, houseRules.Decks)
+		}
+		board = newBoard(houseRules, bot, counter, player, history)
+	}
+
+	err = termui.Init()
+	if err != nil {
+		panic(err)
+	}
+
+	display := newDisplay(counter)
 	display.AttachBoard(board)
 
 	termui.Loop()
+	termui.Close()
+	closeBot(bot)
+
+	if err := saveSession(board, *sessionFile); err != nil {
+		fail(fmt.Errorf("couldn't save the game: %s", err))
+	}
+	if player != nil {
+		if err := saveProfile(store, player); err != nil {
+			fail(fmt.Errorf("couldn't save the profile: %s", err))
+		}
+	}
+	if history != nil && history.Err != nil {
+		fail(fmt.Errorf("couldn't record the hand history: %s", history.Err))
+	}
 }
 
-func newBoard() *game.Board {
-	board := &game.Board{}
-	board.Begin(500)
+func newBoard(
+	rules game.Rules,
+	bot game.Controller,
+	counter *counting.Counter,
+	player *profile.Profile,
+	history *game.History,
+) *game.Board {
+	board := &game.Board{History: history}
+	if counter != nil {
+		board.Watchers = append(board.Watchers, counter)
+	}
+	if player != nil || *players != 1 || *bots != 0 {
+		for i := 1; i <= *players; i++ {
+			board.Players = append(board.Players, game.NewPlayer(
+				fmt.Sprintf("Player %d", i), 100, nil,
+			))
+		}
+		for i := 1; i <= *bots; i++ {
+			board.Players = append(board.Players, game.NewPlayer(
+				fmt.Sprintf("Bot %d", i), 100, bot,
+			))
+		}
+	}
+	// The profile takes the first seat at the keyboard.
+	if player != nil {
+		board.Players[0].Name = player.Name
+		takeSeat(player, board)
+	}
+	board.Begin(500, rules)
 	return board
 }
 
-func newDisplay() *ui.Display {
-	display := &ui.Display{}
+func newDisplay(counter *counting.Counter) *ui.Display {
+	display := &ui.Display{Counter: counter}
 	display.Init()
 	display.Render()
 	go func() {
@@ -40,3 +216,14 @@ func newDisplay() *ui.Display {
 	}()
 	return display
 }
+
+// flagGiven sees if a flag was set on the command line.
+func flagGiven(name string) bool {
+	given := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			given = true
+		}
+	})
+	return given
+}
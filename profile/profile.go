@@ -0,0 +1,263 @@
+package profile
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hughgrigg/blackjack/game"
+)
+
+//
+// Profiles
+//
+
+// Profile is a named player that keeps their bankroll, lifetime statistics and
+// preferred house rules from one game to the next.
+type Profile struct {
+	Name     string     `json:"name"`
+	Bankroll *big.Float `json:"bankroll"`
+	Stats    Stats      `json:"stats"`
+	Rules    game.Rules `json:"rules"`
+	// The seat the profile is sitting in, if any.
+	seat *game.Player
+}
+
+// The bankroll a new or reset profile starts with.
+const StartingBankroll = 100
+
+// New makes a profile with the starting bankroll, no history and the default
+// house rules.
+func New(name string) *Profile {
+	return &Profile{
+		Name:     name,
+		Bankroll: big.NewFloat(StartingBankroll),
+		Stats:    newStats(),
+		Rules:    game.DefaultRules(),
+	}
+}
+
+// Reset takes the profile back to the starting bankroll and clears its
+// statistics, keeping its preferred rules.
+func (p *Profile) Reset() {
+	p.Bankroll = big.NewFloat(StartingBankroll)
+	p.Stats = newStats()
+}
+
+// TopUp adds money to the profile's bankroll.
+func (p *Profile) TopUp(amount float64) error {
+	if amount <= 0 {
+		return ErrBadAmount
+	}
+	p.Bankroll.Add(p.Bankroll, big.NewFloat(amount))
+	return nil
+}
+
+// Sit puts the profile in a seat at a board, so that it keeps the books for
+// that seat alone, even if others share its name.
+func (p *Profile) Sit(player *game.Player) {
+	p.seat = player
+}
+
+// Seat gets the seat the profile is sitting in, if it has one.
+func (p *Profile) Seat() *game.Player {
+	return p.seat
+}
+
+// Settle records how one of the profile's bets was settled, which lets the
+// profile keep the books for its seat at a board.
+func (p *Profile) Settle(player *game.Player, outcome game.Outcome, staked *big.Float, paid *big.Float) {
+	if player == nil || player != p.seat {
+		return
+	}
+	p.Stats.record(outcome, staked, paid)
+}
+
+//
+// Statistics
+//
+
+// Stats are a profile's results over every game played.
+type Stats struct {
+	Hands      int `json:"hands"`
+	Wins       int `json:"wins"`
+	Losses     int `json:"losses"`
+	Pushes     int `json:"pushes"`
+	Blackjacks int `json:"blackjacks"`
+	Surrenders int `json:"surrenders"`
+	// Insurance and side bets settled, which aren't counted as hands.
+	Insurance int        `json:"insurance"`
+	SideBets  int        `json:"side_bets"`
+	Wagered   *big.Float `json:"wagered"`
+	// The total won, or lost if negative.
+	Net *big.Float `json:"net"`
+}
+
+// newStats makes a clean set of statistics.
+func newStats() Stats {
+	return Stats{Wagered: big.NewFloat(0), Net: big.NewFloat(0)}
+}
+
+// record adds a settled bet to the statistics. Insurance and side bets count
+// towards the money wagered and won, but not towards the hands played.
+func (s *Stats) record(outcome game.Outcome, staked *big.Float, paid *big.Float) {
+	s.Wagered.Add(s.Wagered, staked)
+	s.Net.Add(s.Net, paid)
+	s.Net.Sub(s.Net, staked)
+	switch outcome {
+	case game.Insured:
+		s.Insurance++
+		return
+	case game.SideWager:
+		s.SideBets++
+		return
+	}
+	s.Hands++
+	switch outcome {
+	case game.Win:
+		s.Wins++
+	case game.Blackjack:
+		s.Wins++
+		s.Blackjacks++
+	case game.Push:
+		s.Pushes++
+	case game.Surrendered:
+		s.Surrenders++
+		s.Losses++
+	default:
+		s.Losses++
+	}
+}
+
+// WinRate gets the fraction of hands won.
+func (s Stats) WinRate() float64 {
+	if s.Hands == 0 {
+		return 0
+	}
+	return float64(s.Wins) / float64(s.Hands)
+}
+
+//
+// Store
+//
+
+// Errors from managing profiles.
+var (
+	ErrBadName   = errors.New("profile names can only use letters, numbers, spaces, dashes and underscores")
+	ErrBadAmount = errors.New("top ups must be more than nothing")
+	ErrNotFound  = errors.New("there is no such profile")
+)
+
+// Store keeps profiles as files in a directory.
+type Store struct {
+	Dir string
+}
+
+// DefaultStore gets the store in the user's config directory.
+func DefaultStore() Store {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return Store{Dir: ".blackjack-profiles"}
+	}
+	return Store{Dir: filepath.Join(dir, "blackjack", "profiles")}
+}
+
+// Open gets a profile from the store, making a new one if there isn't a
+// profile with the name yet.
+func (s Store) Open(name string) (*Profile, error) {
+	p, err := s.Load(name)
+	if err == ErrNotFound {
+		return New(name), nil
+	}
+	return p, err
+}
+
+// Load gets an existing profile from the store.
+func (s Store) Load(name string) (*Profile, error) {
+	path, err := s.Path(name, ".json")
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	p := New(name)
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, err
+	}
+	if p.Bankroll == nil {
+		p.Bankroll = big.NewFloat(0)
+	}
+	if p.Stats.Wagered == nil || p.Stats.Net == nil {
+		p.Stats = newStats()
+	}
+	return p, nil
+}
+
+// Save writes a profile to the store.
+func (s Store) Save(p *Profile) error {
+	path, err := s.Path(p.Name, ".json")
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// List gets the names of the profiles in the store, in order.
+func (s Store) List() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(s.Dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, file := range files {
+		if strings.HasSuffix(file, ".session.json") {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		p := struct{ Name string }{}
+		if json.Unmarshal(data, &p) == nil && p.Name != "" {
+			names = append(names, p.Name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Path gets the path of a file belonging to a profile, e.g. ".json" for the
+// profile itself.
+func (s Store) Path(name string, suffix string) (string, error) {
+	if strings.TrimSpace(name) == "" {
+		return "", ErrBadName
+	}
+	slug := []rune{}
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_':
+			slug = append(slug, r)
+		case r == ' ':
+			slug = append(slug, '-')
+		default:
+			return "", ErrBadName
+		}
+	}
+	return filepath.Join(s.Dir, string(slug)+suffix), nil
+}
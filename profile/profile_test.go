@@ -0,0 +1,145 @@
+package profile
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hughgrigg/blackjack/game"
+	"github.com/stretchr/testify/assert"
+)
+
+// Make a store in a temporary directory.
+func tempStore(t *testing.T) Store {
+	return Store{Dir: filepath.Join(t.TempDir(), "profiles")}
+}
+
+//
+// Profiles
+//
+
+// New profiles should start with the starting bankroll and default rules.
+func TestNew(t *testing.T) {
+	p := New("Ann")
+	assert.Equal(t, "100", p.Bankroll.String())
+	assert.Equal(t, game.DefaultRules(), p.Rules)
+	assert.Equal(t, 0, p.Stats.Hands)
+}
+
+// Resetting should restore the bankroll and clear the statistics, but keep the
+// preferred rules.
+func TestProfile_Reset(t *testing.T) {
+	p := New("Ann")
+	p.Bankroll = big.NewFloat(3)
+	p.Stats.Hands = 10
+	p.Rules.Decks = 6
+
+	p.Reset()
+	assert.Equal(t, "100", p.Bankroll.String())
+	assert.Equal(t, 0, p.Stats.Hands)
+	assert.Equal(t, 6, p.Rules.Decks)
+}
+
+// Topping up should add to the bankroll, but only by a positive amount.
+func TestProfile_TopUp(t *testing.T) {
+	p := New("Ann")
+	assert.NoError(t, p.TopUp(50))
+	assert.Equal(t, "150", p.Bankroll.String())
+	assert.Equal(t, ErrBadAmount, p.TopUp(-5))
+}
+
+// A profile should keep the books for its own seat only, even when another
+// seat has the same name.
+func TestProfile_Settle(t *testing.T) {
+	p := New("Ann")
+	ann := game.NewPlayer("Ann", 100, nil)
+	bob := game.NewPlayer("Bob", 100, nil)
+	other := game.NewPlayer("Ann", 100, nil)
+	p.Settle(ann, game.Win, big.NewFloat(10), big.NewFloat(20))
+	assert.Equal(t, 0, p.Stats.Hands, "Not sitting yet.")
+	p.Sit(ann)
+	assert.Equal(t, ann, p.Seat())
+	p.Settle(other, game.Win, big.NewFloat(10), big.NewFloat(20))
+
+	p.Settle(ann, game.Blackjack, big.NewFloat(10), big.NewFloat(25))
+	p.Settle(ann, game.Lose, big.NewFloat(10), big.NewFloat(0))
+	p.Settle(ann, game.Surrendered, big.NewFloat(10), big.NewFloat(5))
+	p.Settle(bob, game.Win, big.NewFloat(10), big.NewFloat(20))
+
+	assert.Equal(t, 3, p.Stats.Hands)
+	assert.Equal(t, 1, p.Stats.Wins)
+	assert.Equal(t, 1, p.Stats.Blackjacks)
+	assert.Equal(t, 2, p.Stats.Losses)
+	assert.Equal(t, 1, p.Stats.Surrenders)
+	assert.Equal(t, "30", p.Stats.Wagered.String())
+	assert.Equal(t, "0", p.Stats.Net.String())
+	assert.InDelta(t, 0.333, p.Stats.WinRate(), 0.001)
+
+	p.Settle(ann, game.Insured, big.NewFloat(5), big.NewFloat(15))
+	p.Settle(ann, game.SideWager, big.NewFloat(5), big.NewFloat(0))
+	assert.Equal(t, 3, p.Stats.Hands, "Not hands.")
+	assert.Equal(t, 1, p.Stats.Insurance)
+	assert.Equal(t, 1, p.Stats.SideBets)
+	assert.Equal(t, "40", p.Stats.Wagered.String())
+	assert.Equal(t, "5", p.Stats.Net.String())
+}
+
+//
+// Store
+//
+
+// Profiles should be saved and loaded with everything they keep.
+func TestStore_Save(t *testing.T) {
+	store := tempStore(t)
+	p := New("Ann Smith")
+	p.Bankroll = big.NewFloat(123.5)
+	p.Stats.record(game.Win, big.NewFloat(5), big.NewFloat(10))
+	p.Rules.Decks = 8
+	assert.NoError(t, store.Save(p))
+
+	loaded, err := store.Load("ann smith")
+	assert.NoError(t, err)
+	assert.Equal(t, "Ann Smith", loaded.Name)
+	assert.Equal(t, "123.5", loaded.Bankroll.String())
+	assert.Equal(t, 1, loaded.Stats.Wins)
+	assert.Equal(t, "5", loaded.Stats.Net.String())
+	assert.Equal(t, 8, loaded.Rules.Decks)
+}
+
+// Opening a profile that doesn't exist yet should make a new one.
+func TestStore_Open(t *testing.T) {
+	store := tempStore(t)
+	_, err := store.Load("Ann")
+	assert.Equal(t, ErrNotFound, err)
+
+	p, err := store.Open("Ann")
+	assert.NoError(t, err)
+	assert.Equal(t, "100", p.Bankroll.String())
+}
+
+// Should be able to list the profiles, leaving out their other files.
+func TestStore_List(t *testing.T) {
+	store := tempStore(t)
+	store.Save(New("Bob"))
+	store.Save(New("Ann"))
+	session, _ := store.Path("Ann", ".session.json")
+	os.WriteFile(session, []byte("{}"), 0644)
+
+	names, err := store.List()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Ann", "Bob"}, names)
+}
+
+// Profile names should be kept to those that make safe file names.
+func TestStore_Path(t *testing.T) {
+	store := Store{Dir: "profiles"}
+	path, err := store.Path("Ann Smith", ".json")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join("profiles", "ann-smith.json"), path)
+
+	for _, name := range []string{"", "  ", "../ann", "ann/bob"} {
+		_, err := store.Path(name, ".json")
+		assert.Equal(t, ErrBadName, err, name)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+
+	"github.com/hughgrigg/blackjack/game"
+	"github.com/hughgrigg/blackjack/profile"
+	"github.com/leekchan/accounting"
+)
+
+var ac = accounting.Accounting{Symbol: "£", Precision: 2}
+
+// manageProfiles lists, shows, resets or tops up the saved player profiles.
+func manageProfiles(args []string) {
+	flags := flag.NewFlagSet("profile", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: blackjack profile list")
+		fmt.Fprintln(os.Stderr, "       blackjack profile show NAME")
+		fmt.Fprintln(os.Stderr, "       blackjack profile reset NAME")
+		fmt.Fprintln(os.Stderr, "       blackjack profile topup NAME AMOUNT")
+	}
+	flags.Parse(args)
+	args = flags.Args()
+	store := profile.DefaultStore()
+
+	if len(args) == 1 && args[0] == "list" {
+		names, err := store.List()
+		if err != nil {
+			fail(err)
+		}
+		for _, name := range names {
+			fmt.Println(name)
+		}
+		return
+	}
+	if len(args) < 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	command, name := args[0], args[1]
+	p, err := store.Load(name)
+	if err == profile.ErrNotFound && command != "show" {
+		p, err = profile.New(name), nil
+	}
+	if err != nil {
+		fail(err)
+	}
+	switch {
+	case command == "show" && len(args) == 2:
+		showProfile(p)
+		return
+	case command == "reset" && len(args) == 2:
+		p.Reset()
+	case command == "topup" && len(args) == 3:
+		amount, err := strconv.ParseFloat(args[2], 64)
+		if err != nil {
+			fail(fmt.Errorf("can't top up by %q", args[2]))
+		}
+		if err := p.TopUp(amount); err != nil {
+			fail(err)
+		}
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+	if err := store.Save(p); err != nil {
+		fail(err)
+	}
+	showProfile(p)
+}
+
+// showProfile prints a profile's bankroll and statistics as a table.
+func showProfile(p *profile.Profile) {
+	stats := p.Stats
+	for _, line := range []struct {
+		label string
+		value string
+	}{
+		{"Profile", p.Name},
+		{"Bankroll", ac.FormatMoneyBigFloat(p.Bankroll)},
+		{"Hands", fmt.Sprintf("%d", stats.Hands)},
+		{"Wins", fmt.Sprintf("%d (%.2f%%)", stats.Wins, 100*stats.WinRate())},
+		{"Losses", fmt.Sprintf("%d", stats.Losses)},
+		{"Pushes", fmt.Sprintf("%d", stats.Pushes)},
+		{"Blackjacks", fmt.Sprintf("%d", stats.Blackjacks)},
+		{"Surrenders", fmt.Sprintf("%d", stats.Surrenders)},
+		{"Insurance", fmt.Sprintf("%d", stats.Insurance)},
+		{"Side bets", fmt.Sprintf("%d", stats.SideBets)},
+		{"Wagered", ac.FormatMoneyBigFloat(stats.Wagered)},
+		{"Net", ac.FormatMoneyBigFloat(stats.Net)},
+		{"Rules", describeRules(p.Rules)},
+	} {
+		fmt.Printf("%-15s %s\n", line.label, line.value)
+	}
+}
+
+// describeRules sums up the main house rules, e.g. "6 decks, S17, 3:2".
+func describeRules(rules game.Rules) string {
+	dealer := "S17"
+	if rules.HitSoft17 {
+		dealer = "H17"
+	}
+	return fmt.Sprintf("%d decks, %s, %s", rules.Decks, dealer, rules.BlackjackPayout)
+}
+
+// takeSeat sits a profile at its seat on a board, the first with its name,
+// with its bankroll less anything the seat already has on the table, and keeps
+// the books for it.
+func takeSeat(p *profile.Profile, board *game.Board) {
+	for _, seat := range board.Players {
+		if seat.Name != p.Name {
+			continue
+		}
+		onTable := new(big.Float).Sub(seat.Worth(), seat.Balance)
+		seat.Balance = new(big.Float).Sub(p.Bankroll, onTable)
+		if seat.Balance.Sign() < 0 {
+			seat.Balance = big.NewFloat(0)
+		}
+		p.Sit(seat)
+		break
+	}
+	board.Bookkeepers = append(board.Bookkeepers, p)
+}
+
+// saveProfile keeps what the profile's seat is worth as its bankroll, and saves
+// it to the store.
+func saveProfile(store profile.Store, p *profile.Profile) error {
+	if seat := p.Seat(); seat != nil {
+		p.Bankroll = seat.Worth()
+	}
+	return store.Save(p)
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/hughgrigg/blackjack/game"
+	"github.com/hughgrigg/blackjack/strategy"
+)
+
+// ruleFlags adds flags for the house rules to a flag set, returning a function
+// to get the rules once the flags have been parsed. Only the flags given change
+// the rules the function starts from, e.g. a profile's preferred rules.
+func ruleFlags(flags *flag.FlagSet) func(base game.Rules) (game.Rules, error) {
+	defaults := game.DefaultRules()
+	decks := flags.Int("decks", defaults.Decks, "number of decks in the shoe")
+	s17 := flags.Bool("s17", false, "dealer stands on soft 17")
+	payout := flags.String("payout", defaults.BlackjackPayout.String(), "blackjack payout: 3:2, 6:5 or 1:1")
+	double := flags.String("double", "any", "hands that can be doubled: any, 9-11 or 10-11")
+	noDAS := flags.Bool("no-das", false, "no doubling after splitting")
+	maxSplits := flags.Int("max-splits", defaults.MaxSplits, "most times a seat can split, or 0 for no splitting")
+	resplitAces := flags.Bool("resplit-aces", defaults.ResplitAces, "split aces can be split again")
+	hitSplitAces := flags.Bool("hit-split-aces", defaults.HitSplitAces, "split aces can be hit")
+	surrender := flags.String("surrender", "none", "surrender: none, late or early")
+	noHoleCard := flags.Bool("no-hole-card", false, "European no hole card rule")
+	sideBets := flags.String("side-bets", "", "side bets offered: any of 21+3, perfect-pairs and lucky-ladies, separated by commas, or all")
+
+	return func(base game.Rules) (game.Rules, error) {
+		var err error
+		rules := base
+		given := map[string]bool{}
+		flags.Visit(func(f *flag.Flag) {
+			given[f.Name] = true
+		})
+		if given["decks"] {
+			rules.Decks = *decks
+		}
+		if given["s17"] {
+			rules.HitSoft17 = !*s17
+		}
+		if given["no-das"] {
+			rules.DoubleAfterSplit = !*noDAS
+		}
+		if given["max-splits"] {
+			rules.MaxSplits = *maxSplits
+		}
+		if given["resplit-aces"] {
+			rules.ResplitAces = *resplitAces
+		}
+		if given["hit-split-aces"] {
+			rules.HitSplitAces = *hitSplitAces
+		}
+		if given["no-hole-card"] {
+			rules.HoleCard = game.AmericanHoleCard
+			if *noHoleCard {
+				rules.HoleCard = game.EuropeanNoHoleCard
+			}
+		}
+		if given["payout"] {
+			switch *payout {
+			case "3:2":
+				rules.BlackjackPayout = game.ThreeToTwo
+			case "6:5":
+				rules.BlackjackPayout = game.SixToFive
+			case "1:1":
+				rules.BlackjackPayout = game.OneToOne
+			default:
+				return rules, fmt.Errorf("unknown blackjack payout %q", *payout)
+			}
+		}
+		if given["double"] {
+			switch *double {
+			case "any":
+				rules.Double = game.DoubleAnyTwo
+			case "9-11":
+				rules.Double = game.DoubleNineToEleven
+			case "10-11":
+				rules.Double = game.DoubleTenToEleven
+			default:
+				return rules, fmt.Errorf("unknown double rule %q", *double)
+			}
+		}
+		if given["surrender"] {
+			switch *surrender {
+			case "none":
+				rules.Surrender = game.SurrenderNone
+			case "late":
+				rules.Surrender = game.SurrenderLate
+			case "early":
+				rules.Surrender = game.SurrenderEarly
+			default:
+				return rules, fmt.Errorf("unknown surrender rule %q", *surrender)
+			}
+		}
+		if given["side-bets"] {
+			if rules.SideBets, err = offeredSideBets(*sideBets); err != nil {
+				return rules, err
+			}
+		}
+		return rules, rules.Validate()
+	}
+}
+
+// The names of the flags added by ruleFlags.
+var ruleFlagNames = []string{
+	"decks", "s17", "payout", "double", "no-das", "max-splits", "resplit-aces",
+	"hit-split-aces", "surrender", "no-hole-card", "side-bets",
+}
+
+// offeredSideBets gets the side bets named in a list like "21+3,lucky-ladies",
+// with their usual paytables.
+func offeredSideBets(list string) (*game.SideBetRules, error) {
+	if list == "" {
+		return nil, nil
+	}
+	if list == "all" {
+		return game.DefaultSideBets(game.SideBetKinds...), nil
+	}
+	kinds := []game.SideBetKind{}
+	for _, name := range strings.Split(list, ",") {
+		kind := game.SideBetKind(strings.ReplaceAll(strings.TrimSpace(name), "-", " "))
+		if _, ok := game.DefaultSideBets(kind).Paytables[kind]; !ok {
+			return nil, fmt.Errorf("unknown side bet %q", name)
+		}
+		kinds = append(kinds, kind)
+	}
+	return game.DefaultSideBets(kinds...), nil
+}
+
+// rulesGiven sees if any of the house rules were set on the command line.
+func rulesGiven(flags *flag.FlagSet) bool {
+	given := false
+	flags.Visit(func(f *flag.Flag) {
+		for _, name := range ruleFlagNames {
+			if f.Name == name {
+				given = true
+			}
+		}
+	})
+	return given
+}
+
+// controller gets a bot that plays in a named way. Bots played by another
+// program write what it prints to standard error to stderr, or nowhere if it's
+// nil.
+func controller(play string, stderr io.Writer) (game.Controller, error) {
+	switch play {
+	case "dealer":
+		return game.MimicDealer{}, nil
+	case "basic":
+		return strategy.Bot{}, nil
+	}
+	// Any other program can play by talking the bot protocol, e.g.
+	// "exec:python3 bot.py".
+	if strings.HasPrefix(play, "exec:") {
+		if command := strings.Fields(strings.TrimPrefix(play, "exec:")); len(command) > 0 {
+			cmd := exec.Command(command[0], command[1:]...)
+			cmd.Stderr = stderr
+			return game.StartProcessBot(cmd)
+		}
+	}
+	return nil, fmt.Errorf("bots can play like the dealer, basic strategy or exec:COMMAND, not %q", play)
+}
+
+// closeBot ends the program playing a bot, if there is one, saying so if it
+// failed.
+func closeBot(bot game.Controller) {
+	closer, ok := bot.(io.Closer)
+	if !ok {
+		return
+	}
+	if err := closer.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "the bot's program failed: %s\n", err)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hughgrigg/blackjack/cards"
+	"github.com/hughgrigg/blackjack/counting"
+	"github.com/hughgrigg/blackjack/game"
+)
+
+// defaultSessionFile gets where the game is saved unless told otherwise, in
+// the user's config directory.
+func defaultSessionFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ".blackjack-session.json"
+	}
+	return filepath.Join(dir, "blackjack", "session.json")
+}
+
+// loadSession loads the board saved in a session file, if there is one.
+func loadSession(path string, bot game.Controller) (*game.Board, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return game.LoadBoard(file, bot)
+}
+
+// saveSession saves the board to a session file. If the board is in the
+// middle of play, e.g. the dealer is taking their turn, it is given a few
+// seconds to get to a point where it can be saved.
+func saveSession(board *game.Board, path string) error {
+	deadline := time.Now().Add(10 * time.Second)
+	buffer := bytes.Buffer{}
+	for {
+		buffer.Reset()
+		err := board.Save(&buffer)
+		if err == game.ErrBusy && time.Now().Before(deadline) {
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		break
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buffer.Bytes(), 0644)
+}
+
+// catchUp counts the cards already dealt from a loaded shoe, so that the count
+// carries on from where it was.
+func catchUp(counter *counting.Counter, board *game.Board) {
+	unseen := map[cards.Rank]int{}
+	for _, card := range board.Unseen().Cards {
+		unseen[card.Rank()]++
+	}
+	for _, card := range cards.NewShoe(board.Deck.DeckCount()).Cards {
+		if unseen[card.Rank()] > 0 {
+			unseen[card.Rank()]--
+			continue
+		}
+		counter.See(card)
+	}
+}
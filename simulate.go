@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hughgrigg/blackjack/game"
+	"github.com/hughgrigg/blackjack/simulation"
+)
+
+// simulate plays out many rounds with no display and reports how they went.
+func simulate(args []string) {
+	flags := flag.NewFlagSet("simulate", flag.ExitOnError)
+	rounds := flags.Int("rounds", 1000000, "number of rounds to play")
+	play := flags.String("strategy", "basic", "how to play: basic, dealer or exec:COMMAND")
+	workers := flags.Int("workers", 0, "rounds to play at once, defaults to one per CPU core")
+	seed := flags.Int64("seed", 0, "seed for repeatable shuffles, defaults to the time")
+	rules := ruleFlags(flags)
+	flags.Parse(args)
+
+	houseRules, err := rules(game.DefaultRules())
+	if err != nil {
+		fail(err)
+	}
+	bot, err := controller(*play, os.Stderr)
+	if err != nil {
+		fail(err)
+	}
+	defer closeBot(bot)
+
+	start := time.Now()
+	report, err := simulation.Run(simulation.Config{
+		Rounds:   *rounds,
+		Rules:    houseRules,
+		Strategy: bot,
+		Workers:  *workers,
+		Seed:     *seed,
+	})
+	if err != nil {
+		closeBot(bot)
+		fail(fmt.Errorf("the simulation stopped: %s", err))
+	}
+	fmt.Print(report)
+	fmt.Printf("%-15s %s\n", "Time", time.Since(start).Round(time.Millisecond))
+}
+
+// fail gives up with an error message.
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(2)
+}
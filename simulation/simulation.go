@@ -0,0 +1,237 @@
+package simulation
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/hughgrigg/blackjack/cards"
+	"github.com/hughgrigg/blackjack/game"
+)
+
+//
+// Simulation
+//
+
+// Config sets up a simulation run.
+type Config struct {
+	// The number of rounds to play.
+	Rounds int
+	// The house rules to play under.
+	Rules game.Rules
+	// Plays the hands, e.g. a basic strategy bot.
+	Strategy game.Controller
+	// The number of rounds to play at once. Zero means one per CPU core.
+	Workers int
+	// Seeds the shuffles, so that a run can be repeated. Each worker shuffles
+	// its own shoe from the seed plus its number. Zero means seeding from the
+	// time.
+	Seed int64
+}
+
+// Report is the outcome of a simulation run. Money is counted in units of the
+// initial bet on each round.
+type Report struct {
+	Rounds int
+	// The total won, or lost if negative.
+	Net float64
+	// The sum of squares of each round's result, for the standard deviation.
+	netSquares float64
+	Wins       int
+	Losses     int
+	Pushes     int
+	Blackjacks int
+}
+
+// The balance each simulated seat starts with, which is enough to never run
+// out.
+const bankroll = 1e12
+
+// Run plays out a simulation, spreading the rounds across workers that each
+// have their own board and shoe. It stops at the first error from the board.
+func Run(config Config) (Report, error) {
+	if err := config.Rules.Validate(); err != nil {
+		return Report{}, err
+	}
+	workers := config.Workers
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	reports := make([]Report, workers)
+	errs := make([]error, workers)
+	wg := sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		rounds := config.Rounds / workers
+		if i < config.Rounds%workers {
+			rounds++
+		}
+		wg.Add(1)
+		go func(i int, rounds int) {
+			defer wg.Done()
+			reports[i], errs[i] = play(config, rounds, seed+int64(i))
+		}(i, rounds)
+	}
+	wg.Wait()
+
+	total := Report{}
+	for i, report := range reports {
+		if errs[i] != nil {
+			return total, errs[i]
+		}
+		total.add(report)
+	}
+	return total, nil
+}
+
+// play plays a number of rounds on a board of its own, betting one unit each
+// round.
+func play(config Config, rounds int, seed int64) (Report, error) {
+	board := &game.Board{
+		Deck: &cards.Deck{
+			Decks:  config.Rules.Decks,
+			Random: cards.NewSeededRandomiser(seed),
+		},
+		Players: []*game.Player{
+			game.NewPlayer("Simulation", bankroll, config.Strategy),
+		},
+	}
+	engine := game.NewEngine(board, config.Rules)
+	player := board.Players[0]
+	report := Report{}
+
+	for i := 0; i < rounds; i++ {
+		if err := engine.Execute(0, game.Command{Type: game.CommandBet, Amount: 1}); err != nil {
+			return report, err
+		}
+		before, _ := player.Balance.Float64()
+		before++ // The bet is taken off the balance.
+
+		if err := engine.Execute(0, game.Command{Type: game.CommandDeal}); err != nil {
+			return report, err
+		}
+		if _, ok := board.Stage.(*game.Conclusion); !ok {
+			return report, fmt.Errorf("a round was left waiting in the %s stage", game.StageName(board.Stage))
+		}
+
+		after, _ := player.Balance.Float64()
+		first := player.Bets[0]
+		natural := len(player.Bets) == 1 &&
+			len(first.Hand.Cards) == 2 &&
+			first.Hand.HasBlackJack()
+		report.record(after-before, natural)
+
+		if err := engine.Execute(0, game.Command{Type: game.CommandNewRound}); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// record counts the result of a round.
+func (r *Report) record(net float64, natural bool) {
+	r.Rounds++
+	r.Net += net
+	r.netSquares += net * net
+	switch {
+	case net > 0:
+		r.Wins++
+	case net < 0:
+		r.Losses++
+	default:
+		r.Pushes++
+	}
+	if natural {
+		r.Blackjacks++
+	}
+}
+
+// add combines another report into this one.
+func (r *Report) add(other Report) {
+	r.Rounds += other.Rounds
+	r.Net += other.Net
+	r.netSquares += other.netSquares
+	r.Wins += other.Wins
+	r.Losses += other.Losses
+	r.Pushes += other.Pushes
+	r.Blackjacks += other.Blackjacks
+}
+
+// HouseEdge gets the house's average take as a fraction of the initial bet.
+func (r Report) HouseEdge() float64 {
+	return -r.mean()
+}
+
+// StdDev gets the standard deviation of a round's result, in units of the
+// initial bet.
+func (r Report) StdDev() float64 {
+	if r.Rounds < 2 {
+		return 0
+	}
+	mean := r.mean()
+	variance := (r.netSquares - float64(r.Rounds)*mean*mean) / float64(r.Rounds-1)
+	return math.Sqrt(variance)
+}
+
+// WinRate gets the fraction of rounds won overall.
+func (r Report) WinRate() float64 {
+	return r.rate(r.Wins)
+}
+
+// LossRate gets the fraction of rounds lost overall.
+func (r Report) LossRate() float64 {
+	return r.rate(r.Losses)
+}
+
+// PushRate gets the fraction of rounds that broke even.
+func (r Report) PushRate() float64 {
+	return r.rate(r.Pushes)
+}
+
+// BlackjackRate gets the fraction of rounds the player was dealt blackjack.
+func (r Report) BlackjackRate() float64 {
+	return r.rate(r.Blackjacks)
+}
+
+// mean gets the average result of a round.
+func (r Report) mean() float64 {
+	if r.Rounds == 0 {
+		return 0
+	}
+	return r.Net / float64(r.Rounds)
+}
+
+// rate gets a count as a fraction of rounds played.
+func (r Report) rate(count int) float64 {
+	if r.Rounds == 0 {
+		return 0
+	}
+	return float64(count) / float64(r.Rounds)
+}
+
+// String gets the report as a table.
+func (r Report) String() string {
+	buffer := bytes.Buffer{}
+	for _, line := range []struct {
+		label string
+		value string
+	}{
+		{"Rounds", fmt.Sprintf("%d", r.Rounds)},
+		{"House edge", fmt.Sprintf("%.3f%%", 100*r.HouseEdge())},
+		{"Std deviation", fmt.Sprintf("%.3f", r.StdDev())},
+		{"Wins", fmt.Sprintf("%.2f%%", 100*r.WinRate())},
+		{"Losses", fmt.Sprintf("%.2f%%", 100*r.LossRate())},
+		{"Pushes", fmt.Sprintf("%.2f%%", 100*r.PushRate())},
+		{"Blackjacks", fmt.Sprintf("%.2f%%", 100*r.BlackjackRate())},
+	} {
+		buffer.WriteString(fmt.Sprintf("%-15s %s\n", line.label, line.value))
+	}
+	return buffer.String()
+}
@@ -0,0 +1,97 @@
+package simulation
+
+import (
+	"testing"
+
+	"github.com/hughgrigg/blackjack/game"
+	"github.com/hughgrigg/blackjack/strategy"
+	"github.com/stretchr/testify/assert"
+)
+
+//
+// Simulation
+//
+
+// Should play out the number of rounds asked for across workers.
+func TestRun(t *testing.T) {
+	report, err := Run(Config{
+		Rounds:   2001,
+		Rules:    game.DefaultRules(),
+		Strategy: strategy.Bot{},
+		Workers:  4,
+		Seed:     1,
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2001, report.Rounds)
+	assert.Equal(t, 2001, report.Wins+report.Losses+report.Pushes)
+	assert.InDelta(t, 1, report.WinRate()+report.LossRate()+report.PushRate(), 1e-9)
+	assert.InDelta(t, 0.045, report.BlackjackRate(), 0.02)
+	assert.InDelta(t, 1.15, report.StdDev(), 0.2)
+}
+
+// Runs with the same seed should have the same results.
+func TestRun_Seed(t *testing.T) {
+	config := Config{
+		Rounds:   500,
+		Rules:    game.DefaultRules(),
+		Strategy: game.MimicDealer{},
+		Workers:  2,
+		Seed:     42,
+	}
+	first, err := Run(config)
+	assert.NoError(t, err)
+	second, err := Run(config)
+	assert.NoError(t, err)
+	assert.Equal(t, first, second)
+}
+
+// Runs that can't be played out should stop with an error, rather than
+// panicking.
+func TestRun_Errors(t *testing.T) {
+	rules := game.DefaultRules()
+	rules.Decks = 0
+	_, err := Run(Config{Rounds: 10, Rules: rules, Strategy: strategy.Bot{}})
+	assert.Error(t, err)
+
+	_, err = Run(Config{Rounds: 10, Rules: game.DefaultRules(), Workers: 1, Seed: 5})
+	assert.EqualError(t, err, "a round was left waiting in the player stage")
+}
+
+// Basic strategy should come close to breaking even, and do better than
+// playing like the dealer.
+func TestRun_HouseEdge(t *testing.T) {
+	if testing.Short() {
+		t.Skip("simulating many rounds")
+	}
+	rules := game.DefaultRules()
+	rules.Decks = 6
+	basic, err := Run(Config{Rounds: 20000, Rules: rules, Strategy: strategy.Bot{}, Seed: 1})
+	assert.NoError(t, err)
+	mimic, err := Run(Config{Rounds: 20000, Rules: rules, Strategy: game.MimicDealer{}, Seed: 1})
+	assert.NoError(t, err)
+
+	assert.InDelta(t, 0.005, basic.HouseEdge(), 0.015)
+	assert.True(t, mimic.HouseEdge() > basic.HouseEdge())
+}
+
+// A report should be printed as a table.
+func TestReport_String(t *testing.T) {
+	report := Report{}
+	report.record(1.5, true)
+	report.record(-1, false)
+	report.record(0, false)
+	report.record(2, false)
+
+	assert.Equal(
+		t,
+		"Rounds          4\n"+
+			"House edge      -62.500%\n"+
+			"Std deviation   1.377\n"+
+			"Wins            50.00%\n"+
+			"Losses          25.00%\n"+
+			"Pushes          25.00%\n"+
+			"Blackjacks      25.00%\n",
+		report.String(),
+	)
+}
@@ -0,0 +1,59 @@
+package strategy
+
+import (
+	"github.com/hughgrigg/blackjack/game"
+)
+
+//
+// Bot
+//
+
+// Bot is a game controller that plays basic strategy. It never takes
+// insurance or even money.
+type Bot struct {
+}
+
+// Choose picks the available action with the best expected value, declining
+// anything else that is on offer.
+func (bot Bot) Choose(board *game.Board, actions game.ActionSet) string {
+	commands := map[game.CommandType]string{}
+	for key, action := range actions {
+		commands[action.Command] = key
+	}
+
+	// Surrender early if that beats playing on.
+	if key, ok := commands[game.CommandPlayOn]; ok {
+		if surrender, ok := commands[game.CommandSurrender]; ok &&
+			bot.Advise(board) == Surrender {
+			return surrender
+		}
+		return key
+	}
+	if key, ok := commands[game.CommandDeclineInsurance]; ok {
+		return key
+	}
+
+	evs := Evaluate(
+		board.Player.ActiveBet().Hand,
+		board.Dealer.UpCard(),
+		board.Rules,
+		OptionsFor(board.Player, board.Rules),
+	)
+	for _, action := range Rank(evs) {
+		if key, ok := commands[action.Command()]; ok {
+			return key
+		}
+	}
+	return commands[game.CommandStand]
+}
+
+// Advise gets the basic strategy play for the active hand of the seat whose
+// turn it is.
+func (bot Bot) Advise(board *game.Board) Action {
+	return Advise(
+		board.Player.ActiveBet().Hand,
+		board.Dealer.UpCard(),
+		board.Rules,
+		OptionsFor(board.Player, board.Rules),
+	)
+}
@@ -0,0 +1,31 @@
+package strategy
+
+import (
+	"testing"
+
+	"github.com/hughgrigg/blackjack/cards"
+	"github.com/hughgrigg/blackjack/game"
+	"github.com/stretchr/testify/assert"
+)
+
+//
+// Bot
+//
+
+// The basic strategy bot should choose the available action that is advised.
+func TestBot_Choose(t *testing.T) {
+	engine := game.NewEngine(&game.Board{}, game.DefaultRules())
+	deck := engine.Board.Deck
+	deck.Cards[51] = cards.NewCard(cards.Six, cards.Clubs)   // dealer 1
+	deck.Cards[50] = cards.NewCard(cards.Six, cards.Spades)  // player 1
+	deck.Cards[49] = cards.NewCard(cards.Seven, cards.Clubs) // dealer 2
+	deck.Cards[48] = cards.NewCard(cards.Five, cards.Hearts) // player 2
+	engine.Submit(0, game.Command{Type: game.CommandDeal})
+
+	board := engine.Board
+	assert.Equal(t, "d", Bot{}.Choose(board, board.Stage.Actions(board)))
+
+	// Without enough money to double down, hitting is next best.
+	board.Player.Balance.SetInt64(0)
+	assert.Equal(t, "h", Bot{}.Choose(board, board.Stage.Actions(board)))
+}
@@ -0,0 +1,302 @@
+package strategy
+
+import (
+	"github.com/hughgrigg/blackjack/cards"
+	"github.com/hughgrigg/blackjack/game"
+)
+
+//
+// Exact analysis
+//
+
+// Analyse works out the exact expected value of standing, hitting and each play
+// the options allow on a hand, as a multiple of the bet. Rather than the full
+// shoe the rules call for, the values come from the cards that are still
+// unseen, e.g. what's left of a partly dealt shoe plus the dealer's hole card,
+// and every card drawn is taken out of the shoe.
+//
+// When the dealer peeks for blackjack, values are given on the condition that
+// the dealer did not have it. Splits are valued as two hands played from the
+// same unseen cards, without resplitting.
+func Analyse(hand *cards.Hand, up *cards.Card, unseen *cards.Deck, rules game.Rules, options Options) map[Action]float64 {
+	if up == nil || len(hand.Cards) == 0 {
+		return map[Action]float64{Stand: 0}
+	}
+	a := &analyser{
+		rules:   rules,
+		up:      up.Values()[0],
+		stands:  map[node]outcomes{},
+		dealers: map[node]outcomes{},
+		hits:    map[node]float64{},
+	}
+	switch a.up {
+	case 1:
+		a.blackjackCard = 10
+	case 10:
+		a.blackjackCard = 1
+	}
+	a.peeks = rules.HoleCard == game.AmericanHoleCard && a.blackjackCard != 0
+
+	shoe := counts{}
+	for _, card := range unseen.Cards {
+		shoe[card.Values()[0]]++
+	}
+
+	start := total{}
+	for _, card := range hand.Cards {
+		start = start.add(card.Values()[0])
+	}
+
+	if hand.HasBlackJack() {
+		payout := rules.BlackjackPayout
+		win := float64(payout.Win) / float64(payout.Stake)
+		return map[Action]float64{Stand: win * (1 - a.dealerFirst(shoe)[dealerBlackjack])}
+	}
+
+	evs := map[Action]float64{
+		Stand: a.stand(start, shoe),
+		Hit:   a.hit(start, shoe),
+	}
+	if options.Double {
+		evs[Double] = a.double(start, shoe)
+	}
+	if options.Split && hand.CanSplit() {
+		evs[Split] = a.split(hand.Cards[0].Values()[0], shoe)
+	}
+	if options.Surrender {
+		evs[Surrender] = -0.5
+		if a.peeks && rules.Surrender == game.SurrenderEarly {
+			blackjack := shoe.chance(a.blackjackCard)
+			evs[Surrender] = (-0.5 + blackjack) / (1 - blackjack)
+		}
+	}
+	return evs
+}
+
+// counts are the numbers of unseen cards of each value, indexed 1 (ace) to 10.
+type counts [11]uint16
+
+// size gets the number of unseen cards.
+func (c counts) size() int {
+	n := 0
+	for _, count := range c {
+		n += int(count)
+	}
+	return n
+}
+
+// chance gets the chance of drawing a value from the unseen cards.
+func (c counts) chance(value int) float64 {
+	n := c.size()
+	if n == 0 {
+		return 0
+	}
+	return float64(c[value]) / float64(n)
+}
+
+// without gets the unseen cards after drawing a value.
+func (c counts) without(value int) counts {
+	c[value]--
+	return c
+}
+
+// node is a total with the unseen cards, for remembering values already worked
+// out.
+type node struct {
+	total  total
+	unseen counts
+}
+
+// analyser works out exact expected values by going through every way the rest
+// of the round could be dealt.
+type analyser struct {
+	rules game.Rules
+	up    int
+	// The value of hole card that would give the dealer blackjack, if any.
+	blackjackCard int
+	peeks         bool
+	stands        map[node]outcomes
+	dealers       map[node]outcomes
+	hits          map[node]float64
+}
+
+// draws gets the chance of the player drawing each value next. When the dealer
+// has peeked, the hole card is known not to make blackjack, which makes those
+// cards a little more likely to be drawn.
+func (a *analyser) draws(shoe counts) [11]float64 {
+	chances := [11]float64{}
+	n := shoe.size()
+	if n == 0 {
+		return chances
+	}
+	if !a.peeks {
+		for value := 1; value <= 10; value++ {
+			chances[value] = float64(shoe[value]) / float64(n)
+		}
+		return chances
+	}
+	if n < 2 {
+		return chances
+	}
+	others := float64(n - int(shoe[a.blackjackCard]))
+	for value := 1; value <= 10; value++ {
+		hole := 0.0
+		if value != a.blackjackCard && others > 0 {
+			hole = float64(shoe[value]) / others
+		}
+		chances[value] = (float64(shoe[value]) - hole) / float64(n-1)
+	}
+	return chances
+}
+
+// stand gets the value of standing on a total.
+func (a *analyser) stand(t total, shoe counts) float64 {
+	score := t.score()
+	if score > 21 {
+		return -1
+	}
+	dealer := a.dealerFirst(shoe)
+	ev := dealer[dealerBust] - dealer[dealerBlackjack]
+	for dealerScore := 0; dealerScore <= 21; dealerScore++ {
+		if score > dealerScore {
+			ev += dealer[dealerScore]
+		} else if score < dealerScore {
+			ev -= dealer[dealerScore]
+		}
+	}
+	return ev
+}
+
+// hit gets the value of hitting a total and then playing on as well as
+// possible.
+func (a *analyser) hit(t total, shoe counts) float64 {
+	key := node{t, shoe}
+	if ev, ok := a.hits[key]; ok {
+		return ev
+	}
+	ev := 0.0
+	for value, chance := range a.draws(shoe) {
+		if chance == 0 {
+			continue
+		}
+		next := t.add(value)
+		if next.score() > 21 {
+			ev -= chance
+			continue
+		}
+		ev += chance * a.standOrHit(next, shoe.without(value))
+	}
+	a.hits[key] = ev
+	return ev
+}
+
+// standOrHit gets the better value of standing or hitting a total.
+func (a *analyser) standOrHit(t total, shoe counts) float64 {
+	stand := a.stand(t, shoe)
+	if t.score() == 21 {
+		return stand
+	}
+	if hit := a.hit(t, shoe); hit > stand {
+		return hit
+	}
+	return stand
+}
+
+// double gets the value of doubling down on a total, taking one more card.
+func (a *analyser) double(t total, shoe counts) float64 {
+	ev := 0.0
+	for value, chance := range a.draws(shoe) {
+		if chance == 0 {
+			continue
+		}
+		ev += chance * a.stand(t.add(value), shoe.without(value))
+	}
+	return 2 * ev
+}
+
+// split gets the value of splitting a pair, playing each hand on as well as
+// the rules allow.
+func (a *analyser) split(value int, shoe counts) float64 {
+	ev := 0.0
+	for second, chance := range a.draws(shoe) {
+		if chance == 0 {
+			continue
+		}
+		t := total{}.add(value).add(second)
+		rest := shoe.without(second)
+		if value == 1 && !a.rules.HitSplitAces {
+			ev += chance * a.stand(t, rest)
+			continue
+		}
+		best := a.standOrHit(t, rest)
+		if a.rules.DoubleAfterSplit && a.rules.Double.Allows(twoCardHand(value, second)) {
+			if double := a.double(t, rest); double > best {
+				best = double
+			}
+		}
+		ev += chance * best
+	}
+	return 2 * ev
+}
+
+// dealerFirst works out how the dealer will finish from their up card, with
+// their hole card coming from the unseen cards.
+func (a *analyser) dealerFirst(shoe counts) outcomes {
+	key := node{unseen: shoe}
+	if result, ok := a.stands[key]; ok {
+		return result
+	}
+	result := outcomes{}
+	n := shoe.size()
+	if a.peeks {
+		n -= int(shoe[a.blackjackCard])
+	}
+	for hole := 1; hole <= 10; hole++ {
+		if shoe[hole] == 0 || n <= 0 {
+			continue
+		}
+		chance := float64(shoe[hole]) / float64(n)
+		if hole == a.blackjackCard {
+			if !a.peeks {
+				result[dealerBlackjack] += chance
+			}
+			continue
+		}
+		t := total{}.add(a.up).add(hole)
+		for i, p := range a.dealerFrom(t, shoe.without(hole)) {
+			result[i] += chance * p
+		}
+	}
+	a.stands[key] = result
+	return result
+}
+
+// dealerFrom works out how the dealer will finish from a total, hitting from
+// the unseen cards until they have to stand.
+func (a *analyser) dealerFrom(t total, shoe counts) outcomes {
+	key := node{t, shoe}
+	if result, ok := a.dealers[key]; ok {
+		return result
+	}
+	result := outcomes{}
+	score := t.score()
+	n := shoe.size()
+	switch {
+	case score > 21:
+		result[dealerBust] = 1
+	case score > 17 || score == 17 && !(t.soft() && a.rules.HitSoft17) || n == 0:
+		result[score] = 1
+	default:
+		for value := 1; value <= 10; value++ {
+			if shoe[value] == 0 {
+				continue
+			}
+			chance := float64(shoe[value]) / float64(n)
+			for i, p := range a.dealerFrom(t.add(value), shoe.without(value)) {
+				result[i] += chance * p
+			}
+		}
+	}
+	a.dealers[key] = result
+	return result
+}
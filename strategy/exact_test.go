@@ -0,0 +1,104 @@
+package strategy
+
+import (
+	"testing"
+
+	"github.com/hughgrigg/blackjack/cards"
+	"github.com/hughgrigg/blackjack/game"
+	"github.com/stretchr/testify/assert"
+)
+
+// Make a deck of face up cards as the unseen cards.
+func unseen(ranks ...cards.Rank) *cards.Deck {
+	return &cards.Deck{Cards: hand(ranks...).Cards}
+}
+
+// Make the unseen cards from a full shoe, less the cards that can be seen.
+func shoeLess(decks int, seen ...cards.Rank) *cards.Deck {
+	shoe := cards.NewShoe(decks)
+	shoe.Init()
+	for _, rank := range seen {
+		for i, card := range shoe.Cards {
+			if card.Rank() == rank {
+				shoe.Cards = append(shoe.Cards[:i], shoe.Cards[i+1:]...)
+				break
+			}
+		}
+	}
+	return shoe
+}
+
+// Analyse a hand as the first move of a seat's only bet.
+func analyse(h *cards.Hand, up *cards.Card, unseen *cards.Deck, rules game.Rules) map[Action]float64 {
+	return Analyse(h, up, unseen, rules, FirstOptions(h, rules))
+}
+
+//
+// Exact analysis
+//
+
+// Exact values from a fresh shoe should be close to basic strategy's.
+func TestAnalyse_FreshShoe(t *testing.T) {
+	rules := shoeRules()
+	for _, play := range []struct {
+		hand *cards.Hand
+		up   cards.Rank
+	}{
+		{hand(cards.Ten, cards.Six), cards.Ten},
+		{hand(cards.Six, cards.Five), cards.Six},
+		{hand(cards.Eight, cards.Eight), cards.Nine},
+		{hand(cards.Ace, cards.Seven), cards.Ace},
+	} {
+		up := card(play.up)
+		exact := analyse(
+			play.hand,
+			up,
+			shoeLess(6, play.up, play.hand.Cards[0].Rank(), play.hand.Cards[1].Rank()),
+			rules,
+		)
+		approx := evaluate(play.hand, up, rules)
+		assert.Equal(t, len(approx), len(exact))
+		for action, ev := range approx {
+			assert.InDelta(t, ev, exact[action], 0.01, "%s on %s", action, play.hand.Render())
+		}
+	}
+}
+
+// Exact values should follow what is left in the shoe.
+func TestAnalyse_Depleted(t *testing.T) {
+	rules := game.DefaultRules()
+
+	// Only tens left, so any hit busts and the dealer busts on 16.
+	evs := analyse(
+		hand(cards.Ten, cards.Two),
+		card(cards.Six),
+		unseen(cards.Ten, cards.Jack, cards.Queen, cards.King, cards.Ten),
+		rules,
+	)
+	assert.Equal(t, 1.0, evs[Stand])
+	assert.Equal(t, -1.0, evs[Hit])
+	assert.Equal(t, Stand, Best(evs))
+
+	// Only small cards left, so hitting 12 is safe.
+	evs = analyse(
+		hand(cards.Ten, cards.Two),
+		card(cards.Ten),
+		unseen(cards.Two, cards.Two, cards.Three, cards.Two, cards.Three),
+		rules,
+	)
+	assert.Equal(t, Hit, Best(evs))
+}
+
+// When the dealer peeks, the hole card is known not to make blackjack.
+func TestAnalyse_Peek(t *testing.T) {
+	evs := analyse(
+		hand(cards.Ten, cards.King),
+		card(cards.Ace),
+		unseen(cards.Ten, cards.Nine, cards.Nine),
+		game.DefaultRules(),
+	)
+
+	// The dealer's hole card must be a nine, giving them 20.
+	assert.InDelta(t, 0, evs[Stand], 1e-9)
+	assert.InDelta(t, -1, evs[Hit], 1e-9)
+}
@@ -0,0 +1,478 @@
+package strategy
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/hughgrigg/blackjack/cards"
+	"github.com/hughgrigg/blackjack/game"
+)
+
+//
+// Actions
+//
+
+// Action is a play that can be made on a hand.
+type Action int
+
+const (
+	Stand Action = iota
+	Hit
+	Double
+	Split
+	Surrender
+)
+
+var actionNames = map[Action]string{
+	Stand:     "Stand",
+	Hit:       "Hit",
+	Double:    "Double Down",
+	Split:     "Split",
+	Surrender: "Surrender",
+}
+
+// String gets the name of the action, matching the description of the game
+// action that takes it.
+func (a Action) String() string {
+	return actionNames[a]
+}
+
+// Command gets the game command that takes the action.
+func (a Action) Command() game.CommandType {
+	switch a {
+	case Hit:
+		return game.CommandHit
+	case Double:
+		return game.CommandDouble
+	case Split:
+		return game.CommandSplit
+	case Surrender:
+		return game.CommandSurrender
+	}
+	return game.CommandStand
+}
+
+// Options are the plays the rules allow on a hand besides standing and
+// hitting.
+type Options struct {
+	Double    bool
+	Split     bool
+	Surrender bool
+}
+
+// OptionsFor gets the plays the rules allow on a seat's active bet, e.g. no
+// doubling on a split hand without double after split.
+func OptionsFor(player *game.Player, rules game.Rules) Options {
+	bet := player.ActiveBet()
+	return Options{
+		Double:    rules.CanDouble(bet),
+		Split:     rules.CanSplit(player, bet),
+		Surrender: rules.CanSurrender(player, bet),
+	}
+}
+
+// FirstOptions gets the plays the rules allow on a hand as the first move of a
+// seat's only bet.
+func FirstOptions(hand *cards.Hand, rules game.Rules) Options {
+	bet := &game.Bet{Hand: hand}
+	return OptionsFor(&game.Player{Bets: []*game.Bet{bet}}, rules)
+}
+
+//
+// Basic strategy
+//
+
+// Advise gets the basic strategy play for a player's hand against the dealer's
+// up card under a set of house rules, out of the plays the options allow.
+func Advise(hand *cards.Hand, up *cards.Card, rules game.Rules, options Options) Action {
+	return Best(Evaluate(hand, up, rules, options))
+}
+
+// Best picks the action with the highest expected value. Ties go to the
+// simplest play, e.g. standing rather than hitting.
+func Best(evs map[Action]float64) Action {
+	ranked := Rank(evs)
+	if len(ranked) == 0 {
+		return Stand
+	}
+	return ranked[0]
+}
+
+// Rank orders actions from the highest expected value to the lowest.
+func Rank(evs map[Action]float64) []Action {
+	actions := []Action{}
+	for action := range evs {
+		actions = append(actions, action)
+	}
+	sort.Slice(actions, func(i, j int) bool {
+		if evs[actions[i]] != evs[actions[j]] {
+			return evs[actions[i]] > evs[actions[j]]
+		}
+		return actions[i] < actions[j]
+	})
+	return actions
+}
+
+// Evaluate works out the expected value of standing, hitting and each play the
+// options allow on a hand, as a multiple of the bet. This is computed from the
+// composition of the shoe the rules call for, less the cards that can be seen,
+// so it stays correct as the rules change.
+//
+// When the dealer peeks for blackjack, values are given on the condition that
+// the dealer did not have it, and early surrender is valued on the same terms.
+// Splits are valued without resplitting.
+func Evaluate(hand *cards.Hand, up *cards.Card, rules game.Rules, options Options) map[Action]float64 {
+	// Side bets make no difference to how a hand is played.
+	rules.SideBets = nil
+	key := situation{rules: rules, options: options, hand: handValues(hand)}
+	if up != nil {
+		key.up = up.Values()[0]
+	}
+	evs, ok := evaluated.Load(key)
+	if !ok {
+		seen := append([]*cards.Card{up}, hand.Cards...)
+		evs = newCalculator(newComposition(rules, seen), rules).evaluate(hand, up, options)
+		evaluated.Store(key, evs)
+	}
+	// Give out a copy so the cached values can't be changed.
+	result := map[Action]float64{}
+	for action, ev := range evs.(map[Action]float64) {
+		result[action] = ev
+	}
+	return result
+}
+
+// situation is everything the values of a hand's actions depend on.
+type situation struct {
+	rules   game.Rules
+	options Options
+	up      int
+	hand    string
+}
+
+// Values already worked out for each situation, as the same situations come up
+// again and again.
+var evaluated = sync.Map{}
+
+// handValues gets the values of the cards in a hand, sorted so that the order
+// they were dealt in doesn't matter, e.g. to tell if two hands are the same for
+// working out strategy.
+func handValues(hand *cards.Hand) string {
+	values := []byte{}
+	for _, card := range hand.Cards {
+		values = append(values, byte(card.Values()[0]))
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return values[i] < values[j]
+	})
+	return string(values)
+}
+
+// calculator works out expected values from the chance of drawing each card
+// value, assuming those chances don't change as cards are drawn.
+type calculator struct {
+	rules game.Rules
+	// The chance of drawing each value, indexed 1 (ace) to 10.
+	chances [11]float64
+	// The chance of the dealer finishing on each score, for the current up card.
+	dealer outcomes
+	hits   map[total]float64
+}
+
+// newCalculator makes a calculator for a composition of cards.
+func newCalculator(counts composition, rules game.Rules) *calculator {
+	c := &calculator{rules: rules, hits: map[total]float64{}}
+	sum := 0.0
+	for _, count := range counts {
+		sum += count
+	}
+	for value, count := range counts {
+		if sum > 0 {
+			c.chances[value] = count / sum
+		}
+	}
+	return c
+}
+
+// evaluate works out the value of each action available on a hand.
+func (c *calculator) evaluate(hand *cards.Hand, up *cards.Card, options Options) map[Action]float64 {
+	if up == nil || len(hand.Cards) == 0 {
+		return map[Action]float64{Stand: 0}
+	}
+	upValue := up.Values()[0]
+	peeks := c.peeks(upValue)
+	c.dealer = c.dealerOutcomes(upValue, peeks)
+
+	start := total{}
+	for _, card := range hand.Cards {
+		start = start.add(card.Values()[0])
+	}
+
+	if hand.HasBlackJack() {
+		payout := c.rules.BlackjackPayout
+		win := float64(payout.Win) / float64(payout.Stake)
+		return map[Action]float64{Stand: win * (1 - c.dealer[dealerBlackjack])}
+	}
+
+	evs := map[Action]float64{
+		Stand: c.stand(start.score()),
+		Hit:   c.hit(start),
+	}
+	if options.Double {
+		evs[Double] = c.double(start)
+	}
+	if options.Split && hand.CanSplit() {
+		evs[Split] = c.split(hand.Cards[0].Values()[0])
+	}
+	if options.Surrender {
+		evs[Surrender] = -0.5
+		if peeks && c.rules.Surrender == game.SurrenderEarly {
+			// Surrendering before the peek also saves half the bet from a
+			// dealer blackjack, which the other values are conditional on not
+			// happening.
+			blackjack := c.blackjackChance(upValue)
+			evs[Surrender] = (-0.5 + blackjack) / (1 - blackjack)
+		}
+	}
+	return evs
+}
+
+// peeks sees if the dealer checks for blackjack with an up card, ending the
+// round before the player acts if they have it.
+func (c *calculator) peeks(upValue int) bool {
+	return c.rules.HoleCard == game.AmericanHoleCard &&
+		(upValue == 1 || upValue == 10)
+}
+
+// blackjackChance gets the chance of the dealer's second card making blackjack.
+func (c *calculator) blackjackChance(upValue int) float64 {
+	switch upValue {
+	case 1:
+		return c.chances[10]
+	case 10:
+		return c.chances[1]
+	}
+	return 0
+}
+
+// stand gets the value of standing on a score.
+func (c *calculator) stand(score int) float64 {
+	if score > 21 {
+		return -1
+	}
+	ev := c.dealer[dealerBust] - c.dealer[dealerBlackjack]
+	for dealerScore := 17; dealerScore <= 21; dealerScore++ {
+		if score > dealerScore {
+			ev += c.dealer[dealerScore]
+		} else if score < dealerScore {
+			ev -= c.dealer[dealerScore]
+		}
+	}
+	return ev
+}
+
+// hit gets the value of hitting a total and then playing on as well as
+// possible.
+func (c *calculator) hit(t total) float64 {
+	if ev, ok := c.hits[t]; ok {
+		return ev
+	}
+	ev := 0.0
+	for value := 1; value <= 10; value++ {
+		next := t.add(value)
+		if next.score() > 21 {
+			ev -= c.chances[value]
+			continue
+		}
+		ev += c.chances[value] * c.standOrHit(next)
+	}
+	c.hits[t] = ev
+	return ev
+}
+
+// standOrHit gets the better value of standing or hitting a total.
+func (c *calculator) standOrHit(t total) float64 {
+	stand := c.stand(t.score())
+	if t.score() == 21 {
+		return stand
+	}
+	if hit := c.hit(t); hit > stand {
+		return hit
+	}
+	return stand
+}
+
+// double gets the value of doubling down on a total, taking one more card.
+func (c *calculator) double(t total) float64 {
+	ev := 0.0
+	for value := 1; value <= 10; value++ {
+		ev += c.chances[value] * c.stand(t.add(value).score())
+	}
+	return 2 * ev
+}
+
+// split gets the value of splitting a pair, playing each hand on as well as
+// the rules allow.
+func (c *calculator) split(value int) float64 {
+	ev := 0.0
+	for second := 1; second <= 10; second++ {
+		t := total{}.add(value).add(second)
+		if value == 1 && !c.rules.HitSplitAces {
+			ev += c.chances[second] * c.stand(t.score())
+			continue
+		}
+		best := c.standOrHit(t)
+		if c.rules.DoubleAfterSplit && c.rules.Double.Allows(twoCardHand(value, second)) {
+			if double := c.double(t); double > best {
+				best = double
+			}
+		}
+		ev += c.chances[second] * best
+	}
+	return 2 * ev
+}
+
+//
+// Dealer outcomes
+//
+
+// Indexes of outcomes beyond the dealer's standing scores of 17 to 21.
+const (
+	dealerBust      = 22
+	dealerBlackjack = 23
+)
+
+// outcomes are the chances of the dealer finishing on each score from 17 to 21,
+// going bust, or having blackjack.
+type outcomes [24]float64
+
+// dealerOutcomes works out how the dealer will finish from an up card. If the
+// dealer peeks, the chances are on the condition they don't have blackjack.
+func (c *calculator) dealerOutcomes(upValue int, peeks bool) outcomes {
+	result := outcomes{}
+	memo := map[total]outcomes{}
+	excluded := 0.0
+	for hole := 1; hole <= 10; hole++ {
+		chance := c.chances[hole]
+		t := total{}.add(upValue).add(hole)
+		if t.score() == 21 {
+			if peeks {
+				excluded += chance
+			} else {
+				result[dealerBlackjack] += chance
+			}
+			continue
+		}
+		for i, p := range c.dealerFrom(t, memo) {
+			result[i] += chance * p
+		}
+	}
+	if excluded > 0 && excluded < 1 {
+		for i := range result {
+			result[i] /= 1 - excluded
+		}
+	}
+	return result
+}
+
+// dealerFrom works out how the dealer will finish from a total, hitting until
+// they have to stand.
+func (c *calculator) dealerFrom(t total, memo map[total]outcomes) outcomes {
+	if result, ok := memo[t]; ok {
+		return result
+	}
+	result := outcomes{}
+	score := t.score()
+	switch {
+	case score > 21:
+		result[dealerBust] = 1
+	case score > 17 || score == 17 && !(t.soft() && c.rules.HitSoft17):
+		result[score] = 1
+	default:
+		for value := 1; value <= 10; value++ {
+			for i, p := range c.dealerFrom(t.add(value), memo) {
+				result[i] += c.chances[value] * p
+			}
+		}
+	}
+	memo[t] = result
+	return result
+}
+
+//
+// Totals
+//
+
+// total is a hand's value reduced to what matters for playing it: its hard
+// total, counting aces as 1, and whether it has an ace.
+type total struct {
+	hard int
+	ace  bool
+}
+
+// add gets the total after drawing a card value.
+func (t total) add(value int) total {
+	return total{t.hard + value, t.ace || value == 1}
+}
+
+// soft sees if an ace in the total can count as 11.
+func (t total) soft() bool {
+	return t.ace && t.hard+10 <= 21
+}
+
+// score gets the best score for the total.
+func (t total) score() int {
+	if t.soft() {
+		return t.hard + 10
+	}
+	return t.hard
+}
+
+// twoCardHand makes a hand from two card values, e.g. to check the double
+// rules.
+func twoCardHand(first, second int) *cards.Hand {
+	return &cards.Hand{Cards: []*cards.Card{
+		cards.NewCard(rankOf(first), cards.Spades),
+		cards.NewCard(rankOf(second), cards.Spades),
+	}}
+}
+
+//
+// Composition
+//
+
+// composition is the count of cards of each value, indexed 1 (ace) to 10.
+type composition [11]float64
+
+// newComposition counts the cards in the shoe the rules call for, less cards
+// that have been seen.
+func newComposition(rules game.Rules, seen []*cards.Card) composition {
+	decks := rules.Decks
+	if decks < 1 {
+		decks = 1
+	}
+	counts := composition{}
+	for _, rank := range cards.Ranks {
+		counts[cards.RankValues[rank]] += float64(4 * decks)
+	}
+	for _, card := range seen {
+		if card == nil {
+			continue
+		}
+		if value := card.Values()[0]; counts[value] > 0 {
+			counts[value]--
+		}
+	}
+	return counts
+}
+
+// rankOf gets a rank with a card value.
+func rankOf(value int) cards.Rank {
+	if value == 1 {
+		return cards.Ace
+	}
+	if value >= 10 {
+		return cards.Ten
+	}
+	return cards.Rank('0' + value)
+}
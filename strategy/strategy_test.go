@@ -0,0 +1,186 @@
+package strategy
+
+import (
+	"testing"
+
+	"github.com/hughgrigg/blackjack/cards"
+	"github.com/hughgrigg/blackjack/game"
+	"github.com/stretchr/testify/assert"
+)
+
+// Make a hand of face up cards.
+func hand(ranks ...cards.Rank) *cards.Hand {
+	h := &cards.Hand{}
+	for _, rank := range ranks {
+		h.Hit(cards.NewCard(rank, cards.Spades))
+	}
+	return h
+}
+
+// Make a face up card.
+func card(rank cards.Rank) *cards.Card {
+	return cards.NewCard(rank, cards.Hearts)
+}
+
+// Get the advice for a hand as the first move of a seat's only bet.
+func advise(h *cards.Hand, up *cards.Card, rules game.Rules) Action {
+	return Advise(h, up, rules, FirstOptions(h, rules))
+}
+
+// Evaluate a hand as the first move of a seat's only bet.
+func evaluate(h *cards.Hand, up *cards.Card, rules game.Rules) map[Action]float64 {
+	return Evaluate(h, up, rules, FirstOptions(h, rules))
+}
+
+// Six decks, dealer stands on soft 17, double after split, no surrender.
+func shoeRules() game.Rules {
+	rules := game.DefaultRules()
+	rules.Decks = 6
+	rules.HitSoft17 = false
+	return rules
+}
+
+//
+// Basic strategy
+//
+
+// Should give the well known basic strategy plays.
+func TestAdvise(t *testing.T) {
+	rules := shoeRules()
+	for _, play := range []struct {
+		hand   *cards.Hand
+		up     cards.Rank
+		advice Action
+	}{
+		{hand(cards.Ten, cards.Six), cards.Ten, Hit},
+		{hand(cards.Ten, cards.Two), cards.Four, Stand},
+		{hand(cards.Ten, cards.Two), cards.Two, Hit},
+		{hand(cards.Nine, cards.Seven), cards.Six, Stand},
+		{hand(cards.Six, cards.Five), cards.Six, Double},
+		{hand(cards.Five, cards.Four), cards.Three, Double},
+		{hand(cards.Five, cards.Four), cards.Seven, Hit},
+		{hand(cards.Eight, cards.Eight), cards.Ten, Split},
+		{hand(cards.Ace, cards.Ace), cards.Six, Split},
+		{hand(cards.King, cards.Queen), cards.Six, Stand},
+		{hand(cards.Nine, cards.Nine), cards.Seven, Stand},
+		{hand(cards.Nine, cards.Nine), cards.Eight, Split},
+		{hand(cards.Five, cards.Five), cards.Nine, Double},
+		{hand(cards.Ace, cards.Seven), cards.Nine, Hit},
+		{hand(cards.Ace, cards.Seven), cards.Six, Double},
+		{hand(cards.Ace, cards.Seven), cards.Seven, Stand},
+		{hand(cards.Ace, cards.Two), cards.Two, Hit},
+		{hand(cards.Ten, cards.Three, cards.Three), cards.Ten, Hit},
+		{hand(cards.Ace, cards.King), cards.Ten, Stand},
+	} {
+		assert.Equal(
+			t,
+			play.advice,
+			advise(play.hand, card(play.up), rules),
+			"%s against %s",
+			play.hand.Render(),
+			string(play.up),
+		)
+	}
+}
+
+// The advice should change with the dealer's soft 17 rule.
+func TestAdvise_HitSoft17(t *testing.T) {
+	rules := shoeRules()
+	assert.Equal(t, Hit, advise(hand(cards.Six, cards.Five), card(cards.Ace), rules))
+
+	rules.HitSoft17 = true
+	assert.Equal(t, Double, advise(hand(cards.Six, cards.Five), card(cards.Ace), rules))
+}
+
+// The advice should change without a hole card, as doubles are lost to a
+// dealer blackjack.
+func TestAdvise_NoHoleCard(t *testing.T) {
+	rules := shoeRules()
+	assert.Equal(t, Double, advise(hand(cards.Six, cards.Five), card(cards.Ten), rules))
+
+	rules.HoleCard = game.EuropeanNoHoleCard
+	assert.Equal(t, Hit, advise(hand(cards.Six, cards.Five), card(cards.Ten), rules))
+}
+
+// Surrender should be advised for the worst hands when it is allowed.
+func TestAdvise_Surrender(t *testing.T) {
+	rules := shoeRules()
+	rules.Surrender = game.SurrenderLate
+
+	assert.Equal(t, Surrender, advise(hand(cards.Ten, cards.Six), card(cards.Ten), rules))
+	assert.Equal(t, Surrender, advise(hand(cards.Nine, cards.Six), card(cards.Ten), rules))
+	assert.Equal(t, Hit, advise(hand(cards.Ten, cards.Four), card(cards.Ten), rules))
+	assert.Equal(t, Split, advise(hand(cards.Eight, cards.Eight), card(cards.Nine), rules))
+}
+
+// Doubling should not be advised where the rules don't allow it.
+func TestAdvise_DoubleRestricted(t *testing.T) {
+	rules := shoeRules()
+	rules.Double = game.DoubleTenToEleven
+
+	assert.Equal(t, Hit, advise(hand(cards.Five, cards.Four), card(cards.Three), rules))
+	assert.Equal(t, Stand, advise(hand(cards.Ace, cards.Seven), card(cards.Six), rules))
+}
+
+// Splitting should not be advised where the rules don't allow it.
+func TestAdvise_NoSplitting(t *testing.T) {
+	rules := shoeRules()
+	rules.MaxSplits = 0
+
+	evs := evaluate(hand(cards.Eight, cards.Eight), card(cards.Ten), rules)
+	assert.NotContains(t, evs, Split)
+	assert.Equal(t, Hit, advise(hand(cards.Eight, cards.Eight), card(cards.Ten), rules))
+}
+
+// Split hands should only be doubled with double after split, and never
+// surrendered.
+func TestAdvise_DoubleAfterSplit(t *testing.T) {
+	for _, das := range []bool{true, false} {
+		rules := game.DefaultRules()
+		rules.DoubleAfterSplit = das
+		rules.Surrender = game.SurrenderLate
+		engine := game.NewEngine(&game.Board{}, rules)
+		deck := engine.Board.Deck
+		deck.Cards[51] = cards.NewCard(cards.Six, cards.Clubs)    // dealer 1
+		deck.Cards[50] = cards.NewCard(cards.Eight, cards.Spades) // player 1
+		deck.Cards[49] = cards.NewCard(cards.Seven, cards.Clubs)  // dealer 2
+		deck.Cards[48] = cards.NewCard(cards.Eight, cards.Hearts) // player 2
+		deck.Cards[47] = cards.NewCard(cards.Three, cards.Hearts) // split hand 1
+		deck.Cards[46] = cards.NewCard(cards.Two, cards.Hearts)   // split hand 2
+		assert.NoError(t, engine.Execute(0, game.Command{Type: game.CommandDeal}))
+		assert.NoError(t, engine.Execute(0, game.Command{Type: game.CommandSplit}))
+
+		board := engine.Board
+		options := OptionsFor(board.Player, rules)
+		assert.Equal(t, Options{Double: das}, options)
+		split := board.Player.ActiveBet().Hand
+		assert.Equal(t, 11, split.Score())
+		evs := Evaluate(split, board.Dealer.UpCard(), rules, options)
+		_, doubles := evs[Double]
+		assert.Equal(t, das, doubles)
+		assert.Equal(t, das, Best(evs) == Double)
+	}
+}
+
+// Blackjack should be valued at the payout.
+func TestEvaluate_Blackjack(t *testing.T) {
+	evs := evaluate(hand(cards.Ace, cards.King), card(cards.Six), shoeRules())
+	assert.Equal(t, map[Action]float64{Stand: 1.5}, evs)
+}
+
+// Standing on 20 against a 6 should be well ahead.
+func TestEvaluate_Stand(t *testing.T) {
+	evs := evaluate(hand(cards.King, cards.Queen), card(cards.Six), shoeRules())
+	assert.InDelta(t, 0.7, evs[Stand], 0.05)
+	assert.True(t, evs[Stand] > evs[Hit])
+	assert.True(t, evs[Stand] > evs[Split])
+}
+
+// Actions should be ranked by value, with simpler plays first on ties.
+func TestRank(t *testing.T) {
+	assert.Equal(
+		t,
+		[]Action{Double, Stand, Hit, Surrender},
+		Rank(map[Action]float64{Hit: 0.1, Stand: 0.1, Double: 0.2, Surrender: -0.5}),
+	)
+}
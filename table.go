@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/gizak/termui"
+	"github.com/hughgrigg/blackjack/game"
+	"github.com/hughgrigg/blackjack/table"
+	"github.com/hughgrigg/blackjack/ui"
+)
+
+// serveTable hosts a table on the network for players to join from their own
+// terminals.
+func serveTable(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	address := flags.String("address", ":7777", "address to listen on")
+	seats := flags.Int("players", 2, "number of seats for players to join")
+	bots := flags.Int("bots", 0, "number of seats played by bots")
+	play := flags.String("bot-play", "dealer", "how bots play: dealer, basic or exec:COMMAND")
+	rules := ruleFlags(flags)
+	flags.Parse(args)
+
+	if *seats < 1 || *bots < 0 {
+		fail(fmt.Errorf("need at least one player and no fewer than zero bots"))
+	}
+	if *seats+*bots > table.MaxSeats {
+		fail(fmt.Errorf("a table can have at most %d seats", table.MaxSeats))
+	}
+	houseRules, err := rules(game.DefaultRules())
+	if err != nil {
+		fail(err)
+	}
+	bot, err := controller(*play, os.Stderr)
+	if err != nil {
+		fail(err)
+	}
+
+	board := &game.Board{}
+	for i := 1; i <= *seats; i++ {
+		board.Players = append(board.Players, game.NewPlayer(
+			fmt.Sprintf("Player %d", i), 100, nil,
+		))
+	}
+	for i := 1; i <= *bots; i++ {
+		board.Players = append(board.Players, game.NewPlayer(
+			fmt.Sprintf("Bot %d", i), 100, bot,
+		))
+	}
+
+	listener, err := net.Listen("tcp", *address)
+	if err != nil {
+		fail(err)
+	}
+	fmt.Printf("Hosting a table on %s\n", listener.Addr())
+	err = table.NewServer(game.NewEngine(board, houseRules)).Serve(listener)
+	closeBot(bot)
+	fail(err)
+}
+
+// joinTable takes a seat at a table hosted on the network, playing it in the
+// display.
+func joinTable(args []string) {
+	flags := flag.NewFlagSet("join", flag.ExitOnError)
+	name := flags.String("name", "", "name to sit at the table with")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: blackjack join [-name NAME] HOST:PORT")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	client, err := table.Dial(flags.Arg(0), *name)
+	if err != nil {
+		fail(fmt.Errorf("can't join the table at %s: %s", flags.Arg(0), err))
+	}
+	defer client.Close()
+
+	if err := termui.Init(); err != nil {
+		panic(err)
+	}
+	// Render whenever the table sends something, rather than polling.
+	display := &ui.Display{}
+	display.Init()
+	display.AttachRemote(client)
+	display.Render()
+	go func() {
+		for range client.Changed() {
+			display.Render()
+		}
+	}()
+	termui.Loop()
+	termui.Close()
+}
+
+// serveAPI drives tables over HTTP with JSON, creating them on request.
+func serveAPI(args []string) {
+	flags := flag.NewFlagSet("api", flag.ExitOnError)
+	address := flags.String("address", ":8080", "address to listen on")
+	flags.Parse(args)
+
+	fmt.Printf("Serving the table API on %s\n", *address)
+	fail(http.ListenAndServe(*address, table.NewAPI()))
+}
@@ -0,0 +1,161 @@
+package table
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/hughgrigg/blackjack/game"
+)
+
+//
+// Client
+//
+
+// Client is a seat at a table hosted by a server, keeping the latest state the
+// server has sent it.
+type Client struct {
+	conn    net.Conn
+	encoder *json.Encoder
+	mutex   sync.Mutex
+	seat    int
+	state   Message
+	err     error
+	// Signalled whenever a message comes from the server.
+	changed chan struct{}
+}
+
+// Dial connects to a table's server and joins it under a name, waiting until
+// a seat has been taken.
+func Dial(address string, name string) (*Client, error) {
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{
+		conn:    conn,
+		encoder: json.NewEncoder(conn),
+		seat:    -1,
+		changed: make(chan struct{}, 1),
+	}
+	if err := c.encoder.Encode(Message{Type: MessageJoin, Name: name}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	// Wait for the seat, and the state of the table once it's been taken.
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		message := Message{}
+		if err := json.Unmarshal(scanner.Bytes(), &message); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		switch message.Type {
+		case MessageError:
+			conn.Close()
+			return nil, errors.New(message.Error)
+		case MessageJoined:
+			c.seat = message.Seat
+		case MessageState:
+			c.state = message
+			if c.seat != -1 {
+				go c.read(scanner)
+				return c, nil
+			}
+		}
+	}
+	conn.Close()
+	if scanner.Err() != nil {
+		return nil, scanner.Err()
+	}
+	return nil, errors.New("the table hung up")
+}
+
+// Seat gets the seat the client has taken.
+func (c *Client) Seat() int {
+	return c.seat
+}
+
+// State gets the latest state the server has sent.
+func (c *Client) State() Message {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.state
+}
+
+// Err gets why the server refused the last message sent, or why the
+// connection was lost.
+func (c *Client) Err() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.err
+}
+
+// Changed is signalled whenever a message comes from the server, e.g. so that
+// the display can be rendered again.
+func (c *Client) Changed() <-chan struct{} {
+	return c.changed
+}
+
+// Act sends the key of an action for the client's seat to take.
+func (c *Client) Act(key string) error {
+	return c.send(Message{Type: MessageAction, Key: key})
+}
+
+// Command sends a typed command for the client's seat to carry out.
+func (c *Client) Command(command game.Command) error {
+	return c.send(Message{Type: MessageCommand, Command: &command})
+}
+
+// Close leaves the table.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// send sends a message to the server, clearing the last error.
+func (c *Client) send(message Message) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.err = nil
+	return c.encoder.Encode(message)
+}
+
+// read keeps the latest state and error from the server until the connection
+// is lost.
+func (c *Client) read(scanner *bufio.Scanner) {
+	for scanner.Scan() {
+		message := Message{}
+		if err := json.Unmarshal(scanner.Bytes(), &message); err != nil {
+			continue
+		}
+		c.mutex.Lock()
+		switch message.Type {
+		case MessageState:
+			c.state = message
+		case MessageError:
+			c.err = errors.New(message.Error)
+		}
+		c.mutex.Unlock()
+		c.signal()
+	}
+	c.mutex.Lock()
+	c.err = fmt.Errorf("lost the connection to the table: %v", scanner.Err())
+	if scanner.Err() == nil {
+		c.err = errors.New("the table hung up")
+	}
+	c.mutex.Unlock()
+	c.signal()
+}
+
+// signal says that a message has come, without waiting for anyone to notice.
+func (c *Client) signal() {
+	select {
+	case c.changed <- struct{}{}:
+	default:
+	}
+}
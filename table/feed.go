@@ -0,0 +1,121 @@
+package table
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/hughgrigg/blackjack/game"
+)
+
+//
+// Feed
+//
+
+// Feed passes the events on a board to everyone watching it as they happen,
+// e.g. spectators over a WebSocket. It subscribes to the board.
+type Feed struct {
+	mutex    sync.Mutex
+	watchers map[chan FeedMessage]bool
+}
+
+// FeedMessage is a message pushed to those watching a table: first the state
+// of the table when they started watching, then each event on it in turn, e.g.
+//
+//	{"kind":"table","table":{"id":"1","state":{...},"log":[...]}}
+//	{"kind":"card dealt","event":{"seat":0,"hand":0,"card":"A♤"}}
+//
+// Folding the events over the table's state keeps it up to date.
+type FeedMessage struct {
+	// The kind of event, or "table" for the table's state.
+	Kind  string      `json:"kind"`
+	Event game.Event  `json:"event,omitempty"`
+	Table *TableState `json:"table,omitempty"`
+}
+
+// The number of events that can wait to be pushed to a watcher before it is
+// dropped for not keeping up.
+const feedBacklog = 256
+
+// Notify pushes an event to everyone watching.
+func (f *Feed) Notify(event game.Event) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for watcher := range f.watchers {
+		select {
+		case watcher <- FeedMessage{Kind: event.Kind(), Event: event}:
+		default:
+			delete(f.watchers, watcher)
+			close(watcher)
+		}
+	}
+}
+
+// Watch starts watching the feed, getting the events pushed to it from now on.
+func (f *Feed) Watch() chan FeedMessage {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.watchers == nil {
+		f.watchers = map[chan FeedMessage]bool{}
+	}
+	watcher := make(chan FeedMessage, feedBacklog)
+	f.watchers[watcher] = true
+	return watcher
+}
+
+// Unwatch stops pushing events to a watcher, closing its channel.
+func (f *Feed) Unwatch(watcher chan FeedMessage) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.watchers[watcher] {
+		delete(f.watchers, watcher)
+		close(watcher)
+	}
+}
+
+// Close stops pushing events to everyone watching.
+func (f *Feed) Close() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for watcher := range f.watchers {
+		delete(f.watchers, watcher)
+		close(watcher)
+	}
+}
+
+// upgrader makes WebSocket connections from HTTP requests.
+var upgrader = websocket.Upgrader{}
+
+// push sends a watcher's messages over a WebSocket until either the watcher is
+// dropped or the other end goes away.
+func push(w http.ResponseWriter, r *http.Request, feed *Feed, first FeedMessage, watcher chan FeedMessage) {
+	defer feed.Unwatch(watcher)
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	// Nothing is expected from the other end, but reading notices it going.
+	go func() {
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				feed.Unwatch(watcher)
+				return
+			}
+		}
+	}()
+
+	if err := conn.WriteJSON(first); err != nil {
+		return
+	}
+	for message := range watcher {
+		if err := conn.WriteJSON(message); err != nil {
+			return
+		}
+	}
+	conn.WriteMessage(
+		websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseGoingAway, ""),
+	)
+}
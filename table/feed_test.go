@@ -0,0 +1,142 @@
+package table
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/hughgrigg/blackjack/cards"
+	"github.com/hughgrigg/blackjack/game"
+	"github.com/stretchr/testify/assert"
+)
+
+//
+// Feed
+//
+
+// Everyone watching a feed should be given each event, in order.
+func TestFeed(t *testing.T) {
+	feed := &Feed{}
+	board := &game.Board{Deck: &cards.Deck{Random: cards.NewSeededRandomiser(5)}}
+	engine := game.NewEngine(board, game.DefaultRules())
+	board.Subscribers = append(board.Subscribers, feed)
+	start := engine.Snapshot()
+	first, second := feed.Watch(), feed.Watch()
+
+	engine.Execute(0, game.Command{Type: game.CommandBet, Amount: 10})
+	engine.Execute(0, game.Command{Type: game.CommandDeal})
+	feed.Unwatch(second)
+	engine.Execute(0, game.Command{Type: game.CommandStand})
+	feed.Close()
+
+	events := []game.Event{}
+	for message := range first {
+		assert.Equal(t, message.Event.Kind(), message.Kind)
+		events = append(events, message.Event)
+	}
+	folded := game.Fold(start, events...)
+	assert.Equal(t, engine.Snapshot().Seats[0].Balance, folded.Seats[0].Balance)
+	assert.Equal(t, engine.Snapshot().Dealer, folded.Dealer)
+	assert.Equal(t, "conclusion", folded.Stage)
+
+	watched := 0
+	for range second {
+		watched++
+	}
+	assert.True(t, watched < len(events))
+}
+
+// Watchers that fall too far behind should be dropped rather than hold up the
+// board.
+func TestFeed_Slow(t *testing.T) {
+	feed := &Feed{}
+	watcher := feed.Watch()
+	for i := 0; i < feedBacklog+1; i++ {
+		feed.Notify(game.DealerRevealed{Card: "A♤"})
+	}
+	count := 0
+	for range watcher {
+		count++
+	}
+	assert.Equal(t, feedBacklog, count)
+}
+
+// Watching a table over a WebSocket should push its state, then each event on
+// it as it happens.
+func TestAPI_Events(t *testing.T) {
+	server := httptest.NewServer(NewAPI())
+	defer server.Close()
+	post := func(path, body string) {
+		response, err := http.Post(server.URL+path, "application/json", strings.NewReader(body))
+		assert.NoError(t, err)
+		response.Body.Close()
+	}
+	post("/tables", `{"players": ["Ann"], "seed": 1}`)
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/tables/1/events"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	assert.NoError(t, err)
+	defer conn.Close()
+	receive := func() map[string]json.RawMessage {
+		conn.SetReadDeadline(time.Now().Add(time.Second * 2))
+		message := map[string]json.RawMessage{}
+		assert.NoError(t, conn.ReadJSON(&message))
+		return message
+	}
+
+	table := TableState{}
+	first := receive()
+	assert.Equal(t, `"table"`, string(first["kind"]))
+	assert.NoError(t, json.Unmarshal(first["table"], &table))
+	assert.Equal(t, "Ann", table.State.Seats[0].Name)
+
+	post("/tables/1/seats/0/bets", `{"amount": 20}`)
+	bet := receive()
+	assert.Equal(t, `"bet placed"`, string(bet["kind"]))
+	assert.JSONEq(t, `{"seat":0,"name":"Ann","amount":20,"balance":80}`, string(bet["event"]))
+
+	post("/tables/1/seats/0/actions", `{"key": "d"}`)
+	kinds := []string{}
+	for len(kinds) < 5 {
+		kinds = append(kinds, string(receive()["kind"]))
+	}
+	assert.Equal(t, []string{
+		`"card dealt"`, `"card dealt"`, `"card dealt"`, `"card dealt"`, `"stage changed"`,
+	}, kinds)
+
+	_, _, err = websocket.DefaultDialer.Dial(
+		"ws"+strings.TrimPrefix(server.URL, "http")+"/tables/2/events",
+		nil,
+	)
+	assert.Equal(t, websocket.ErrBadHandshake, err)
+}
+
+// Closing a table should stop pushing its events.
+func TestAPI_Events_Delete(t *testing.T) {
+	server := httptest.NewServer(NewAPI())
+	defer server.Close()
+	response, err := http.Post(server.URL+"/tables", "application/json", nil)
+	assert.NoError(t, err)
+	response.Body.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial(
+		"ws"+strings.TrimPrefix(server.URL, "http")+"/tables/1/events",
+		nil,
+	)
+	assert.NoError(t, err)
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(time.Second * 2))
+	_, _, err = conn.ReadMessage()
+	assert.NoError(t, err)
+
+	request, _ := http.NewRequest("DELETE", server.URL+"/tables/1", nil)
+	response, err = http.DefaultClient.Do(request)
+	assert.NoError(t, err)
+	response.Body.Close()
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway))
+}
@@ -0,0 +1,348 @@
+package table
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hughgrigg/blackjack/cards"
+	"github.com/hughgrigg/blackjack/game"
+	"github.com/hughgrigg/blackjack/util"
+)
+
+//
+// HTTP API
+//
+
+// API drives tables over HTTP with JSON, e.g. for a web front end or tests. Each
+// table is a headless board of its own, created on request:
+//
+//	POST   /tables                           create a table
+//	GET    /tables/{id}                      get the state of a table
+//	DELETE /tables/{id}                      close a table
+//	POST   /tables/{id}/seats/{seat}/bets    place a bet or side bet
+//	GET    /tables/{id}/seats/{seat}/actions list the actions a seat can take
+//	POST   /tables/{id}/seats/{seat}/actions take an action by its key
+//	GET    /tables/{id}/events               watch the table over a WebSocket
+//
+// Requests that are refused get an error status with an APIError as the body.
+type API struct {
+	mutex  sync.Mutex
+	tables map[string]*hostedTable
+	last   int
+}
+
+// hostedTable is a table created through the API, with a feed of its events for
+// those watching it.
+type hostedTable struct {
+	engine *game.Engine
+	feed   *Feed
+}
+
+// NewAPI makes an API with no tables.
+func NewAPI() *API {
+	return &API{tables: map[string]*hostedTable{}}
+}
+
+// NewTable is the body of a request to create a table.
+type NewTable struct {
+	// The names of the seats to play, one seat each. A single seat is made if
+	// none are given.
+	Players []string `json:"players"`
+	// The number of seats played by bots that play like the dealer. A table has
+	// at most MaxSeats seats, counting the bots.
+	Bots int `json:"bots"`
+	// What each seat starts with, or 100 if not given.
+	Balance float64 `json:"balance"`
+	// The seed to shuffle the shoe with, for tables that play out the same way
+	// each time. The time is used if not given.
+	Seed int64 `json:"seed"`
+	// Any house rules that differ from the defaults.
+	Rules json.RawMessage `json:"rules"`
+}
+
+// TableState is a table as it is now.
+type TableState struct {
+	ID    string        `json:"id"`
+	State game.Snapshot `json:"state"`
+	// The game log, oldest first, without formatting.
+	Log []string `json:"log"`
+}
+
+// BetRequest is the body of a request to place a bet, or a side bet if one is
+// named.
+type BetRequest struct {
+	Amount  float64          `json:"amount"`
+	SideBet game.SideBetKind `json:"side_bet"`
+}
+
+// ActionRequest is the body of a request to take an action.
+type ActionRequest struct {
+	Key string `json:"key"`
+}
+
+// APIError says why a request was refused.
+type APIError struct {
+	Status int `json:"status"`
+	// A short name for the kind of error, e.g. "not_your_turn".
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error describes the refusal.
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// Errors from the API itself, rather than the board.
+var (
+	ErrNoSuchTable = errors.New("there is no such table")
+	ErrNotFound    = errors.New("there is nothing here")
+	ErrBadRequest  = errors.New("the request body isn't valid")
+	ErrMethod      = errors.New("that method isn't allowed here")
+)
+
+// ServeHTTP routes a request to the table it's for.
+func (a *API) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	// Watching a table goes on for as long as the WebSocket is open, so only
+	// holds up the other requests while it starts.
+	if len(parts) == 3 && parts[0] == "tables" && parts[2] == "events" {
+		a.watch(w, r, parts[1])
+		return
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if parts[0] != "tables" {
+		a.fail(w, ErrNotFound)
+		return
+	}
+	if len(parts) == 1 {
+		if r.Method != http.MethodPost {
+			a.fail(w, ErrMethod)
+			return
+		}
+		a.create(w, r)
+		return
+	}
+	id := parts[1]
+	hosted, ok := a.tables[id]
+	if !ok {
+		a.fail(w, ErrNoSuchTable)
+		return
+	}
+	engine := hosted.engine
+	if len(parts) == 2 {
+		switch r.Method {
+		case http.MethodGet:
+			a.respond(w, http.StatusOK, a.state(id))
+		case http.MethodDelete:
+			hosted.feed.Close()
+			delete(a.tables, id)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			a.fail(w, ErrMethod)
+		}
+		return
+	}
+	if len(parts) != 5 || parts[2] != "seats" {
+		a.fail(w, ErrNotFound)
+		return
+	}
+	seat, err := strconv.Atoi(parts[3])
+	if err != nil || seat < 0 || seat >= len(engine.Board.Players) {
+		a.fail(w, game.ErrNoSuchSeat)
+		return
+	}
+	switch {
+	case parts[4] == "bets" && r.Method == http.MethodPost:
+		a.bet(w, r, id, seat)
+	case parts[4] == "actions" && r.Method == http.MethodGet:
+		a.respond(w, http.StatusOK, actionsOf(engine.Actions(seat)))
+	case parts[4] == "actions" && r.Method == http.MethodPost:
+		a.act(w, r, id, seat)
+	case parts[4] == "bets" || parts[4] == "actions":
+		a.fail(w, ErrMethod)
+	default:
+		a.fail(w, ErrNotFound)
+	}
+}
+
+// create makes a new table from the request, giving its state.
+func (a *API) create(w http.ResponseWriter, r *http.Request) {
+	request := NewTable{}
+	if !a.decode(w, r, &request) {
+		return
+	}
+	rules := game.DefaultRules()
+	if len(request.Rules) > 0 {
+		if err := json.Unmarshal(request.Rules, &rules); err != nil {
+			a.fail(w, ErrBadRequest)
+			return
+		}
+	}
+	if rules.Validate() != nil || request.Bots < 0 || request.Balance < 0 ||
+		len(request.Players) > MaxSeats || request.Bots > MaxSeats-len(request.Players) {
+		a.fail(w, ErrBadRequest)
+		return
+	}
+	balance := request.Balance
+	if balance == 0 {
+		balance = 100
+	}
+
+	board := &game.Board{}
+	if request.Seed != 0 {
+		board.Deck = &cards.Deck{
+			Decks:  rules.Decks,
+			Random: cards.NewSeededRandomiser(request.Seed),
+		}
+	}
+	for _, name := range request.Players {
+		board.Players = append(board.Players, game.NewPlayer(name, balance, nil))
+	}
+	for i := 1; i <= request.Bots; i++ {
+		board.Players = append(board.Players, game.NewPlayer(
+			"Bot "+strconv.Itoa(i), balance, game.MimicDealer{},
+		))
+	}
+	if len(board.Players) == 0 {
+		board.Players = []*game.Player{game.NewPlayer("Player", balance, nil)}
+	}
+
+	feed := &Feed{}
+	engine := game.NewEngine(board, rules)
+	board.Subscribers = append(board.Subscribers, feed)
+	a.last++
+	id := strconv.Itoa(a.last)
+	a.tables[id] = &hostedTable{engine, feed}
+	a.respond(w, http.StatusCreated, a.state(id))
+}
+
+// bet places a bet or side bet for a seat, giving the table's state.
+func (a *API) bet(w http.ResponseWriter, r *http.Request, id string, seat int) {
+	request := BetRequest{}
+	if !a.decode(w, r, &request) {
+		return
+	}
+	command := game.Command{Type: game.CommandBet, Amount: request.Amount}
+	if request.SideBet != "" {
+		command = game.Command{
+			Type:    game.CommandSideBet,
+			Amount:  request.Amount,
+			SideBet: request.SideBet,
+		}
+	}
+	if err := a.tables[id].engine.Execute(seat, command); err != nil {
+		a.fail(w, err)
+		return
+	}
+	a.respond(w, http.StatusOK, a.state(id))
+}
+
+// act takes an action by its key for a seat, giving the table's state.
+func (a *API) act(w http.ResponseWriter, r *http.Request, id string, seat int) {
+	request := ActionRequest{}
+	if !a.decode(w, r, &request) {
+		return
+	}
+	if err := a.tables[id].engine.Act(seat, request.Key); err != nil {
+		a.fail(w, err)
+		return
+	}
+	a.respond(w, http.StatusOK, a.state(id))
+}
+
+// watch pushes the events on a table over a WebSocket as they happen, after
+// the state of the table when watching starts.
+func (a *API) watch(w http.ResponseWriter, r *http.Request, id string) {
+	a.mutex.Lock()
+	hosted, ok := a.tables[id]
+	if !ok {
+		a.fail(w, ErrNoSuchTable)
+		a.mutex.Unlock()
+		return
+	}
+	if r.Method != http.MethodGet {
+		a.fail(w, ErrMethod)
+		a.mutex.Unlock()
+		return
+	}
+	// Nothing happens on the table between taking its state and watching.
+	state := a.state(id)
+	watcher := hosted.feed.Watch()
+	a.mutex.Unlock()
+
+	push(w, r, hosted.feed, FeedMessage{Kind: "table", Table: &state}, watcher)
+}
+
+// state gets the state of a table.
+func (a *API) state(id string) TableState {
+	engine := a.tables[id].engine
+	state := TableState{ID: id, State: engine.Snapshot(), Log: []string{}}
+	for _, event := range engine.Board.Log.Events() {
+		state.Log = append(state.Log, util.StripFormatting(event))
+	}
+	return state
+}
+
+// decode reads a request's JSON body, refusing the request if it can't. An
+// empty body leaves everything as its default.
+func (a *API) decode(w http.ResponseWriter, r *http.Request, body interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(body); err != nil && err != io.EOF {
+		a.fail(w, ErrBadRequest)
+		return false
+	}
+	return true
+}
+
+// respond writes a JSON response with a status.
+func (a *API) respond(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// fail refuses a request, saying why.
+func (a *API) fail(w http.ResponseWriter, err error) {
+	refusal := apiError(err)
+	a.respond(w, refusal.Status, map[string]*APIError{"error": refusal})
+}
+
+// apiError gets the status and code for an error.
+func apiError(err error) *APIError {
+	status, code := http.StatusUnprocessableEntity, "refused"
+	switch err {
+	case ErrNoSuchTable:
+		status, code = http.StatusNotFound, "no_such_table"
+	case ErrNotFound:
+		status, code = http.StatusNotFound, "not_found"
+	case ErrBadRequest, game.ErrInvalidAmount:
+		status, code = http.StatusBadRequest, "bad_request"
+	case ErrMethod:
+		status, code = http.StatusMethodNotAllowed, "method_not_allowed"
+	case game.ErrNoSuchSeat:
+		status, code = http.StatusNotFound, "no_such_seat"
+	case game.ErrNotYourTurn:
+		status, code = http.StatusConflict, "not_your_turn"
+	case game.ErrNoSuchAction:
+		status, code = http.StatusConflict, "no_such_action"
+	case game.ErrCannotAfford:
+		code = "cannot_afford"
+	case game.ErrMinimumBet:
+		code = "minimum_bet"
+	case game.ErrOverInsured:
+		code = "over_insured"
+	case game.ErrNoSideBet:
+		code = "no_side_bet"
+	}
+	if _, ok := err.(*game.IllegalCommandError); ok {
+		status, code = http.StatusConflict, "illegal_command"
+	}
+	return &APIError{Status: status, Code: code, Message: err.Error()}
+}
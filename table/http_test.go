@@ -0,0 +1,138 @@
+package table
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/hughgrigg/blackjack/game"
+	"github.com/stretchr/testify/assert"
+)
+
+//
+// HTTP API
+//
+
+// Make a request of the API, decoding the JSON response into a body.
+func request(t *testing.T, api *API, method, path, body string, response interface{}) int {
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest(method, path, strings.NewReader(body)))
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), response))
+	return recorder.Code
+}
+
+// Should be able to create a table, bet and play a round through the API.
+func TestAPI(t *testing.T) {
+	api := NewAPI()
+	state := TableState{}
+	status := request(t, api, "POST", "/tables", `{
+		"players": ["Ann", "Bob"],
+		"bots": 1,
+		"seed": 3,
+		"rules": {"Decks": 6}
+	}`, &state)
+	assert.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, "1", state.ID)
+	assert.Equal(t, "betting", state.State.Stage)
+	assert.Len(t, state.State.Seats, 3)
+	assert.Equal(t, "Bot 1", state.State.Seats[2].Name)
+	assert.Equal(t, 6*52, state.State.Remaining)
+
+	status = request(t, api, "POST", "/tables/1/seats/1/bets", `{"amount": 20}`, &state)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, 20.0, state.State.Seats[1].Bets[0].Amount)
+	assert.Equal(t, 80.0, state.State.Seats[1].Balance)
+
+	actions := []Action{}
+	status = request(t, api, "GET", "/tables/1/seats/0/actions", "", &actions)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, actions, Action{"d", "Deal", game.CommandDeal})
+
+	status = request(t, api, "POST", "/tables/1/seats/0/actions", `{"key": "d"}`, &state)
+	assert.Equal(t, http.StatusOK, status)
+	assert.NotEqual(t, "betting", state.State.Stage)
+	assert.Len(t, state.State.Dealer.Cards, 2)
+	assert.NotEmpty(t, state.Log)
+
+	// Play the round out, standing on every hand.
+	for i := 0; i < 10 && state.State.Stage != "conclusion"; i++ {
+		key := "s"
+		if state.State.Stage == "insurance" {
+			key = "n"
+		}
+		path := "/tables/1/seats/" + strconv.Itoa(state.State.Turn) + "/actions"
+		request(t, api, "POST", path, `{"key": "`+key+`"}`, &state)
+	}
+	assert.Equal(t, "conclusion", state.State.Stage)
+
+	fetched := TableState{}
+	status = request(t, api, "GET", "/tables/1", "", &fetched)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, state, fetched)
+}
+
+// Requests that can't be carried out should be refused with an error saying
+// why.
+func TestAPI_Errors(t *testing.T) {
+	api := NewAPI()
+	request(t, api, "POST", "/tables", "", &TableState{})
+
+	for _, test := range []struct {
+		method, path, body string
+		status             int
+		code               string
+	}{
+		{"GET", "/chairs", "", http.StatusNotFound, "not_found"},
+		{"GET", "/tables", "", http.StatusMethodNotAllowed, "method_not_allowed"},
+		{"POST", "/tables", "{", http.StatusBadRequest, "bad_request"},
+		{"POST", "/tables", `{"rules": {"Decks": 0}}`, http.StatusBadRequest, "bad_request"},
+		{"POST", "/tables", `{"rules": {"Decks": 1000000000}}`, http.StatusBadRequest, "bad_request"},
+		{"POST", "/tables", `{"rules": {"BlackjackPayout": {"Win": 0, "Stake": 0}}}`, http.StatusBadRequest, "bad_request"},
+		{"POST", "/tables", `{"rules": {"MaxSplits": -1}}`, http.StatusBadRequest, "bad_request"},
+		{"POST", "/tables", `{"rules": {"Surrender": 7}}`, http.StatusBadRequest, "bad_request"},
+		{"POST", "/tables", `{"rules": {"SideBets": {"Paytables": {"21+3": {"flush": -5}}}}}`, http.StatusBadRequest, "bad_request"},
+		{"POST", "/tables", `{"bots": 2000000000}`, http.StatusBadRequest, "bad_request"},
+		{"POST", "/tables", `{"players": ["A", "B", "C", "D"], "bots": 4}`, http.StatusBadRequest, "bad_request"},
+		{"POST", "/tables", `{"players": ["A", "B", "C", "D", "E", "F", "G", "H"]}`, http.StatusBadRequest, "bad_request"},
+		{"GET", "/tables/2", "", http.StatusNotFound, "no_such_table"},
+		{"PUT", "/tables/1", "", http.StatusMethodNotAllowed, "method_not_allowed"},
+		{"GET", "/tables/1/seats/1/actions", "", http.StatusNotFound, "no_such_seat"},
+		{"GET", "/tables/1/seats/0/chips", "", http.StatusNotFound, "not_found"},
+		{"GET", "/tables/1/seats/0/bets", "", http.StatusMethodNotAllowed, "method_not_allowed"},
+		{"POST", "/tables/1/seats/0/bets", `{"amount": 500}`, http.StatusUnprocessableEntity, "cannot_afford"},
+		{"POST", "/tables/1/seats/0/bets", `{"amount": 0}`, http.StatusBadRequest, "bad_request"},
+		{"POST", "/tables/1/seats/0/bets", `{"amount": -5}`, http.StatusBadRequest, "bad_request"},
+		{"POST", "/tables/1/seats/0/bets", `{"amount": 5, "side_bet": "21+3"}`, http.StatusUnprocessableEntity, "no_side_bet"},
+		{"POST", "/tables/1/seats/0/actions", `{"key": "h"}`, http.StatusConflict, "no_such_action"},
+	} {
+		response := map[string]APIError{}
+		status := request(t, api, test.method, test.path, test.body, &response)
+		assert.Equal(t, test.status, status, test.path)
+		assert.Equal(t, test.status, response["error"].Status, test.path)
+		assert.Equal(t, test.code, response["error"].Code, test.path)
+		assert.NotEmpty(t, response["error"].Message, test.path)
+	}
+
+	// Betting is only allowed before the deal.
+	request(t, api, "POST", "/tables/1/seats/0/actions", `{"key": "d"}`, &TableState{})
+	response := map[string]APIError{}
+	status := request(t, api, "POST", "/tables/1/seats/0/bets", `{"amount": 10}`, &response)
+	assert.Equal(t, http.StatusConflict, status)
+	assert.Equal(t, "illegal_command", response["error"].Code)
+	assert.Contains(t, response["error"].Message, "can't bet during the")
+}
+
+// Closing a table should remove it.
+func TestAPI_Delete(t *testing.T) {
+	api := NewAPI()
+	request(t, api, "POST", "/tables", "", &TableState{})
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("DELETE", "/tables/1", nil))
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	response := map[string]APIError{}
+	assert.Equal(t, http.StatusNotFound, request(t, api, "GET", "/tables/1", "", &response))
+}
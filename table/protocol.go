@@ -0,0 +1,87 @@
+package table
+
+import (
+	"sort"
+
+	"github.com/hughgrigg/blackjack/game"
+)
+
+//
+// Protocol
+//
+
+// Message is a line of the table protocol. Each message is a JSON object on a
+// line of its own, sent either way over a TCP connection, e.g.
+//
+//	{"type":"join","name":"Ann"}
+//	{"type":"joined","seat":0}
+//	{"type":"action","key":"h"}
+//	{"type":"state","seat":0,"state":{...},"log":[...],"actions":[...]}
+//	{"type":"error","seat":0,"error":"it is not this seat's turn"}
+//
+// Only the fields a type of message uses are given.
+type Message struct {
+	Type MessageType `json:"type"`
+	// The seat the client has taken, or -1 if it is only watching.
+	Seat int `json:"seat"`
+	// The name to sit at the table with, for join messages.
+	Name string `json:"name,omitempty"`
+	// The key of the action to take, for action messages.
+	Key string `json:"key,omitempty"`
+	// The command to carry out, for command messages.
+	Command *game.Command `json:"command,omitempty"`
+	// The board as it is now, for state messages.
+	State *game.Snapshot `json:"state,omitempty"`
+	// The game log, oldest first, for state messages.
+	Log []string `json:"log,omitempty"`
+	// The actions the client's seat can take now, for state messages.
+	Actions []Action `json:"actions,omitempty"`
+	// Why the last message from the client was refused, for error messages.
+	Error string `json:"error,omitempty"`
+}
+
+// MessageType is the kind of a message, which says which of its fields are
+// used.
+type MessageType string
+
+// The messages clients send to the server.
+const (
+	// Join takes the first free seat at the table, under a name if one is
+	// given.
+	MessageJoin MessageType = "join"
+	// Action takes the action a key stands for, as if it were pressed at the
+	// keyboard.
+	MessageAction MessageType = "action"
+	// Command carries out a typed command, e.g. to bet an exact amount.
+	MessageCommand MessageType = "command"
+)
+
+// The messages the server sends to clients.
+const (
+	// Joined says which seat the client has taken.
+	MessageJoined MessageType = "joined"
+	// State is sent when a client connects and whenever the board changes.
+	MessageState MessageType = "state"
+	// Error refuses the client's last message.
+	MessageError MessageType = "error"
+)
+
+// Action is something a seat can do at the current stage of the game, with the
+// key that does it.
+type Action struct {
+	Key         string           `json:"key"`
+	Description string           `json:"description"`
+	Command     game.CommandType `json:"command"`
+}
+
+// actionsOf lists a set of actions in order of their keys.
+func actionsOf(set game.ActionSet) []Action {
+	actions := []Action{}
+	for key, action := range set {
+		actions = append(actions, Action{key, action.Description, action.Command})
+	}
+	sort.Slice(actions, func(i, j int) bool {
+		return actions[i].Key < actions[j].Key
+	})
+	return actions
+}
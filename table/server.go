@@ -0,0 +1,293 @@
+package table
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/hughgrigg/blackjack/game"
+)
+
+//
+// Server
+//
+
+// Server hosts a board for players on other machines, who each take a seat at
+// it over TCP. The server's board is the only one played on: clients send it
+// the keys they press and are sent its state whenever it changes.
+type Server struct {
+	engine  *game.Engine
+	mutex   sync.Mutex
+	clients map[*client]bool
+	// Seats whose players have left, which sit out their turns until someone
+	// else joins.
+	vacated map[int]bool
+}
+
+// Errors refusing a client's message.
+var (
+	ErrTableFull  = errors.New("every seat at the table is taken")
+	ErrSeated     = errors.New("already sitting at the table")
+	ErrNotSeated  = errors.New("join the table before playing")
+	ErrBadMessage = errors.New("not a message the table understands")
+)
+
+// The number of messages that can wait to be sent to a client before it is
+// dropped for not keeping up.
+const backlog = 64
+
+// MaxSeats is the most seats a hosted table can have, including those played
+// by bots.
+const MaxSeats = 7
+
+// client is a connection to the server, and the seat it has taken, if any.
+type client struct {
+	conn net.Conn
+	seat int
+	out  chan Message
+}
+
+// NewServer hosts an engine's board. The board's seats are taken by clients as
+// they join, apart from those played by bots.
+func NewServer(engine *game.Engine) *Server {
+	return &Server{
+		engine:  engine,
+		clients: map[*client]bool{},
+		vacated: map[int]bool{},
+	}
+}
+
+// Serve accepts connections on a listener until it is closed.
+func (s *Server) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go s.handle(conn)
+	}
+}
+
+// handle reads a client's messages until it disconnects, at which point its
+// seat is freed.
+func (s *Server) handle(conn net.Conn) {
+	c := &client{conn: conn, seat: -1, out: make(chan Message, backlog)}
+	go c.write()
+
+	s.mutex.Lock()
+	s.clients[c] = true
+	c.send(s.state(c))
+	s.mutex.Unlock()
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		message := Message{}
+		if err := json.Unmarshal(scanner.Bytes(), &message); err != nil {
+			s.mutex.Lock()
+			c.send(Message{Type: MessageError, Seat: c.seat, Error: ErrBadMessage.Error()})
+			s.mutex.Unlock()
+			continue
+		}
+		s.receive(c, message)
+	}
+
+	s.mutex.Lock()
+	delete(s.clients, c)
+	close(c.out)
+	if c.seat != -1 {
+		s.engine.Board.Log.Push(fmt.Sprintf(
+			"[%s leaves the table](fg-cyan)",
+			s.engine.Board.Players[c.seat].Name,
+		))
+		s.vacated[c.seat] = true
+		s.sitOut()
+		s.broadcast()
+	}
+	s.mutex.Unlock()
+	conn.Close()
+}
+
+// receive carries out a message from a client, telling every client about the
+// change or telling the client why it was refused.
+func (s *Server) receive(c *client, message Message) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var err error
+	switch message.Type {
+	case MessageJoin:
+		err = s.join(c, message.Name)
+	case MessageAction:
+		err = s.act(c, message.Key)
+	case MessageCommand:
+		err = s.command(c, message.Command)
+	default:
+		err = ErrBadMessage
+	}
+	if err != nil {
+		c.send(Message{Type: MessageError, Seat: c.seat, Error: err.Error()})
+		return
+	}
+	s.sitOut()
+	s.broadcast()
+}
+
+// join sits a client at the first free seat that isn't played by a bot.
+func (s *Server) join(c *client, name string) error {
+	if c.seat != -1 {
+		return ErrSeated
+	}
+	taken := map[int]bool{}
+	for other := range s.clients {
+		taken[other.seat] = true
+	}
+	for seat, player := range s.engine.Board.Players {
+		if player.IsBot() || taken[seat] {
+			continue
+		}
+		c.seat = seat
+		delete(s.vacated, seat)
+		if name != "" {
+			player.Name = name
+		}
+		s.engine.Board.Log.Push(fmt.Sprintf("[%s joins the table](fg-cyan)", player.Name))
+		c.send(Message{Type: MessageJoined, Seat: seat})
+		return nil
+	}
+	return ErrTableFull
+}
+
+// act takes an action by its key for a client's seat, logging it as the
+// display does for a key press.
+func (s *Server) act(c *client, key string) error {
+	if c.seat == -1 {
+		return ErrNotSeated
+	}
+	actions := s.actions(c.seat)
+	action, ok := actions[key]
+	if !ok && len(actions) == 0 {
+		return game.ErrNotYourTurn
+	}
+	if !ok {
+		return game.ErrNoSuchAction
+	}
+	board := s.engine.Board
+	board.Log.Push(fmt.Sprintf(
+		">> [%s: %s](fg-bold,fg-green)",
+		board.Players[c.seat].Name,
+		action.Description,
+	))
+	return s.engine.Act(c.seat, key)
+}
+
+// command carries out a typed command for a client's seat. As with actions,
+// changing seat isn't allowed.
+func (s *Server) command(c *client, command *game.Command) error {
+	if c.seat == -1 {
+		return ErrNotSeated
+	}
+	if command == nil {
+		return ErrBadMessage
+	}
+	if command.Type == game.CommandChangeSeat {
+		return &game.IllegalCommandError{
+			Command: command.Type,
+			Stage:   game.StageName(s.engine.Board.Stage),
+		}
+	}
+	return s.engine.Execute(c.seat, *command)
+}
+
+// The commands a vacated seat takes when its turn comes, so as not to hold up
+// the table.
+var sitOutCommands = map[game.CommandType]bool{
+	game.CommandDeclineInsurance: true,
+	game.CommandPlayOn:           true,
+	game.CommandStand:            true,
+}
+
+// sitOut stands or declines for each vacated seat whose turn it is, until it's
+// the turn of a seat that's still played. Betting and starting new rounds are
+// left to the players.
+func (s *Server) sitOut() {
+	board := s.engine.Board
+	for acted := true; acted; {
+		acted = false
+		switch game.StageName(board.Stage) {
+		case "betting", "conclusion":
+			return
+		}
+		for seat := range s.vacated {
+			for key, action := range s.actions(seat) {
+				if !sitOutCommands[action.Command] {
+					continue
+				}
+				board.Log.Push(fmt.Sprintf(
+					">> [%s: %s](fg-bold,fg-magenta)",
+					board.Players[seat].Name,
+					action.Description,
+				))
+				if s.engine.Act(seat, key) == nil {
+					acted = true
+				}
+				break
+			}
+		}
+	}
+}
+
+// actions gets the actions a seat can take. Changing seat is left out, as each
+// client bets for its own seat.
+func (s *Server) actions(seat int) game.ActionSet {
+	actions := s.engine.Actions(seat)
+	for key, action := range actions {
+		if action.Command == game.CommandChangeSeat {
+			delete(actions, key)
+		}
+	}
+	return actions
+}
+
+// state gets the state of the board as a client sees it.
+func (s *Server) state(c *client) Message {
+	snapshot := s.engine.Snapshot()
+	message := Message{
+		Type:  MessageState,
+		Seat:  c.seat,
+		State: &snapshot,
+		Log:   s.engine.Board.Log.Events(),
+	}
+	if c.seat != -1 {
+		message.Actions = actionsOf(s.actions(c.seat))
+	}
+	return message
+}
+
+// broadcast sends every client the state of the board.
+func (s *Server) broadcast() {
+	for c := range s.clients {
+		c.send(s.state(c))
+	}
+}
+
+// send queues a message for the client, dropping the client if it has fallen
+// too far behind.
+func (c *client) send(message Message) {
+	select {
+	case c.out <- message:
+	default:
+		c.conn.Close()
+	}
+}
+
+// write sends the client its queued messages, a line each.
+func (c *client) write() {
+	encoder := json.NewEncoder(c.conn)
+	for message := range c.out {
+		if err := encoder.Encode(message); err != nil {
+			c.conn.Close()
+		}
+	}
+}
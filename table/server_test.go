@@ -0,0 +1,165 @@
+package table
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/hughgrigg/blackjack/cards"
+	"github.com/hughgrigg/blackjack/game"
+	"github.com/stretchr/testify/assert"
+)
+
+//
+// Server
+//
+
+// Host a board with some seats on a local port, getting its address.
+func serve(t *testing.T, players ...*game.Player) string {
+	board := &game.Board{
+		Deck:    &cards.Deck{Random: cards.NewSeededRandomiser(5)},
+		Players: players,
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	go NewServer(game.NewEngine(board, game.DefaultRules())).Serve(listener)
+	return listener.Addr().String()
+}
+
+// Wait for the client's state to meet a condition, failing after a while.
+func waitFor(t *testing.T, c *Client, condition func(Message) bool) Message {
+	timeout := time.After(time.Second * 2)
+	for {
+		if state := c.State(); state.State != nil && condition(state) {
+			return state
+		}
+		select {
+		case <-c.Changed():
+		case <-timeout:
+			t.Fatalf("timed out waiting for the table, last state %+v", c.State())
+		}
+	}
+}
+
+// Clients should take free seats in turn, and play them by pressing keys.
+func TestServer(t *testing.T) {
+	address := serve(
+		t,
+		game.NewPlayer("Player 1", 100, nil),
+		game.NewPlayer("Bot", 100, game.MimicDealer{}),
+		game.NewPlayer("Player 2", 100, nil),
+	)
+	ann, err := Dial(address, "Ann")
+	assert.NoError(t, err)
+	defer ann.Close()
+	bob, err := Dial(address, "Bob")
+	assert.NoError(t, err)
+	defer bob.Close()
+	assert.Equal(t, 0, ann.Seat())
+	assert.Equal(t, 2, bob.Seat())
+
+	_, err = Dial(address, "Cat")
+	assert.EqualError(t, err, ErrTableFull.Error())
+
+	// Both seats see Bob raise his bet.
+	assert.NoError(t, bob.Act("r"))
+	for _, c := range []*Client{ann, bob} {
+		state := waitFor(t, c, func(m Message) bool {
+			return m.State.Seats[2].Bets[0].Amount == 10
+		})
+		assert.Equal(t, "Ann", state.State.Seats[0].Name)
+		assert.Equal(t, "Bob", state.State.Seats[2].Name)
+		assert.Contains(t, state.Log, ">> [Bob: Raise](fg-bold,fg-green)")
+	}
+
+	assert.NoError(t, ann.Act("d"))
+	state := waitFor(t, ann, func(m Message) bool {
+		return m.State.Stage != "betting"
+	})
+	assert.Len(t, state.State.Dealer.Cards, 2)
+
+	// Only the seat whose turn it is has actions.
+	assert.Equal(t, "player", state.State.Stage)
+	assert.Equal(t, 0, state.State.Turn)
+	assert.NotEmpty(t, state.Actions)
+	assert.Empty(t, waitFor(t, bob, func(m Message) bool {
+		return m.State.Stage == "player"
+	}).Actions)
+	assert.NoError(t, bob.Act("h"))
+	waitFor(t, bob, func(Message) bool { return bob.Err() != nil })
+	assert.EqualError(t, bob.Err(), game.ErrNotYourTurn.Error())
+}
+
+// A seat whose player leaves on their turn should stand rather than hold up
+// the table.
+func TestServer_Leave(t *testing.T) {
+	address := serve(t, game.NewPlayer("Player 1", 100, nil), game.NewPlayer("Player 2", 100, nil))
+	ann, err := Dial(address, "Ann")
+	assert.NoError(t, err)
+	bob, err := Dial(address, "Bob")
+	assert.NoError(t, err)
+	defer bob.Close()
+
+	assert.NoError(t, bob.Act("d"))
+	state := waitFor(t, ann, func(m Message) bool {
+		return m.State.Stage != "betting"
+	})
+	assert.Equal(t, "player", state.State.Stage)
+	assert.Equal(t, 0, state.State.Turn)
+
+	ann.Close()
+	state = waitFor(t, bob, func(m Message) bool {
+		return m.State.Turn == 1 || m.State.Stage == "conclusion"
+	})
+	assert.Contains(t, state.Log, ">> [Ann: Stand](fg-bold,fg-magenta)")
+}
+
+// Messages the server can't make sense of should be refused with an error.
+func TestServer_BadMessages(t *testing.T) {
+	address := serve(t, game.NewPlayer("Player", 100, nil), game.NewPlayer("Other", 100, nil))
+	conn, err := net.Dial("tcp", address)
+	assert.NoError(t, err)
+	defer conn.Close()
+	lines := bufio.NewScanner(conn)
+	lines.Buffer(nil, 1024*1024)
+	receive := func() Message {
+		message := Message{}
+		assert.True(t, lines.Scan())
+		assert.NoError(t, json.Unmarshal(lines.Bytes(), &message))
+		return message
+	}
+
+	// Watchers are sent the state without joining.
+	state := receive()
+	assert.Equal(t, MessageState, state.Type)
+	assert.Equal(t, -1, state.Seat)
+	assert.Empty(t, state.Actions)
+
+	for line, expected := range map[string]error{
+		"nonsense\n":                         ErrBadMessage,
+		`{"type":"shout"}` + "\n":            ErrBadMessage,
+		`{"type":"action","key":"d"}` + "\n": ErrNotSeated,
+	} {
+		conn.Write([]byte(line))
+		assert.Equal(t, Message{Type: MessageError, Seat: -1, Error: expected.Error()}, receive())
+	}
+
+	conn.Write([]byte(`{"type":"join"}` + "\n"))
+	assert.Equal(t, Message{Type: MessageJoined, Seat: 0}, receive())
+	assert.Equal(t, "Player", receive().State.Seats[0].Name)
+
+	conn.Write([]byte(`{"type":"command","command":{"type":"bet","amount":500}}` + "\n"))
+	assert.Equal(t, game.ErrCannotAfford.Error(), receive().Error)
+	conn.Write([]byte(`{"type":"command","command":{"type":"bet","amount":50}}` + "\n"))
+	assert.Equal(t, 50.0, receive().State.Seats[0].Bets[0].Amount)
+
+	// Each client only bets for its own seat.
+	conn.Write([]byte(`{"type":"command","command":{"type":"change seat"}}` + "\n"))
+	assert.Equal(t, "can't change seat during the betting stage", receive().Error)
+	conn.Write([]byte(`{"type":"command","command":{"type":"bet","amount":40}}` + "\n"))
+	after := receive().State
+	assert.Equal(t, 40.0, after.Seats[0].Bets[0].Amount)
+	assert.Equal(t, 0, after.Turn)
+}
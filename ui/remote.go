@@ -0,0 +1,186 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/hughgrigg/blackjack/game"
+	"github.com/hughgrigg/blackjack/table"
+	"github.com/hughgrigg/blackjack/util"
+)
+
+// AttachRemote shows a table hosted by a server in the display, sending the
+// keys pressed to it as actions instead of playing a board of its own.
+func (d *Display) AttachRemote(c *table.Client) {
+	d.remote = c
+	d.deckView.renderer = remoteRenderer{c, renderRemoteDeck}
+	d.dealerView.renderer = remoteRenderer{c, renderRemoteDealer}
+	d.playerView.renderer = remoteRenderer{c, renderRemoteSeats}
+	d.balanceView.renderer = remoteRenderer{c, renderRemoteBalances}
+	d.eventLogView.renderer = remoteRenderer{c, renderRemoteLog}
+	d.actionsView.renderer = remoteRenderer{c, renderRemoteActions}
+	d.adviceView.BorderLabel = "Table"
+	d.adviceView.renderer = remoteRenderer{c, renderRemoteTable}
+
+	// Make room for a line per seat.
+	if state := c.State().State; state != nil && len(state.Seats) > 1 {
+		extra := len(state.Seats) - 1
+		d.playerView.Height += extra
+		d.eventLogView.Height += extra
+	}
+}
+
+// sendRemote sends a key press to the remote table, if it stands for one of
+// the actions the seat can take.
+func (d *Display) sendRemote(key string) {
+	for _, action := range d.remote.State().Actions {
+		if action.Key == key {
+			d.remote.Act(key)
+			return
+		}
+	}
+}
+
+// remoteRenderer renders part of the latest state sent by a remote table.
+type remoteRenderer struct {
+	client *table.Client
+	render func(c *table.Client, state game.Snapshot, message table.Message) string
+}
+
+// Render prints part of the remote table's state as a string, once the server
+// has sent it.
+func (rr remoteRenderer) Render() string {
+	message := rr.client.State()
+	if message.State == nil {
+		return ""
+	}
+	return rr.render(rr.client, *message.State, message)
+}
+
+// renderRemoteDeck prints the number of cards left in the remote shoe.
+func renderRemoteDeck(c *table.Client, state game.Snapshot, message table.Message) string {
+	return fmt.Sprintf("🂠  ×%d", state.Remaining)
+}
+
+// renderRemoteDealer prints the dealer's hand at the remote table.
+func renderRemoteDealer(c *table.Client, state game.Snapshot, message table.Message) string {
+	return state.Dealer.Hand().Render()
+}
+
+// renderRemoteSeats prints each seat's hands and bets at the remote table on a
+// line of its own, marking whose turn it is.
+func renderRemoteSeats(c *table.Client, state game.Snapshot, message table.Message) string {
+	lines := []string{}
+	for i, seat := range state.Seats {
+		if i == state.Turn {
+			lines = append(lines, fmt.Sprintf(
+				"[▶ %s](fg-bold,fg-magenta) %s",
+				seat.Name,
+				renderRemoteSeat(seat, true),
+			))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf(
+			"  %s %s",
+			seat.Name,
+			util.StripFormatting(renderRemoteSeat(seat, false)),
+		))
+	}
+	return strings.Join(lines, "\n ")
+}
+
+// renderRemoteSeat prints a seat's hands and bets, highlighting the hand being
+// played if it's the seat's turn.
+func renderRemoteSeat(seat game.SeatSnapshot, turn bool) string {
+	buffer := bytes.Buffer{}
+	focused := false
+	last := len(seat.Bets) - 1
+	for i, bet := range seat.Bets {
+		if turn && !focused && !bet.Finished {
+			focused = true
+			buffer.WriteString(fmt.Sprintf(
+				"%s {[%s](fg-bold,fg-cyan,fg-underline)}",
+				bet.Hand.Hand().Render(),
+				ac.FormatMoney(bet.Amount),
+			))
+		} else {
+			buffer.WriteString(fmt.Sprintf(
+				"[%s {%s}](fg-magenta)",
+				util.StripFormatting(bet.Hand.Hand().Render()),
+				ac.FormatMoney(bet.Amount),
+			))
+		}
+		if i != last {
+			buffer.WriteString(" | ")
+		}
+	}
+	for _, sideBet := range seat.SideBets {
+		label := string(sideBet.Kind)
+		if sideBet.Result != "" {
+			label = fmt.Sprintf("%s %s", sideBet.Kind, sideBet.Result)
+		}
+		buffer.WriteString(fmt.Sprintf(
+			" | [%s {%s}](fg-yellow)",
+			label,
+			ac.FormatMoney(sideBet.Amount),
+		))
+	}
+	return buffer.String()
+}
+
+// renderRemoteBalances prints the balance of each seat at the remote table.
+func renderRemoteBalances(c *table.Client, state game.Snapshot, message table.Message) string {
+	balances := []string{}
+	for _, seat := range state.Seats {
+		balances = append(balances, fmt.Sprintf(
+			"%s: [%s](fg-green)",
+			seat.Name,
+			ac.FormatMoney(seat.Balance),
+		))
+	}
+	return strings.Join(balances, " | ")
+}
+
+// renderRemoteLog prints the remote table's game log.
+func renderRemoteLog(c *table.Client, state game.Snapshot, message table.Message) string {
+	log := game.Log{}
+	for _, event := range message.Log {
+		log.Push(event)
+	}
+	return log.Render()
+}
+
+// renderRemoteActions prints the actions the client's seat can take, or whose
+// turn it is while it waits, followed by why the table refused the last key
+// sent, if it did.
+func renderRemoteActions(c *table.Client, state game.Snapshot, message table.Message) string {
+	buffer := bytes.Buffer{}
+	if state.Turn >= 0 && state.Turn < len(state.Seats) {
+		buffer.WriteString(fmt.Sprintf(
+			"[%s](fg-bold,fg-magenta) | ",
+			state.Seats[state.Turn].Name,
+		))
+	}
+	for _, action := range message.Actions {
+		buffer.WriteString(fmt.Sprintf(
+			"[%s](fg-bold,fg-green): %s | ",
+			action.Key,
+			action.Description,
+		))
+	}
+	buffer.WriteString("[q](fg-bold,fg-green): Quit")
+	if err := c.Err(); err != nil {
+		buffer.WriteString(fmt.Sprintf("\n [%s](fg-red)", err))
+	}
+	return buffer.String()
+}
+
+// renderRemoteTable prints which seat the client has taken at the remote
+// table.
+func renderRemoteTable(c *table.Client, state game.Snapshot, message table.Message) string {
+	if c.Seat() < 0 || c.Seat() >= len(state.Seats) {
+		return "Watching"
+	}
+	return fmt.Sprintf("Playing as [%s](fg-bold,fg-magenta)", state.Seats[c.Seat()].Name)
+}
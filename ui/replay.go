@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/hughgrigg/blackjack/game"
+)
+
+// AttachReplay shows a replay of a recorded round in the display, stepping it
+// forwards and back with the arrow keys instead of playing the board.
+func (d *Display) AttachReplay(r *game.Replay) {
+	d.replay = r
+	d.AttachBoard(r.Board)
+	d.actionsView.BorderLabel = "Replay"
+	d.actionsView.renderer = ReplayRenderer{r}
+}
+
+// stepReplay moves the replay forwards or back for a key press, and shows the
+// board as it is at the new step.
+func (d *Display) stepReplay(key string) {
+	r := d.replay
+	var err error
+	switch key {
+	case "<right>", "l", "<space>":
+		err = r.Forward()
+	case "<left>", "h":
+		err = r.Back()
+	case "<home>", "g":
+		err = r.Seek(0)
+	case "<end>", "G":
+		err = r.Seek(r.Steps())
+	default:
+		return
+	}
+	d.AttachBoard(r.Board)
+	d.actionsView.renderer = ReplayRenderer{r}
+	if err != nil {
+		r.Board.Log.Push(fmt.Sprintf("[%s](fg-red)", err))
+	}
+}
+
+// ReplayRenderer renders how far through a replay is, the decision made by
+// stepping forward, and the keys that step it.
+type ReplayRenderer struct {
+	replay *game.Replay
+}
+
+// Render prints the replay's step and controls as a string.
+func (rr ReplayRenderer) Render() string {
+	r := rr.replay
+	next := "[End of round](fg-bold,fg-magenta)"
+	if decision, ok := r.Next(); ok {
+		next = fmt.Sprintf("Next: [%s](fg-bold,fg-magenta)", decision.Text())
+	}
+	return fmt.Sprintf(
+		"Round %d, step %d of %d | %s\n "+
+			"[←](fg-bold,fg-green): Back | [→](fg-bold,fg-green): Forward | "+
+			"[g](fg-bold,fg-green): Start | [G](fg-bold,fg-green): End | "+
+			"[q](fg-bold,fg-green): Quit",
+		r.Round.Number,
+		r.Step(),
+		r.Steps(),
+		next,
+	)
+}
@@ -0,0 +1,238 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/hughgrigg/blackjack/counting"
+	"github.com/hughgrigg/blackjack/game"
+)
+
+//
+// Count trainer
+//
+
+// TrainerRenderer drills the player on keeping the count. The count is shown
+// for the first round after each drill, then hidden until the next drill asks
+// the player to type it in.
+type TrainerRenderer struct {
+	board   *game.Board
+	counter *counting.Counter
+	// The number of rounds played between drills.
+	Every int
+	// Every drill answered so far, oldest first.
+	History []Drill
+	rounds  int
+	drill   *Drill
+	input   string
+}
+
+// Drill is a single test of the player's count, taken at the end of a round.
+type Drill struct {
+	Running      float64
+	True         float64
+	RunningGuess float64
+	TrueGuess    float64
+	// Whether the true count was asked for, which it isn't for unbalanced
+	// systems.
+	AskedTrue bool
+	answered  int
+}
+
+// How far a true count guess can be out and still be right, as players round
+// the remaining decks when working it out.
+const trueCountTolerance = 0.5
+
+// NewTrainerRenderer makes a count trainer for a board, following the count
+// with a counter that is watching the board.
+func NewTrainerRenderer(board *game.Board, counter *counting.Counter) *TrainerRenderer {
+	return &TrainerRenderer{board: board, counter: counter, Every: 3}
+}
+
+// RoundEnded counts a finished round, starting a drill if one is due.
+func (tr *TrainerRenderer) RoundEnded() {
+	tr.rounds++
+	if tr.rounds < tr.Every {
+		return
+	}
+	tr.rounds = 0
+	tr.input = ""
+	tr.drill = &Drill{
+		Running:   tr.counter.RunningCount(),
+		True:      tr.counter.TrueCount(),
+		AskedTrue: tr.counter.System.Balanced(),
+	}
+}
+
+// Asking sees if a drill is waiting for an answer.
+func (tr *TrainerRenderer) Asking() bool {
+	return tr.drill != nil
+}
+
+// Type takes a key press towards the answer to the current drill. Numbers are
+// typed in and answered with enter.
+func (tr *TrainerRenderer) Type(key string) {
+	if tr.drill == nil {
+		return
+	}
+	switch key {
+	case "<enter>":
+		tr.answer()
+	case "<backspace>", "C-8":
+		if len(tr.input) > 0 {
+			tr.input = tr.input[:len(tr.input)-1]
+		}
+	case "-", "+", ".", "0", "1", "2", "3", "4", "5", "6", "7", "8", "9":
+		tr.input += key
+	}
+}
+
+// answer takes the typed number as the answer to the current question, moving
+// on to the true count or finishing the drill.
+func (tr *TrainerRenderer) answer() {
+	guess, err := strconv.ParseFloat(tr.input, 64)
+	tr.input = ""
+	if err != nil {
+		return
+	}
+	drill := tr.drill
+	if drill.answered == 0 {
+		drill.RunningGuess = guess
+	} else {
+		drill.TrueGuess = guess
+	}
+	drill.answered++
+	if drill.AskedTrue && drill.answered < 2 {
+		return
+	}
+
+	tr.History = append(tr.History, *drill)
+	tr.drill = nil
+	colour := "fg-green"
+	if !drill.Correct() {
+		colour = "fg-red"
+	}
+	tr.board.Log.Push(fmt.Sprintf("[Count drill: %s](%s)", drill, colour))
+}
+
+// Correct sees if every answer in the drill was right.
+func (d Drill) Correct() bool {
+	return d.RunningCorrect() && (!d.AskedTrue || d.TrueCorrect())
+}
+
+// RunningCorrect sees if the running count was answered exactly.
+func (d Drill) RunningCorrect() bool {
+	return d.RunningGuess == d.Running
+}
+
+// TrueCorrect sees if the true count was answered closely enough.
+func (d Drill) TrueCorrect() bool {
+	diff := d.TrueGuess - d.True
+	return diff <= trueCountTolerance && diff >= -trueCountTolerance
+}
+
+// String describes the answers given against the actual counts.
+func (d Drill) String() string {
+	description := fmt.Sprintf(
+		"running %s, you said %s",
+		formatCount(d.Running),
+		formatCount(d.RunningGuess),
+	)
+	if d.AskedTrue {
+		description += fmt.Sprintf(
+			"; true %s, you said %s",
+			formatCount(d.True),
+			formatCount(d.TrueGuess),
+		)
+	}
+	return description
+}
+
+// Accuracy gets the fraction of answers that were right across every drill.
+func (tr *TrainerRenderer) Accuracy() float64 {
+	asked, right := 0, 0
+	for _, drill := range tr.History {
+		asked++
+		if drill.RunningCorrect() {
+			right++
+		}
+		if drill.AskedTrue {
+			asked++
+			if drill.TrueCorrect() {
+				right++
+			}
+		}
+	}
+	if asked == 0 {
+		return 0
+	}
+	return float64(right) / float64(asked)
+}
+
+// Render prints the current question or the count if it's showing, followed by
+// a summary of the drills so far.
+func (tr *TrainerRenderer) Render() string {
+	buffer := bytes.Buffer{}
+	buffer.WriteString(fmt.Sprintf("[%s](fg-bold,fg-cyan) | ", tr.counter.System.Name))
+	switch {
+	case tr.drill != nil:
+		question := "Running count?"
+		if tr.drill.answered > 0 {
+			question = "True count?"
+		}
+		buffer.WriteString(fmt.Sprintf(
+			"[%s](fg-bold,fg-yellow) %s_ | [enter](fg-bold,fg-green): Answer",
+			question,
+			tr.input,
+		))
+	case tr.rounds == 0:
+		buffer.WriteString(fmt.Sprintf(
+			"Running %s | True %s | Decks left %.1f",
+			formatCount(tr.counter.RunningCount()),
+			formatCount(tr.counter.TrueCount()),
+			tr.counter.DecksRemaining(),
+		))
+	default:
+		buffer.WriteString(fmt.Sprintf(
+			"Count hidden, drill after %d more round(s)",
+			tr.Every-tr.rounds,
+		))
+	}
+	buffer.WriteString("\n ")
+	buffer.WriteString(tr.renderHistory())
+	return buffer.String()
+}
+
+// renderHistory summarises the drills so far, marking the latest few as right
+// or wrong.
+func (tr *TrainerRenderer) renderHistory() string {
+	if len(tr.History) == 0 {
+		return "No drills yet"
+	}
+	marks := []string{}
+	recent := tr.History
+	if len(recent) > 10 {
+		recent = recent[len(recent)-10:]
+	}
+	for _, drill := range recent {
+		if drill.Correct() {
+			marks = append(marks, "[✓](fg-green)")
+		} else {
+			marks = append(marks, "[✗](fg-red)")
+		}
+	}
+	return fmt.Sprintf(
+		"Drills %d | Accuracy %.0f%% | %s",
+		len(tr.History),
+		100*tr.Accuracy(),
+		strings.Join(marks, " "),
+	)
+}
+
+// formatCount prints a count with its sign, to at most one decimal place.
+func formatCount(count float64) string {
+	return fmt.Sprintf("%+g", math.Round(count*10)/10)
+}
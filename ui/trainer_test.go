@@ -0,0 +1,96 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/hughgrigg/blackjack/cards"
+	"github.com/hughgrigg/blackjack/counting"
+	"github.com/hughgrigg/blackjack/game"
+	"github.com/stretchr/testify/assert"
+)
+
+//
+// Count trainer
+//
+
+// Make a trainer with a Hi-Lo count of +2 in a two deck shoe.
+func countTwo() *TrainerRenderer {
+	board := &game.Board{}
+	board.BeginHeadless(game.DefaultRules())
+	counter := counting.NewCounter(counting.HiLo, 2)
+	counter.See(cards.NewCard(cards.Three, cards.Clubs))
+	counter.See(cards.NewCard(cards.Four, cards.Clubs))
+	return NewTrainerRenderer(board, counter)
+}
+
+// Type each key in turn.
+func typeKeys(trainer *TrainerRenderer, keys ...string) {
+	for _, key := range keys {
+		trainer.Type(key)
+	}
+}
+
+// The count should show at first, then be hidden until a drill is due.
+func TestTrainerRenderer_Render(t *testing.T) {
+	trainer := countTwo()
+	assert.Equal(
+		t,
+		"[Hi-Lo](fg-bold,fg-cyan) | Running +2 | True +1 | Decks left 2.0\n No drills yet",
+		trainer.Render(),
+	)
+
+	trainer.RoundEnded()
+	assert.Contains(t, trainer.Render(), "Count hidden, drill after 2 more round(s)")
+	assert.False(t, trainer.Asking())
+
+	trainer.RoundEnded()
+	trainer.RoundEnded()
+	assert.True(t, trainer.Asking())
+	assert.Contains(t, trainer.Render(), "[Running count?](fg-bold,fg-yellow) _")
+}
+
+// A drill should ask for the running then true count, and log the result.
+func TestTrainerRenderer_Drill(t *testing.T) {
+	trainer := countTwo()
+	trainer.Every = 1
+	trainer.RoundEnded()
+
+	typeKeys(trainer, "+", "3", "<backspace>", "2", "<enter>")
+	assert.True(t, trainer.Asking())
+	assert.Contains(t, trainer.Render(), "True count?")
+
+	typeKeys(trainer, "1", ".", "5", "<enter>")
+	assert.False(t, trainer.Asking())
+	assert.Len(t, trainer.History, 1)
+	assert.True(t, trainer.History[0].Correct())
+	assert.Contains(
+		t,
+		trainer.board.Log.Render(),
+		"[Count drill: running +2, you said +2; true +1, you said +1.5](fg-green)",
+	)
+	assert.Contains(t, trainer.Render(), "Drills 1 | Accuracy 100% | [✓](fg-green)")
+}
+
+// Wrong answers should count against the accuracy.
+func TestTrainerRenderer_Drill_Wrong(t *testing.T) {
+	trainer := countTwo()
+	trainer.Every = 1
+	trainer.RoundEnded()
+	typeKeys(trainer, "2", "<enter>", "3", "<enter>")
+
+	assert.False(t, trainer.History[0].Correct())
+	assert.Equal(t, 0.5, trainer.Accuracy())
+	assert.Contains(t, trainer.board.Log.Render(), "(fg-red)")
+}
+
+// Unbalanced systems should only be asked for the running count.
+func TestTrainerRenderer_Drill_Unbalanced(t *testing.T) {
+	trainer := countTwo()
+	trainer.counter = counting.NewCounter(counting.KO, 1)
+	trainer.Every = 1
+	trainer.RoundEnded()
+	typeKeys(trainer, "0", "<enter>")
+
+	assert.False(t, trainer.Asking())
+	assert.True(t, trainer.History[0].Correct())
+}
@@ -4,9 +4,14 @@ import (
 	"bytes"
 	"fmt"
 	"sort"
+	"strings"
 
 	"github.com/gizak/termui"
+	"github.com/hughgrigg/blackjack/cards"
+	"github.com/hughgrigg/blackjack/counting"
 	"github.com/hughgrigg/blackjack/game"
+	"github.com/hughgrigg/blackjack/strategy"
+	"github.com/hughgrigg/blackjack/table"
 	"github.com/hughgrigg/blackjack/util"
 	"github.com/leekchan/accounting"
 )
@@ -20,7 +25,16 @@ type Display struct {
 	balanceView  *View
 	eventLogView *View
 	actionsView  *View
+	adviceView   *View
+	trainerView  *View
 	views        []*View
+	advice       *AdviceRenderer
+	trainer      *TrainerRenderer
+	replay       *game.Replay
+	remote       *table.Client
+	// Runs the count trainer with a counter watching the board, if set before
+	// the display is initialised.
+	Counter *counting.Counter
 }
 
 // Initialise the display with its views and keyboard handlers.
@@ -32,6 +46,20 @@ func (d *Display) Init() {
 		termui.StopLoop()
 	})
 
+	// ? toggles basic strategy advice.
+	termui.Handle("/sys/kbd/?", func(event termui.Event) {
+		if d.advice != nil {
+			d.advice.Enabled = !d.advice.Enabled
+		}
+	})
+
+	// x works out the exact value of each play from the cards left.
+	termui.Handle("/sys/kbd/x", func(event termui.Event) {
+		if d.advice != nil {
+			d.advice.Analyse()
+		}
+	})
+
 	// Pass key presses to actions for the game board's current stage.
 	termui.Handle(
 		"/sys/kbd",
@@ -40,6 +68,25 @@ func (d *Display) Init() {
 			if !ok {
 				return
 			}
+			// Replays only step forwards and back.
+			if d.replay != nil {
+				d.stepReplay(evtKbd.KeyStr)
+				return
+			}
+			// Remote tables are played on the server.
+			if d.remote != nil {
+				d.sendRemote(evtKbd.KeyStr)
+				return
+			}
+			// Drills take typed answers until they're done.
+			if d.trainer != nil && d.trainer.Asking() {
+				d.trainer.Type(evtKbd.KeyStr)
+				return
+			}
+			// Bots take their own turns.
+			if d.board.Player != nil && d.board.Player.IsBot() {
+				return
+			}
 			actions := d.board.Stage.Actions(d.board)
 			playerAction, ok := actions[evtKbd.KeyStr]
 			if !ok {
@@ -49,7 +96,15 @@ func (d *Display) Init() {
 				">> [%s](fg-bold,fg-green)",
 				playerAction.Description,
 			))
-			playerAction.Execute(d.board)
+			if d.advice != nil {
+				d.advice.CheckDeviation(playerAction)
+			}
+			if d.trainer != nil && playerAction.Command == game.CommandNewRound {
+				d.trainer.RoundEnded()
+			}
+			if err := d.board.Take(playerAction); err != nil {
+				d.board.Log.Push(fmt.Sprintf("[%s](fg-red)", err))
+			}
 		},
 	)
 }
@@ -63,24 +118,31 @@ func (d *Display) initViews() {
 	d.playerView.BorderLabelFg = termui.ColorGreen
 	d.balanceView = d.NewView("Funds", 5)
 	d.actionsView = d.NewView("Actions", 5)
-	d.eventLogView = d.NewView("Game Log", util.SumInts([]int{
-		d.deckView.Height,
-		d.dealerView.Height,
-		d.playerView.Height,
-		d.balanceView.Height,
-		d.actionsView.Height,
-	}))
+	d.adviceView = d.NewView("Advice (?)", 5)
+	d.adviceView.BorderLabelFg = termui.ColorCyan
+	left := []*View{
+		d.deckView,
+		d.dealerView,
+		d.playerView,
+		d.balanceView,
+		d.actionsView,
+		d.adviceView,
+	}
+	if d.Counter != nil {
+		d.trainerView = d.NewView("Count Trainer", 5)
+		d.trainerView.BorderLabelFg = termui.ColorYellow
+		left = append(left, d.trainerView)
+	}
+	heights := []int{}
+	column := []termui.GridBufferer{}
+	for _, view := range left {
+		heights = append(heights, view.Height)
+		column = append(column, view)
+	}
+	d.eventLogView = d.NewView("Game Log", util.SumInts(heights))
 	termui.Body.AddRows(
 		termui.NewRow(
-			termui.NewCol(
-				7,
-				0,
-				d.deckView,
-				d.dealerView,
-				d.playerView,
-				d.balanceView,
-				d.actionsView,
-			),
+			termui.NewCol(7, 0, column...),
 			termui.NewCol(5, 0, d.eventLogView),
 		),
 	)
@@ -108,14 +170,28 @@ func (d *Display) Render() {
 
 // Allow setting renderer interfaces for each part of the display.
 func (d *Display) AttachBoard(b *game.Board) {
+	attached := d.board != nil
 	d.board = b
 
 	d.deckView.renderer = b.Deck
 	d.dealerView.renderer = b.Dealer
-	d.playerView.renderer = b.Player
-	d.balanceView.renderer = BalanceRenderer{b.Player}
+	d.playerView.renderer = SeatsRenderer{b}
+	d.balanceView.renderer = BalanceRenderer{b}
 	d.eventLogView.renderer = b.Log
 	d.actionsView.renderer = ActionSetRenderer{b}
+	d.advice = &AdviceRenderer{board: b}
+	d.adviceView.renderer = d.advice
+	if d.Counter != nil {
+		d.trainer = NewTrainerRenderer(b, d.Counter)
+		d.trainerView.renderer = d.trainer
+	}
+
+	// Make room for a line per seat, the first time a board is attached.
+	if len(b.Players) > 1 && !attached {
+		extra := len(b.Players) - 1
+		d.playerView.Height += extra
+		d.eventLogView.Height += extra
+	}
 }
 
 //
@@ -155,16 +231,23 @@ func (asr ActionSetRenderer) Render() string {
 		keys = append(keys, k)
 	}
 	sort.Strings(keys)
+	buffer := bytes.Buffer{}
+	// Say whose turn it is when there is more than one seat.
+	if len(asr.board.Players) > 1 && asr.board.Player != nil {
+		buffer.WriteString(fmt.Sprintf(
+			"[%s](fg-bold,fg-magenta) | ",
+			asr.board.Player.Name,
+		))
+	}
 	// Add quit at the end of the actions
 	actions["q"] = game.PlayerAction{
-		Execute: func(*game.Board) bool {
+		Execute: func(*game.Board) error {
 			termui.StopLoop()
-			return true
+			return nil
 		},
 		Description: "Quit",
 	}
 	keys = append(keys, "q")
-	buffer := bytes.Buffer{}
 	last := keys[len(keys)-1]
 	for _, k := range keys {
 		buffer.WriteString(fmt.Sprintf(
@@ -180,17 +263,180 @@ func (asr ActionSetRenderer) Render() string {
 	return buffer.String()
 }
 
-// BalanceRenderer renders the player's bank balance.
+// SeatsRenderer renders the hands and bets of each seat at the board, marking
+// whose turn it is.
+type SeatsRenderer struct {
+	board *game.Board
+}
+
+// Render prints each seat's hands and bets on a line of its own.
+func (sr SeatsRenderer) Render() string {
+	if len(sr.board.Players) < 2 {
+		return sr.board.Player.Render()
+	}
+	lines := []string{}
+	for _, player := range sr.board.Players {
+		if player == sr.board.Player {
+			lines = append(lines, fmt.Sprintf(
+				"[▶ %s](fg-bold,fg-magenta) %s",
+				player.Name,
+				player.Render(),
+			))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf(
+			"  %s %s",
+			player.Name,
+			util.StripFormatting(player.Render()),
+		))
+	}
+	return strings.Join(lines, "\n ")
+}
+
+// BalanceRenderer renders the bank balance of each seat.
 type BalanceRenderer struct {
-	player *game.Player
+	board *game.Board
 }
 
 var ac = accounting.Accounting{Symbol: "£", Precision: 2}
 
-// Render prints the balance as a string.
+// Render prints the balances as a string.
 func (br BalanceRenderer) Render() string {
-	return fmt.Sprintf(
-		"[%s](fg-green)",
-		ac.FormatMoneyBigFloat(br.player.Balance),
-	)
+	if len(br.board.Players) < 2 {
+		return fmt.Sprintf(
+			"[%s](fg-green)",
+			ac.FormatMoneyBigFloat(br.board.Player.Balance),
+		)
+	}
+	balances := []string{}
+	for _, player := range br.board.Players {
+		balances = append(balances, fmt.Sprintf(
+			"%s: [%s](fg-green)",
+			player.Name,
+			ac.FormatMoneyBigFloat(player.Balance),
+		))
+	}
+	return strings.Join(balances, " | ")
+}
+
+// AdviceRenderer renders the basic strategy play for the focused bet during
+// the player stage, when it is enabled.
+type AdviceRenderer struct {
+	board   *game.Board
+	Enabled bool
+}
+
+// Render prints the advised play, followed by the expected value of each play
+// available.
+func (ar *AdviceRenderer) Render() string {
+	if !ar.Enabled {
+		return "Press ? for basic strategy advice"
+	}
+	advice, evs, ok := ar.advise()
+	if !ok {
+		return "Advice is given on your turn to play"
+	}
+	buffer := bytes.Buffer{}
+	buffer.WriteString(fmt.Sprintf("[%s](fg-bold,fg-cyan)", advice))
+	for _, action := range strategy.Rank(evs) {
+		buffer.WriteString(fmt.Sprintf(" | %s %+.3f", action, evs[action]))
+	}
+	return buffer.String()
+}
+
+// CheckDeviation flags in the game log when an action taken differs from the
+// advised play, if advice is enabled.
+func (ar *AdviceRenderer) CheckDeviation(action game.PlayerAction) {
+	if !ar.Enabled {
+		return
+	}
+	advice, _, ok := ar.advise()
+	if !ok || action.Command == advice.Command() {
+		return
+	}
+	ar.board.Log.Push(fmt.Sprintf(
+		"[Basic strategy was to %s](fg-yellow)",
+		strings.ToLower(advice.String()),
+	))
+}
+
+// Analyse puts the exact value of each play available into the game log,
+// worked out from the cards that are left unseen. The working out can take a
+// while on a big shoe, so it's done in the background from a copy of the
+// cards; the returned channel is closed once the values are in the log.
+func (ar *AdviceRenderer) Analyse() <-chan struct{} {
+	done := make(chan struct{})
+	if !ar.playing() {
+		close(done)
+		return done
+	}
+	board := ar.board
+	hand := &cards.Hand{
+		Cards: append([]*cards.Card{}, board.Player.ActiveBet().Hand.Cards...),
+	}
+	up := board.Dealer.UpCard()
+	unseen := board.Unseen()
+	options := strategy.OptionsFor(board.Player, board.Rules)
+	commands := ar.commands()
+	board.Log.Push("Working out exact values...")
+	go func() {
+		defer close(done)
+		evs := only(strategy.Analyse(hand, up, unseen, board.Rules, options), commands)
+		buffer := bytes.Buffer{}
+		buffer.WriteString("Exact values:")
+		for _, action := range strategy.Rank(evs) {
+			buffer.WriteString(fmt.Sprintf(" %s %+.3f", action, evs[action]))
+		}
+		board.Log.Push(fmt.Sprintf("[%s](fg-cyan)", buffer.String()))
+	}()
+	return done
+}
+
+// advise gets the best play out of those available, along with the expected
+// value of each, if it's a human's turn in the player stage.
+func (ar *AdviceRenderer) advise() (strategy.Action, map[strategy.Action]float64, bool) {
+	if !ar.playing() {
+		return strategy.Stand, nil, false
+	}
+	board := ar.board
+	evs := ar.available(strategy.Evaluate(
+		board.Player.ActiveBet().Hand,
+		board.Dealer.UpCard(),
+		board.Rules,
+		strategy.OptionsFor(board.Player, board.Rules),
+	))
+	return strategy.Best(evs), evs, true
+}
+
+// playing sees if it's a human's turn in the player stage.
+func (ar *AdviceRenderer) playing() bool {
+	board := ar.board
+	if _, ok := board.Stage.(*game.PlayerStage); !ok {
+		return false
+	}
+	return board.Player != nil && !board.Player.IsBot()
+}
+
+// available leaves only the values of plays that can be made right now.
+func (ar *AdviceRenderer) available(evs map[strategy.Action]float64) map[strategy.Action]float64 {
+	return only(evs, ar.commands())
+}
+
+// commands gets the set of commands that can be made right now.
+func (ar *AdviceRenderer) commands() map[game.CommandType]bool {
+	commands := map[game.CommandType]bool{}
+	for _, action := range ar.board.Stage.Actions(ar.board) {
+		commands[action.Command] = true
+	}
+	return commands
+}
+
+// only leaves the values of plays made with the given commands.
+func only(evs map[strategy.Action]float64, commands map[game.CommandType]bool) map[strategy.Action]float64 {
+	for action := range evs {
+		if !commands[action.Command()] {
+			delete(evs, action)
+		}
+	}
+	return evs
 }
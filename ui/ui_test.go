@@ -1,10 +1,15 @@
 package ui
 
 import (
+	"net"
 	"testing"
+	"time"
 
 	"github.com/gizak/termui"
+	"github.com/hughgrigg/blackjack/cards"
+	"github.com/hughgrigg/blackjack/counting"
 	"github.com/hughgrigg/blackjack/game"
+	"github.com/hughgrigg/blackjack/table"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -45,6 +50,15 @@ func TestDisplay_AttachBoard(t *testing.T) {
 	assert.Equal(t, board.Deck, display.deckView.renderer)
 }
 
+// The display should have a count trainer when given a counter.
+func TestDisplay_AttachBoard_Trainer(t *testing.T) {
+	display := Display{Counter: counting.NewCounter(counting.HiLo, 6)}
+	display.initViews()
+	display.AttachBoard(&game.Board{})
+
+	assert.Equal(t, display.trainer, display.trainerView.renderer)
+}
+
 // Should be able to render the display as a string.
 func TestDisplay_Render(t *testing.T) {
 	display := Display{}
@@ -80,16 +94,178 @@ func (fs fooStage) Begin(b *game.Board) {
 func (fs fooStage) Actions(board *game.Board) game.ActionSet {
 	return game.ActionSet{
 		"f": {
-			Execute: func(b *game.Board) bool {
-				return true
+			Execute: func(b *game.Board) error {
+				return nil
 			},
 			Description: "Foobar",
 		},
 	}
 }
 
+// A seats renderer should mark whose turn it is when there are several seats.
+func TestSeatsRenderer_Render(t *testing.T) {
+	board := &game.Board{Players: []*game.Player{
+		game.NewPlayer("Ann", 100, nil),
+		game.NewPlayer("Bob", 100, game.MimicDealer{}),
+	}}
+	board.Begin(0, game.DefaultRules())
+
+	rendered := SeatsRenderer{board}.Render()
+
+	assert.Contains(t, rendered, "[▶ Ann](fg-bold,fg-magenta)")
+	assert.Contains(t, rendered, "  Bob")
+}
+
+// A balance renderer should show each seat's balance.
+func TestBalanceRenderer_Render(t *testing.T) {
+	board := &game.Board{Players: []*game.Player{
+		game.NewPlayer("Ann", 100, nil),
+		game.NewPlayer("Bob", 50, nil),
+	}}
+	board.Begin(0, game.DefaultRules())
+
+	assert.Equal(
+		t,
+		"Ann: [£95.00](fg-green) | Bob: [£45.00](fg-green)",
+		BalanceRenderer{board}.Render(),
+	)
+}
+
+// Deal the player 11 against the dealer's 6, for which basic strategy is to
+// double down.
+func dealEleven() *game.Board {
+	engine := game.NewEngine(&game.Board{}, game.DefaultRules())
+	deck := engine.Board.Deck
+	deck.Cards[51] = cards.NewCard(cards.Six, cards.Clubs)   // dealer 1
+	deck.Cards[50] = cards.NewCard(cards.Six, cards.Spades)  // player 1
+	deck.Cards[49] = cards.NewCard(cards.Seven, cards.Clubs) // dealer 2
+	deck.Cards[48] = cards.NewCard(cards.Five, cards.Hearts) // player 2
+	engine.Submit(0, game.Command{Type: game.CommandDeal})
+	return engine.Board
+}
+
+// An advice renderer should show the basic strategy play once enabled.
+func TestAdviceRenderer_Render(t *testing.T) {
+	advice := &AdviceRenderer{board: dealEleven()}
+	assert.Equal(t, "Press ? for basic strategy advice", advice.Render())
+
+	advice.Enabled = true
+	assert.Contains(t, advice.Render(), "[Double Down](fg-bold,fg-cyan) | Double Down +")
+}
+
+// An advice renderer should only advise during the player stage.
+func TestAdviceRenderer_Render_NotPlaying(t *testing.T) {
+	board := &game.Board{}
+	board.BeginHeadless(game.DefaultRules())
+	advice := &AdviceRenderer{board: board, Enabled: true}
+
+	assert.Equal(t, "Advice is given on your turn to play", advice.Render())
+}
+
+// Deviating from the advised play should be flagged in the log.
+func TestAdviceRenderer_CheckDeviation(t *testing.T) {
+	board := dealEleven()
+	advice := &AdviceRenderer{board: board, Enabled: true}
+	actions := board.Stage.Actions(board)
+
+	advice.CheckDeviation(actions["d"])
+	assert.NotContains(t, board.Log.Render(), "Basic strategy")
+
+	advice.CheckDeviation(actions["h"])
+	assert.Contains(t, board.Log.Render(), "[Basic strategy was to double down](fg-yellow)")
+}
+
+// Analysing should put the exact value of each play in the log, while the
+// board carries on logging other events.
+func TestAdviceRenderer_Analyse(t *testing.T) {
+	board := dealEleven()
+	advice := &AdviceRenderer{board: board}
+
+	done := advice.Analyse()
+	board.Log.Push("Player waits")
+	<-done
+	assert.Contains(t, board.Log.Render(), "[Exact values: Double Down +")
+	assert.Contains(t, board.Log.Render(), "Player waits")
+}
+
 // A null rendered should render to an empty string.
 func TestNullRenderer_Render(t *testing.T) {
 	nullRenderer := NullRenderer{}
 	assert.Equal(t, "", nullRenderer.Render())
 }
+
+//
+// Replay
+//
+
+// The arrow keys should step a replay forwards and back on the display.
+func TestDisplay_AttachReplay(t *testing.T) {
+	board := &game.Board{
+		Deck:    &cards.Deck{Random: cards.NewSeededRandomiser(5)},
+		History: &game.History{},
+	}
+	engine := game.NewEngine(board, game.DefaultRules())
+	engine.Execute(0, game.Command{Type: game.CommandDeal})
+	for len(board.History.Rounds) == 0 {
+		engine.Execute(0, game.Command{Type: game.CommandStand})
+		engine.Execute(0, game.Command{Type: game.CommandDeclineInsurance})
+	}
+	replay, err := game.NewReplay(board.History.Rounds[0])
+	assert.NoError(t, err)
+
+	display := Display{}
+	display.initViews()
+	display.AttachReplay(replay)
+	assert.Contains(t, display.actionsView.renderer.Render(), "step 0 of 1")
+	assert.Contains(t, display.actionsView.renderer.Render(), "Next: [Player: stand]")
+
+	display.stepReplay("<right>")
+	assert.Equal(t, 1, replay.Step())
+	assert.Equal(t, replay.Board, display.board)
+	assert.Contains(t, display.actionsView.renderer.Render(), "End of round")
+
+	display.stepReplay("<left>")
+	assert.Equal(t, 0, replay.Step())
+	assert.Equal(t, replay.Board.Deck, display.deckView.renderer)
+}
+
+//
+// Remote
+//
+
+// A remote table should be shown from the state its server sends, with keys
+// sent to the server as actions.
+func TestDisplay_AttachRemote(t *testing.T) {
+	board := &game.Board{Players: []*game.Player{
+		game.NewPlayer("Player 1", 100, nil),
+		game.NewPlayer("Player 2", 50, nil),
+	}}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	go table.NewServer(game.NewEngine(board, game.DefaultRules())).Serve(listener)
+	client, err := table.Dial(listener.Addr().String(), "Ann")
+	assert.NoError(t, err)
+	defer client.Close()
+
+	display := Display{}
+	display.initViews()
+	display.AttachRemote(client)
+	assert.Equal(t, "Playing as [Ann](fg-bold,fg-magenta)", display.adviceView.renderer.Render())
+	assert.Contains(t, display.actionsView.renderer.Render(), "[d](fg-bold,fg-green): Deal")
+	assert.NotContains(t, display.actionsView.renderer.Render(), "Change seat")
+
+	display.sendRemote("x")
+	display.sendRemote("d")
+	for client.State().State.Stage == "betting" {
+		select {
+		case <-client.Changed():
+		case <-time.After(time.Second * 2):
+			t.Fatal("timed out waiting for the deal")
+		}
+	}
+	assert.Contains(t, display.dealerView.renderer.Render(), "🂠 ?")
+	assert.Contains(t, display.playerView.renderer.Render(), "Player 2")
+	assert.Contains(t, display.balanceView.renderer.Render(), "Ann: [£95.00](fg-green)")
+	assert.Contains(t, display.eventLogView.renderer.Render(), ">> [Ann: Deal]")
+	assert.NoError(t, client.Err())
+}